@@ -208,7 +208,12 @@ func (s *WebIdentityService) callback(ctx context.Context, request *web.Request)
 	broker := request.Params["broker"]
 	for _, provider := range s.identityProviders {
 		if provider.Broker() == broker {
-			return provider.(WebCallbacker).Callback(ctx, request, s.getRedirectURL)
+			result := provider.(WebCallbacker).Callback(ctx, request, s.getRedirectURL)
+			// a new session id after a successful login prevents session fixation
+			if identity, _ := provider.Identify(ctx, request); identity != nil {
+				request.Session().Regenerate()
+			}
+			return result
 		}
 	}
 	return nil
@@ -230,6 +235,7 @@ func (s *WebIdentityService) logoutRedirect(request *web.Request, postLogoutRedi
 }
 
 func (s *WebIdentityService) logout(ctx context.Context, request *web.Request, postLogoutRedirect *url.URL, broker string, all bool) web.Result {
+	request.Session().Regenerate()
 	s.storeLogoutRedirects(request, redirectURLlist{})
 
 	for _, provider := range s.identityProviders {
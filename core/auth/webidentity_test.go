@@ -2,10 +2,14 @@ package auth
 
 import (
 	"context"
+	"net/url"
 	"testing"
 
+	"github.com/gorilla/sessions"
 	"github.com/stretchr/testify/assert"
+	"github.com/zemirco/memorystore"
 
+	"flamingo.me/flamingo/v3/framework/flamingo"
 	"flamingo.me/flamingo/v3/framework/web"
 )
 
@@ -85,3 +89,76 @@ func Test_WebIdentityServiceIdentifyAs(t *testing.T) {
 		assert.NoError(t, refresher.RefreshIdentity(context.Background(), nil))
 	})
 }
+
+type testCallbackIdentifier struct {
+	loggedIn bool
+}
+
+func (*testCallbackIdentifier) Broker() string {
+	return "callback"
+}
+
+func (i *testCallbackIdentifier) Identify(context.Context, *web.Request) (Identity, error) {
+	if i.loggedIn {
+		return &testIdentity{}, nil
+	}
+	return nil, nil
+}
+
+func (i *testCallbackIdentifier) Callback(_ context.Context, request *web.Request, _ func(*web.Request) *url.URL) web.Result {
+	i.loggedIn = request.Params["code"] == "valid"
+	return &web.Response{}
+}
+
+func (i *testCallbackIdentifier) Logout(context.Context, *web.Request) {
+	i.loggedIn = false
+}
+
+func Test_WebIdentityServiceRegeneratesSession(t *testing.T) {
+	sessionStore := new(web.SessionStore).Inject(new(flamingo.NullLogger), &struct {
+		SessionStore sessions.Store `inject:",optional"`
+		SessionName  string         `inject:"config:flamingo.session.name,optional"`
+		SaveMode     string         `inject:"config:flamingo.session.saveMode"`
+	}{SessionStore: memorystore.NewMemoryStore([]byte("flamingosecret")), SessionName: "test"})
+
+	// request saves the session and returns if the id has changed
+	request := func(t *testing.T, code string, handle func(context.Context, *WebIdentityService, *web.Request)) bool {
+		t.Helper()
+
+		session, err := sessionStore.LoadByID(context.Background(), "")
+		assert.NoError(t, err)
+		_, err = sessionStore.Save(context.Background(), session)
+		assert.NoError(t, err)
+		id := session.ID()
+
+		identifier := &testCallbackIdentifier{loggedIn: code == ""}
+		service := &WebIdentityService{identityProviders: []RequestIdentifier{identifier}, eventRouter: new(flamingo.DefaultEventRouter), responder: new(web.Responder)}
+
+		req := web.CreateRequest(nil, session)
+		req.Params = web.RequestParams{"broker": "callback", "code": code}
+		handle(context.Background(), service, req)
+
+		_, err = sessionStore.Save(context.Background(), req.Session())
+		assert.NoError(t, err)
+
+		return id != req.Session().ID()
+	}
+
+	callback := func(ctx context.Context, service *WebIdentityService, req *web.Request) {
+		assert.NotNil(t, service.callback(ctx, req))
+	}
+
+	t.Run("successful callback", func(t *testing.T) {
+		assert.True(t, request(t, "valid", callback))
+	})
+
+	t.Run("failed callback", func(t *testing.T) {
+		assert.False(t, request(t, "invalid", callback))
+	})
+
+	t.Run("logout", func(t *testing.T) {
+		assert.True(t, request(t, "", func(ctx context.Context, service *WebIdentityService, req *web.Request) {
+			service.Logout(ctx, req, nil)
+		}))
+	})
+}
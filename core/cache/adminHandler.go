@@ -0,0 +1,193 @@
+package cache
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+)
+
+type (
+	backendProvider func() map[string]Backend
+
+	// adminHandler inspects and purges the named backends on the systemendpoint
+	adminHandler struct {
+		backendProvider backendProvider
+		path            string
+	}
+
+	adminEntry struct {
+		Key          string     `json:"key"`
+		Tags         []string   `json:"tags"`
+		Lifetime     *time.Time `json:"lifetime,omitempty"`
+		Gracetime    *time.Time `json:"gracetime,omitempty"`
+		StaleIfError *time.Time `json:"staleIfError,omitempty"`
+		Error        string     `json:"error,omitempty"`
+	}
+)
+
+var (
+	// ErrKeysNotSupported is returned by backends which can not list their keys
+	ErrKeysNotSupported = errors.New("backend does not support listing keys")
+)
+
+// Inject dependencies
+func (h *adminHandler) Inject(
+	backendProvider backendProvider,
+	config *struct {
+		Path string `inject:"config:core.cache.admin.path"`
+	},
+) *adminHandler {
+	h.backendProvider = backendProvider
+	h.path = config.Path
+
+	return h
+}
+
+// ServeHTTP routes the admin requests:
+//
+//	GET  {path}                          names of all backends
+//	GET  {path}{backend}/keys            keys of the backend
+//	GET  {path}{backend}/entry?key=...   meta data of an entry
+//	POST {path}{backend}/purge?key=...&tag=...
+//	POST {path}{backend}/flush
+func (h *adminHandler) ServeHTTP(rw http.ResponseWriter, req *http.Request) {
+	backends := h.backendProvider()
+
+	name, action, _ := strings.Cut(strings.Trim(strings.TrimPrefix(req.URL.Path, h.path), "/"), "/")
+	if name == "" {
+		if req.Method != http.MethodGet {
+			writeAdminError(rw, http.StatusMethodNotAllowed, errors.New("method not allowed"))
+			return
+		}
+
+		names := make([]string, 0, len(backends))
+		for name := range backends {
+			names = append(names, name)
+		}
+		slices.Sort(names)
+
+		writeAdminJSON(rw, http.StatusOK, map[string][]string{"backends": names})
+		return
+	}
+
+	backend, ok := backends[name]
+	if !ok {
+		writeAdminError(rw, http.StatusNotFound, errors.New("unknown backend "+name))
+		return
+	}
+
+	method := map[string]string{"keys": http.MethodGet, "entry": http.MethodGet, "purge": http.MethodPost, "flush": http.MethodPost}[action]
+	if method == "" {
+		writeAdminError(rw, http.StatusNotFound, errors.New("unknown action "+action))
+		return
+	}
+	if req.Method != method {
+		writeAdminError(rw, http.StatusMethodNotAllowed, errors.New("method not allowed"))
+		return
+	}
+
+	switch action {
+	case "keys":
+		h.keys(rw, backend)
+	case "entry":
+		h.entry(rw, req, backend)
+	case "purge":
+		h.purge(rw, req, backend)
+	case "flush":
+		if err := backend.Flush(); errors.Is(err, ErrFlushWithoutKeyPrefix) {
+			writeAdminError(rw, http.StatusNotImplemented, err)
+			return
+		} else if err != nil {
+			writeAdminError(rw, http.StatusInternalServerError, err)
+			return
+		}
+		rw.WriteHeader(http.StatusNoContent)
+	}
+}
+
+func (h *adminHandler) keys(rw http.ResponseWriter, backend Backend) {
+	lister, ok := backend.(KeyLister)
+	if !ok {
+		writeAdminError(rw, http.StatusNotImplemented, ErrKeysNotSupported)
+		return
+	}
+
+	keys, err := lister.Keys()
+	if errors.Is(err, ErrKeysNotSupported) {
+		writeAdminError(rw, http.StatusNotImplemented, err)
+		return
+	}
+	if err != nil {
+		writeAdminError(rw, http.StatusInternalServerError, err)
+		return
+	}
+	slices.Sort(keys)
+
+	writeAdminJSON(rw, http.StatusOK, map[string][]string{"keys": keys})
+}
+
+func (h *adminHandler) entry(rw http.ResponseWriter, req *http.Request, backend Backend) {
+	key := req.URL.Query().Get("key")
+	entry, found := backend.Get(key)
+	if !found {
+		writeAdminError(rw, http.StatusNotFound, errors.New("no entry for "+key))
+		return
+	}
+
+	response := adminEntry{
+		Key:          key,
+		Tags:         entry.Meta.Tags,
+		Lifetime:     adminTime(entry.Meta.lifetime),
+		Gracetime:    adminTime(entry.Meta.gracetime),
+		StaleIfError: adminTime(entry.Meta.staleIfError),
+	}
+	if entry.Meta.err != nil {
+		response.Error = entry.Meta.err.Error()
+	}
+
+	writeAdminJSON(rw, http.StatusOK, response)
+}
+
+func (h *adminHandler) purge(rw http.ResponseWriter, req *http.Request, backend Backend) {
+	query := req.URL.Query()
+	keys, tags := query["key"], query["tag"]
+	if len(keys) == 0 && len(tags) == 0 {
+		writeAdminError(rw, http.StatusBadRequest, errors.New("key or tag is required"))
+		return
+	}
+
+	var errs []error
+	for _, key := range keys {
+		errs = append(errs, backend.Purge(key))
+	}
+	if len(tags) > 0 {
+		errs = append(errs, backend.PurgeTags(tags))
+	}
+
+	if err := errors.Join(errs...); err != nil {
+		writeAdminError(rw, http.StatusInternalServerError, err)
+		return
+	}
+
+	rw.WriteHeader(http.StatusNoContent)
+}
+
+func adminTime(t time.Time) *time.Time {
+	if t.IsZero() {
+		return nil
+	}
+	return &t
+}
+
+func writeAdminError(rw http.ResponseWriter, status int, err error) {
+	writeAdminJSON(rw, status, map[string]string{"error": err.Error()})
+}
+
+func writeAdminJSON(rw http.ResponseWriter, status int, body interface{}) {
+	rw.Header().Set("Content-Type", "application/json; charset=utf-8")
+	rw.WriteHeader(status)
+	_ = json.NewEncoder(rw).Encode(body)
+}
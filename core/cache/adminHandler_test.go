@@ -0,0 +1,90 @@
+package cache
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAdminHandler(t *testing.T) {
+	t.Parallel()
+
+	products := NewInMemoryCache()
+	require.NoError(t, products.Set("product-1", &Entry{Meta: Meta{Tags: []string{"product"}, lifetime: time.Now().Add(time.Minute)}, Data: "1"}))
+	require.NoError(t, products.Set("product-2", &Entry{Meta: Meta{Tags: []string{"product"}}, Data: "2"}))
+	require.NoError(t, products.Set("category-1", &Entry{Meta: Meta{Tags: []string{"category"}, err: errors.New("unavailable")}}))
+
+	handler := new(adminHandler).Inject(
+		func() map[string]Backend { return map[string]Backend{"products": products, "null": new(NullBackend)} },
+		&struct {
+			Path string `inject:"config:core.cache.admin.path"`
+		}{Path: "/cache/"},
+	)
+
+	serve := func(method, target string) *httptest.ResponseRecorder {
+		recorder := httptest.NewRecorder()
+		handler.ServeHTTP(recorder, httptest.NewRequest(method, target, nil))
+		return recorder
+	}
+
+	t.Run("backends", func(t *testing.T) {
+		recorder := serve(http.MethodGet, "/cache/")
+		assert.Equal(t, http.StatusOK, recorder.Code)
+		assert.JSONEq(t, `{"backends": ["null", "products"]}`, recorder.Body.String())
+
+		assert.Equal(t, http.StatusNotFound, serve(http.MethodGet, "/cache/unknown/keys").Code)
+		assert.Equal(t, http.StatusNotFound, serve(http.MethodGet, "/cache/products/unknown").Code)
+		assert.Equal(t, http.StatusMethodNotAllowed, serve(http.MethodGet, "/cache/products/flush").Code)
+	})
+
+	t.Run("keys", func(t *testing.T) {
+		recorder := serve(http.MethodGet, "/cache/products/keys")
+		assert.Equal(t, http.StatusOK, recorder.Code)
+		assert.JSONEq(t, `{"keys": ["category-1", "product-1", "product-2"]}`, recorder.Body.String())
+
+		assert.Equal(t, http.StatusNotImplemented, serve(http.MethodGet, "/cache/null/keys").Code)
+	})
+
+	t.Run("entry", func(t *testing.T) {
+		recorder := serve(http.MethodGet, "/cache/products/entry?key=product-1")
+		assert.Equal(t, http.StatusOK, recorder.Code)
+
+		var entry adminEntry
+		require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &entry))
+		assert.Equal(t, "product-1", entry.Key)
+		assert.Equal(t, []string{"product"}, entry.Tags)
+		assert.NotNil(t, entry.Lifetime)
+		assert.Nil(t, entry.Gracetime)
+
+		recorder = serve(http.MethodGet, "/cache/products/entry?key=category-1")
+		assert.Contains(t, recorder.Body.String(), `"error":"unavailable"`)
+
+		assert.Equal(t, http.StatusNotFound, serve(http.MethodGet, "/cache/products/entry?key=unknown").Code)
+	})
+
+	t.Run("purge", func(t *testing.T) {
+		assert.Equal(t, http.StatusBadRequest, serve(http.MethodPost, "/cache/products/purge").Code)
+
+		assert.Equal(t, http.StatusNoContent, serve(http.MethodPost, "/cache/products/purge?key=category-1").Code)
+		_, found := products.Get("category-1")
+		assert.False(t, found)
+
+		assert.Equal(t, http.StatusNoContent, serve(http.MethodPost, "/cache/products/purge?tag=product").Code)
+		_, found = products.Get("product-1")
+		assert.False(t, found)
+	})
+
+	t.Run("flush", func(t *testing.T) {
+		require.NoError(t, products.Set("product-3", &Entry{Data: "3"}))
+
+		assert.Equal(t, http.StatusNoContent, serve(http.MethodPost, "/cache/products/flush").Code)
+		_, found := products.Get("product-3")
+		assert.False(t, found)
+	})
+}
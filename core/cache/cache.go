@@ -11,7 +11,10 @@ type (
 	Meta struct {
 		Tags                []string
 		Lifetime, Gracetime time.Duration
-		lifetime, gracetime time.Time
+		// StaleIfError is the time after the gracetime during which the entry is served if loading fails
+		StaleIfError                      time.Duration
+		lifetime, gracetime, staleIfError time.Time
+		err                               error
 	}
 
 	// Entry is a cached object with associated meta data
@@ -29,9 +32,27 @@ type (
 		Flush() error
 	}
 
+	// KeyLister is implemented by backends which can list the keys of their entries
+	KeyLister interface {
+		Keys() ([]string, error)
+	}
+
+	// EntryCounter is implemented by backends which can cheaply count their entries, the count is recorded as metric
+	EntryCounter interface {
+		Len() int
+	}
+
 	loaderResponse struct {
 		data interface{}
 		meta *Meta
 		span trace.SpanContext
 	}
 )
+
+// validUntil is the time until the entry might be served
+func (m Meta) validUntil() time.Time {
+	if m.staleIfError.After(m.gracetime) {
+		return m.staleIfError
+	}
+	return m.gracetime
+}
@@ -0,0 +1,92 @@
+package cache
+
+import (
+	"bytes"
+	"encoding/gob"
+	"encoding/json"
+
+	"github.com/vmihailenco/msgpack/v5"
+)
+
+type (
+	// Codec serializes Entry.Data for backends which store bytes
+	Codec interface {
+		Encode(data interface{}) ([]byte, error)
+		Decode(b []byte) (interface{}, error)
+	}
+
+	// GobCodec encodes data with encoding/gob. The concrete types of all cached values must be registered once,
+	// with gob.Register or NewGobCodec, in every process reading or writing the entries
+	GobCodec struct{}
+
+	// JSONCodec encodes data as JSON and decodes it into T
+	JSONCodec[T any] struct{}
+
+	// MsgpackCodec encodes data as MessagePack and decodes it into T
+	MsgpackCodec[T any] struct{}
+)
+
+var (
+	_ Codec = GobCodec{}
+	_ Codec = JSONCodec[string]{}
+	_ Codec = MsgpackCodec[string]{}
+)
+
+// NewGobCodec registers the types of the given values with gob, it is meant to be called once during setup
+func NewGobCodec(types ...interface{}) GobCodec {
+	for _, t := range types {
+		gob.Register(t)
+	}
+
+	return GobCodec{}
+}
+
+// Encode the data as gob interface value, the type of data must be registered
+func (GobCodec) Encode(data interface{}) ([]byte, error) {
+	b := new(bytes.Buffer)
+	if err := gob.NewEncoder(b).Encode(&data); err != nil {
+		return nil, err
+	}
+
+	return b.Bytes(), nil
+}
+
+// Decode a gob interface value
+func (GobCodec) Decode(b []byte) (interface{}, error) {
+	var data interface{}
+	if err := gob.NewDecoder(bytes.NewReader(b)).Decode(&data); err != nil {
+		return nil, err
+	}
+
+	return data, nil
+}
+
+// Encode the data as JSON
+func (JSONCodec[T]) Encode(data interface{}) ([]byte, error) {
+	return json.Marshal(data)
+}
+
+// Decode the JSON into T
+func (JSONCodec[T]) Decode(b []byte) (interface{}, error) {
+	var data T
+	if err := json.Unmarshal(b, &data); err != nil {
+		return nil, err
+	}
+
+	return data, nil
+}
+
+// Encode the data as MessagePack
+func (MsgpackCodec[T]) Encode(data interface{}) ([]byte, error) {
+	return msgpack.Marshal(data)
+}
+
+// Decode the MessagePack into T
+func (MsgpackCodec[T]) Decode(b []byte) (interface{}, error) {
+	var data T
+	if err := msgpack.Unmarshal(b, &data); err != nil {
+		return nil, err
+	}
+
+	return data, nil
+}
@@ -2,84 +2,469 @@ package cache
 
 import (
 	"bytes"
+	"container/list"
+	"context"
 	"encoding/gob"
+	"errors"
+	"fmt"
 	"os"
 	"path/filepath"
 	"regexp"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"flamingo.me/flamingo/v3/framework/flamingo"
 )
 
 type (
-	// FileBackend is a cache backend which saves the data in files
+	// FileBackend is a cache backend which saves the data in files.
+	// Files are written atomically, least recently used entries are evicted if the maximum size is exceeded,
+	// and entries past their gracetime are deleted by a background sweeper, every minute by default.
 	FileBackend struct {
 		baseDir string
+		codec   Codec
+		maxSize int64
+
+		mu      sync.Mutex
+		ticker  *time.Ticker
+		stop    chan struct{}
+		indexed bool
+		index   map[string]*list.Element
+		lru     *list.List
+		size    int64
+	}
+
+	// fileIndexEntry tracks size, tags and validity of a file, the lru list holds the most recently used file first
+	fileIndexEntry struct {
+		name       string
+		size       int64
+		tags       []string
+		validUntil time.Time
+	}
+
+	// fileEntry is the gob encoded content of a file, the data is encoded with the codec of the backend
+	fileEntry struct {
+		Tags                              []string
+		Lifetime, Gracetime, StaleIfError time.Duration
+		LifetimeAt, GracetimeAt           int64
+		StaleIfErrorAt, ValidUntil        int64
+		Error                             string
+		Data                              []byte
 	}
 )
 
-const defaultBaseDir = "/tmp/cache"
+const (
+	defaultBaseDir = "/tmp/cache"
+	// tempFilePattern contains a "-", which never occurs in escaped keys
+	tempFilePattern = "tmp-*"
+)
 
 var (
 	escape = regexp.MustCompile(`[^a-zA-Z0-9.]`)
+
+	_ Backend      = new(FileBackend)
+	_ EntryCounter = new(FileBackend)
 )
 
-// NewFileBackend returns a FileBackend operating in the given baseDir
+// NewFileBackend returns a FileBackend operating in the given baseDir, data is encoded with the GobCodec by default.
+// The sweeper is started with an interval of one minute.
 func NewFileBackend(baseDir string) *FileBackend {
 	if baseDir == "" {
 		baseDir = defaultBaseDir
 	}
 
-	return &FileBackend{
+	fb := &FileBackend{
 		baseDir: baseDir,
+		codec:   GobCodec{},
+	}
+
+	return fb.WithSweepInterval(lurkerPeriod)
+}
+
+// WithCodec sets the codec for Entry.Data
+func (fb *FileBackend) WithCodec(codec Codec) *FileBackend {
+	fb.codec = codec
+	return fb
+}
+
+// WithMaxSize limits the total size of all files in bytes, least recently used entries are evicted first.
+// A size of 0 disables the limit.
+func (fb *FileBackend) WithMaxSize(maxSize int64) *FileBackend {
+	fb.mu.Lock()
+	defer fb.mu.Unlock()
+
+	fb.maxSize = maxSize
+	fb.loadIndex()
+	fb.evict()
+
+	return fb
+}
+
+// WithSweepInterval changes the interval of the sweeper, which deletes entries past their gracetime.
+// The sweeper runs until Close is called or the flamingo.ShutdownEvent is dispatched,
+// so bind the backend with flamingo.BindEventSubscriber.
+func (fb *FileBackend) WithSweepInterval(interval time.Duration) *FileBackend {
+	if interval <= 0 {
+		return fb
+	}
+
+	fb.mu.Lock()
+	defer fb.mu.Unlock()
+
+	if fb.ticker != nil {
+		fb.ticker.Reset(interval)
+		return fb
+	}
+
+	fb.ticker = time.NewTicker(interval)
+	fb.stop = make(chan struct{})
+	go fb.sweeper(fb.ticker, fb.stop)
+
+	return fb
+}
+
+// Close stops the sweeper
+func (fb *FileBackend) Close() {
+	fb.mu.Lock()
+	defer fb.mu.Unlock()
+
+	if fb.ticker != nil {
+		fb.ticker.Stop()
+		close(fb.stop)
+		fb.ticker, fb.stop = nil, nil
+	}
+}
+
+// Notify stops the sweeper on shutdown
+func (fb *FileBackend) Notify(_ context.Context, event flamingo.Event) {
+	if _, ok := event.(*flamingo.ShutdownEvent); ok {
+		fb.Close()
 	}
 }
 
 // Get reads a cache entry
 func (fb *FileBackend) Get(key string) (entry *Entry, found bool) {
-	key = escape.ReplaceAllString(key, ".")
+	name := escape.ReplaceAllString(key, ".")
 
-	b, err := os.ReadFile(filepath.Join(fb.baseDir, key))
+	b, err := os.ReadFile(filepath.Join(fb.baseDir, name))
 	if err != nil {
 		return nil, false
 	}
 
-	bb := bytes.NewBuffer(b)
-	d := gob.NewDecoder(bb)
-	entry = new(Entry)
-	err = d.Decode(&entry)
+	stored := new(fileEntry)
+	if err := gob.NewDecoder(bytes.NewReader(b)).Decode(stored); err != nil {
+		return nil, false
+	}
+
+	validUntil := fromUnixNano(stored.ValidUntil)
+	if !validUntil.IsZero() && validUntil.Before(time.Now()) {
+		_ = fb.Purge(key)
+		return nil, false
+	}
+
+	data, err := fb.codec.Decode(stored.Data)
 	if err != nil {
 		return nil, false
 	}
 
+	fb.mu.Lock()
+	fb.loadIndex()
+	fb.track(fileIndexEntry{name: name, size: int64(len(b)), tags: stored.Tags, validUntil: validUntil})
+	fb.mu.Unlock()
+
+	entry = &Entry{
+		Meta: Meta{
+			Tags:         stored.Tags,
+			Lifetime:     stored.Lifetime,
+			Gracetime:    stored.Gracetime,
+			StaleIfError: stored.StaleIfError,
+			lifetime:     fromUnixNano(stored.LifetimeAt),
+			gracetime:    fromUnixNano(stored.GracetimeAt),
+			staleIfError: fromUnixNano(stored.StaleIfErrorAt),
+		},
+		Data: data,
+	}
+	if stored.Error != "" {
+		//nolint:err113 // the original error type is lost in the file
+		entry.Meta.err = errors.New(stored.Error)
+	}
+
 	return entry, true
 }
 
-// Set writes a cache entry
+// Set writes a cache entry, the file is replaced atomically so readers never see partially written files
 func (fb *FileBackend) Set(key string, entry *Entry) error {
-	key = escape.ReplaceAllString(key, ".")
+	name := escape.ReplaceAllString(key, ".")
 
-	gob.Register(entry)
-	gob.Register(entry.Data)
+	data, err := fb.codec.Encode(entry.Data)
+	if err != nil {
+		return fmt.Errorf("file backend: failed to encode %q: %w", key, err)
+	}
+
+	validUntil := entry.Meta.validUntil()
+	if validUntil.IsZero() {
+		if ttl := entry.Meta.Lifetime + entry.Meta.Gracetime + entry.Meta.StaleIfError; ttl > 0 {
+			validUntil = time.Now().Add(ttl)
+		}
+	}
+
+	stored := fileEntry{
+		Tags:           entry.Meta.Tags,
+		Lifetime:       entry.Meta.Lifetime,
+		Gracetime:      entry.Meta.Gracetime,
+		StaleIfError:   entry.Meta.StaleIfError,
+		LifetimeAt:     unixNano(entry.Meta.lifetime),
+		GracetimeAt:    unixNano(entry.Meta.gracetime),
+		StaleIfErrorAt: unixNano(entry.Meta.staleIfError),
+		ValidUntil:     unixNano(validUntil),
+		Data:           data,
+	}
+	if entry.Meta.err != nil {
+		stored.Error = entry.Meta.err.Error()
+	}
 
 	b := new(bytes.Buffer)
-	err := gob.NewEncoder(b).Encode(entry)
+	if err := gob.NewEncoder(b).Encode(stored); err != nil {
+		return fmt.Errorf("file backend: failed to encode %q: %w", key, err)
+	}
+
+	if err := fb.write(name, b.Bytes()); err != nil {
+		return fmt.Errorf("file backend: failed to write %q: %w", key, err)
+	}
+
+	fb.mu.Lock()
+	defer fb.mu.Unlock()
+
+	fb.loadIndex()
+	fb.track(fileIndexEntry{name: name, size: int64(b.Len()), tags: entry.Meta.Tags, validUntil: validUntil})
+	fb.evict()
+
+	return nil
+}
+
+// write the file to a temporary file first, which is then renamed
+func (fb *FileBackend) write(name string, b []byte) error {
+	if err := os.MkdirAll(fb.baseDir, 0o755); err != nil {
+		return err
+	}
+
+	file, err := os.CreateTemp(fb.baseDir, tempFilePattern)
 	if err != nil {
 		return err
 	}
 
-	os.WriteFile(filepath.Join(fb.baseDir, key), b.Bytes(), os.ModePerm)
+	_, err = file.Write(b)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(file.Name(), filepath.Join(fb.baseDir, name))
+	}
+	if err != nil {
+		_ = os.Remove(file.Name())
+	}
 
-	return nil
+	return err
 }
 
 // Purge deletes a cache entry
 func (fb *FileBackend) Purge(key string) error {
-	key = escape.ReplaceAllString(key, ".")
-	os.Remove(filepath.Join(fb.baseDir, key))
+	name := escape.ReplaceAllString(key, ".")
+
+	fb.mu.Lock()
+	defer fb.mu.Unlock()
+
+	fb.loadIndex()
+	if element, ok := fb.index[name]; ok {
+		return fb.remove(element)
+	}
+
+	if err := os.Remove(filepath.Join(fb.baseDir, name)); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
 
 	return nil
 }
 
-// PurgeTags is not supported by FileBackend and does nothing
-func (*FileBackend) PurgeTags(tags []string) error { return nil }
+// PurgeTags deletes all entries with one of the tags
+func (fb *FileBackend) PurgeTags(tags []string) error {
+	fb.mu.Lock()
+	defer fb.mu.Unlock()
+
+	fb.loadIndex()
+
+	var errs []error
+	for element := fb.lru.Front(); element != nil; {
+		next := element.Next()
+		if slices.ContainsFunc(element.Value.(*fileIndexEntry).tags, func(tag string) bool { return slices.Contains(tags, tag) }) {
+			errs = append(errs, fb.remove(element))
+		}
+		element = next
+	}
+
+	return errors.Join(errs...)
+}
+
+// Flush deletes all entries
+func (fb *FileBackend) Flush() error {
+	fb.mu.Lock()
+	defer fb.mu.Unlock()
+
+	fb.loadIndex()
+
+	var errs []error
+	for element := fb.lru.Front(); element != nil; {
+		next := element.Next()
+		errs = append(errs, fb.remove(element))
+		element = next
+	}
+
+	return errors.Join(errs...)
+}
+
+// Len is the number of entries
+func (fb *FileBackend) Len() int {
+	fb.mu.Lock()
+	defer fb.mu.Unlock()
+
+	fb.loadIndex()
+
+	return fb.lru.Len()
+}
+
+// loadIndex reads all existing files once, the most recently modified files are considered most recently used
+func (fb *FileBackend) loadIndex() {
+	if fb.indexed {
+		return
+	}
+
+	fb.indexed = true
+	fb.index = make(map[string]*list.Element)
+	fb.lru = list.New()
+	fb.size = 0
+
+	dirEntries, err := os.ReadDir(fb.baseDir)
+	if err != nil {
+		return
+	}
+
+	type indexedFile struct {
+		fileIndexEntry
+		modTime time.Time
+	}
+
+	files := make([]indexedFile, 0, len(dirEntries))
+	for _, dirEntry := range dirEntries {
+		if dirEntry.IsDir() {
+			continue
+		}
+
+		// temporary files of running writes
+		if strings.Contains(dirEntry.Name(), "-") {
+			continue
+		}
+
+		info, err := dirEntry.Info()
+		if err != nil {
+			continue
+		}
+
+		file := indexedFile{fileIndexEntry: fileIndexEntry{name: dirEntry.Name(), size: info.Size()}, modTime: info.ModTime()}
+		if b, err := os.ReadFile(filepath.Join(fb.baseDir, dirEntry.Name())); err == nil {
+			stored := new(fileEntry)
+			if gob.NewDecoder(bytes.NewReader(b)).Decode(stored) == nil {
+				file.tags = stored.Tags
+				file.validUntil = fromUnixNano(stored.ValidUntil)
+			}
+		}
+
+		files = append(files, file)
+	}
 
-// Flush is not supported by FileBackend and does nothing
-func (*FileBackend) Flush() error { return nil }
+	slices.SortFunc(files, func(a, b indexedFile) int {
+		return a.modTime.Compare(b.modTime)
+	})
+
+	for _, file := range files {
+		fb.track(file.fileIndexEntry)
+	}
+}
+
+// track adds or updates the file as most recently used
+func (fb *FileBackend) track(entry fileIndexEntry) {
+	if element, ok := fb.index[entry.name]; ok {
+		fb.size -= element.Value.(*fileIndexEntry).size
+		element.Value = &entry
+		fb.lru.MoveToFront(element)
+	} else {
+		fb.index[entry.name] = fb.lru.PushFront(&entry)
+	}
+
+	fb.size += entry.size
+}
+
+// evict least recently used files until the total size is within the maximum size
+func (fb *FileBackend) evict() {
+	for fb.maxSize > 0 && fb.size > fb.maxSize && fb.lru.Len() > 0 {
+		_ = fb.remove(fb.lru.Back())
+	}
+}
+
+// remove the file and its index entry
+func (fb *FileBackend) remove(element *list.Element) error {
+	entry := element.Value.(*fileIndexEntry)
+
+	fb.lru.Remove(element)
+	delete(fb.index, entry.name)
+	fb.size -= entry.size
+
+	if err := os.Remove(filepath.Join(fb.baseDir, entry.name)); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+
+	return nil
+}
+
+// sweep deletes all entries past their gracetime
+func (fb *FileBackend) sweep() {
+	fb.mu.Lock()
+	defer fb.mu.Unlock()
+
+	fb.loadIndex()
+
+	now := time.Now()
+	for element := fb.lru.Front(); element != nil; {
+		next := element.Next()
+		if validUntil := element.Value.(*fileIndexEntry).validUntil; !validUntil.IsZero() && validUntil.Before(now) {
+			_ = fb.remove(element)
+		}
+		element = next
+	}
+}
+
+func (fb *FileBackend) sweeper(ticker *time.Ticker, stop <-chan struct{}) {
+	for {
+		select {
+		case <-ticker.C:
+			fb.sweep()
+		case <-stop:
+			return
+		}
+	}
+}
+
+func unixNano(t time.Time) int64 {
+	if t.IsZero() {
+		return 0
+	}
+	return t.UnixNano()
+}
+
+func fromUnixNano(nanos int64) time.Time {
+	if nanos == 0 {
+		return time.Time{}
+	}
+	return time.Unix(0, nanos)
+}
@@ -1,17 +1,23 @@
 package cache_test
 
 import (
+	"context"
 	"encoding/gob"
 	"flag"
+	"fmt"
 	"os"
 	"path/filepath"
 	"reflect"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
 	"flamingo.me/flamingo/v3/core/cache"
+	"flamingo.me/flamingo/v3/framework/flamingo"
 )
 
 type (
@@ -209,3 +215,168 @@ func TestFileBackendPurge(t *testing.T) {
 		})
 	}
 }
+
+func TestFileBackendCodecs(t *testing.T) {
+	t.Parallel()
+
+	codecs := map[string]cache.Codec{
+		"gob":     cache.GobCodec{},
+		"json":    cache.JSONCodec[testStruct]{},
+		"msgpack": cache.MsgpackCodec[testStruct]{},
+	}
+
+	for name, codec := range codecs {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			gob.Register(testStruct{})
+			f := cache.NewFileBackend(t.TempDir()).WithCodec(codec)
+			t.Cleanup(f.Close)
+
+			entry := &cache.Entry{
+				Meta: cache.Meta{Tags: []string{"tag"}, Lifetime: time.Minute},
+				Data: testStruct{S: "string", B: true, I: -17},
+			}
+			require.NoError(t, f.Set("struct", entry))
+
+			actual, found := f.Get("struct")
+			require.True(t, found)
+			assert.Equal(t, entry.Data, actual.Data)
+			assert.Equal(t, entry.Meta.Tags, actual.Meta.Tags)
+		})
+	}
+}
+
+func TestFileBackendMaxSize(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	f := cache.NewFileBackend(dir)
+	t.Cleanup(f.Close)
+
+	require.NoError(t, f.Set("probe", &cache.Entry{Data: strings.Repeat("x", 100)}))
+	info, err := os.Stat(filepath.Join(dir, "probe"))
+	require.NoError(t, err)
+	require.NoError(t, f.Purge("probe"))
+
+	f.WithMaxSize(3 * info.Size())
+
+	for _, key := range []string{"a", "b", "c"} {
+		require.NoError(t, f.Set(key, &cache.Entry{Data: strings.Repeat("x", 100)}))
+	}
+
+	_, found := f.Get("a")
+	require.True(t, found, "a is now the most recently used entry")
+
+	require.NoError(t, f.Set("d", &cache.Entry{Data: strings.Repeat("x", 100)}))
+
+	_, found = f.Get("b")
+	assert.False(t, found, "the least recently used entry is evicted")
+	for _, key := range []string{"a", "c", "d"} {
+		_, found = f.Get(key)
+		assert.True(t, found, key)
+	}
+	assert.Equal(t, 3, f.Len())
+
+	t.Run("existing files are indexed", func(t *testing.T) {
+		other := cache.NewFileBackend(dir).WithMaxSize(info.Size())
+		t.Cleanup(other.Close)
+
+		assert.Equal(t, 1, other.Len())
+	})
+}
+
+func TestFileBackendSweeper(t *testing.T) {
+	t.Parallel()
+
+	f := cache.NewFileBackend(t.TempDir()).WithSweepInterval(10 * time.Millisecond)
+	t.Cleanup(f.Close)
+
+	require.NoError(t, f.Set("expiring", &cache.Entry{Meta: cache.Meta{Lifetime: 10 * time.Millisecond, Gracetime: 10 * time.Millisecond}, Data: "foo"}))
+	require.NoError(t, f.Set("permanent", &cache.Entry{Data: "bar"}))
+
+	assert.Eventually(t, func() bool {
+		return f.Len() == 1
+	}, time.Second, 5*time.Millisecond)
+
+	_, found := f.Get("permanent")
+	assert.True(t, found)
+
+	t.Run("stopped on shutdown", func(t *testing.T) {
+		f.Notify(context.Background(), new(flamingo.ShutdownEvent))
+		require.NoError(t, f.Set("expiring", &cache.Entry{Meta: cache.Meta{Lifetime: time.Millisecond}, Data: "foo"}))
+
+		time.Sleep(50 * time.Millisecond)
+		assert.Equal(t, 2, f.Len())
+	})
+}
+
+func TestFileBackendGobRegistration(t *testing.T) {
+	t.Parallel()
+
+	type unregistered struct{ Name string }
+	type registered struct{ Name string }
+
+	f := cache.NewFileBackend(t.TempDir()).WithCodec(cache.NewGobCodec(registered{}))
+	t.Cleanup(f.Close)
+
+	assert.Error(t, f.Set("key", &cache.Entry{Data: unregistered{Name: "foo"}}), "types are not registered on the fly")
+
+	require.NoError(t, f.Set("key", &cache.Entry{Data: registered{Name: "foo"}}))
+	entry, found := f.Get("key")
+	require.True(t, found)
+	assert.Equal(t, registered{Name: "foo"}, entry.Data)
+}
+
+func TestFileBackendPurgeTagsAndFlush(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	f := cache.NewFileBackend(dir)
+	t.Cleanup(f.Close)
+
+	for key, tags := range map[string][]string{"foo": {"bar"}, "baz": {"bar", "qux"}, "quux": {"qux"}} {
+		require.NoError(t, f.Set(key, &cache.Entry{Meta: cache.Meta{Tags: tags}, Data: key}))
+	}
+
+	require.NoError(t, f.PurgeTags([]string{"bar"}))
+
+	_, found := f.Get("foo")
+	assert.False(t, found)
+	_, found = f.Get("baz")
+	assert.False(t, found)
+	_, found = f.Get("quux")
+	assert.True(t, found)
+
+	require.NoError(t, f.Flush())
+
+	files, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	assert.Empty(t, files)
+}
+
+func TestFileBackendConcurrentWrites(t *testing.T) {
+	t.Parallel()
+
+	f := cache.NewFileBackend(t.TempDir())
+	t.Cleanup(f.Close)
+
+	require.NoError(t, f.Set("key", &cache.Entry{Data: "initial"}))
+
+	var wg sync.WaitGroup
+	for i := range 10 {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			assert.NoError(t, f.Set("key", &cache.Entry{Data: fmt.Sprintf("value %d %s", i, strings.Repeat("x", 10000))}))
+		}()
+		go func() {
+			defer wg.Done()
+			_, found := f.Get("key")
+			assert.True(t, found, "readers never see partially written files")
+		}()
+	}
+	wg.Wait()
+
+	assert.Equal(t, 1, f.Len())
+}
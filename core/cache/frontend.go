@@ -0,0 +1,257 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"time"
+
+	"go.opencensus.io/trace"
+	"golang.org/x/sync/singleflight"
+
+	"flamingo.me/flamingo/v3/framework/flamingo"
+)
+
+type (
+	// Loader loads the data of a cache entry, the Meta controls lifetime, gracetime and tags of the entry
+	Loader[T any] func(ctx context.Context) (T, *Meta, error)
+
+	// Frontend caches data of type T in a Backend.
+	// Entries are served during their lifetime, and during the gracetime while they are reloaded in the background.
+	// Concurrent loads of the same key are done in a single flight.
+	Frontend[T any] struct {
+		group        singleflight.Group
+		backend      Backend
+		logger       flamingo.Logger
+		name         string
+		errorTTL     time.Duration
+		staleIfError time.Duration
+		jitter       float64
+	}
+)
+
+const (
+	defaultLifetime  = 30 * time.Second
+	defaultGracetime = 10 * time.Minute
+)
+
+var (
+	// ErrNoBackend is returned if a frontend has no backend
+	ErrNoBackend = errors.New("NO backend in Cache")
+)
+
+// NewFrontend creates a Frontend for the backend
+func NewFrontend[T any](backend Backend, logger flamingo.Logger) *Frontend[T] {
+	return new(Frontend[T]).Inject(backend, logger)
+}
+
+// Inject Frontend dependencies
+func (f *Frontend[T]) Inject(backend Backend, logger flamingo.Logger) *Frontend[T] {
+	f.backend = backend
+	f.logger = logger
+	if f.logger == nil {
+		f.logger = flamingo.NullLogger{}
+	}
+	if f.name == "" {
+		f.name = "default"
+	}
+
+	return f
+}
+
+// WithName sets the name used in traces and logs
+func (f *Frontend[T]) WithName(name string) *Frontend[T] {
+	f.name = name
+	return f
+}
+
+// WithErrorTTL caches loader errors for the given duration, so a failing loader is not called for every request
+func (f *Frontend[T]) WithErrorTTL(ttl time.Duration) *Frontend[T] {
+	f.errorTTL = ttl
+	return f
+}
+
+// WithStaleIfError serves entries up to the given duration after their gracetime if the loader fails.
+// Loaders can set Meta.StaleIfError per entry.
+func (f *Frontend[T]) WithStaleIfError(staleIfError time.Duration) *Frontend[T] {
+	f.staleIfError = staleIfError
+	return f
+}
+
+// WithJitter shortens the lifetime of entries randomly by up to the given fraction (0 to 1),
+// so entries loaded at the same time do not expire at the same time
+func (f *Frontend[T]) WithJitter(fraction float64) *Frontend[T] {
+	f.jitter = min(max(fraction, 0), 1)
+	return f
+}
+
+// Get the entry for the key, the loader is called if there is no valid entry
+func (f *Frontend[T]) Get(ctx context.Context, key string, loader Loader[T]) (T, error) {
+	var zero T
+	if f.backend == nil {
+		return zero, ErrNoBackend
+	}
+
+	ctx, span := trace.StartSpan(ctx, "flamingo/cache/frontend/Get")
+	span.Annotate(nil, key)
+	span.AddAttributes(trace.StringAttribute("frontend", f.name))
+	defer span.End()
+
+	entry, found := f.backend.Get(key)
+	var data T
+	if found {
+		data, found = entry.Data.(T)
+	}
+
+	now := time.Now()
+	if found && entry.Meta.lifetime.After(now) {
+		record(ctx, f.name, hitMeasure.M(1))
+		if entry.Meta.err != nil {
+			f.log(ctx).Debug("Serving cached error", key)
+			return zero, entry.Meta.err
+		}
+
+		f.log(ctx).Debug("Serving from cache", key)
+		return data, nil
+	}
+
+	if found && entry.Meta.err == nil && entry.Meta.gracetime.After(now) {
+		record(ctx, f.name, graceHitMeasure.M(1))
+		go func() {
+			_, _ = f.load(ctx, key, loader, true)
+		}()
+
+		f.log(ctx).Debug("Gracetime! Serving from cache", key)
+		return data, nil
+	}
+
+	stale := found && entry.Meta.err == nil && entry.Meta.staleIfError.After(now)
+
+	record(ctx, f.name, missMeasure.M(1))
+	f.log(ctx).Debug("No cache entry for", key)
+	loaded, err := f.load(ctx, key, loader, stale)
+	if err != nil && stale {
+		f.log(ctx).Warn("Loading failed, serving stale entry", key, err)
+		return data, nil
+	}
+
+	return loaded, err
+}
+
+// load the entry in a single flight, detached from the cancellation of the requesting context
+func (f *Frontend[T]) load(ctx context.Context, key string, loader Loader[T], keepExistingEntry bool) (T, error) {
+	newContext := trace.NewContext(context.Background(), trace.FromContext(ctx))
+	if deadline, hasDeadline := ctx.Deadline(); hasDeadline {
+		var cancel context.CancelFunc
+		newContext, cancel = context.WithDeadline(newContext, deadline)
+		defer cancel()
+	}
+
+	newContext, span := trace.StartSpan(newContext, "flamingo/cache/frontend/load")
+	span.Annotate(nil, key)
+	span.AddAttributes(trace.StringAttribute("frontend", f.name))
+	defer span.End()
+
+	loaded, err, _ := f.group.Do(key, func() (res interface{}, resultErr error) {
+		ctx, fetchRoutineSpan := trace.StartSpan(newContext, "flamingo/cache/frontend/fetchRoutine")
+		fetchRoutineSpan.Annotate(nil, key)
+		defer fetchRoutineSpan.End()
+
+		start := time.Now()
+		defer func() {
+			recordLoad(ctx, f.name, start, resultErr)
+		}()
+
+		defer func() {
+			if err := recover(); err != nil {
+				if err2, ok := err.(error); ok {
+					resultErr = fmt.Errorf("cache frontend %s load: %w", f.name, err2)
+				} else {
+					//nolint:err113 // not worth introducing a dedicated error for this edge case
+					resultErr = fmt.Errorf("cache frontend %s load: %v", f.name, err)
+				}
+				res = loaderResponse{meta: new(Meta), span: fetchRoutineSpan.SpanContext()}
+			}
+		}()
+
+		data, meta, err := loader(ctx)
+		if meta == nil {
+			meta = &Meta{
+				Lifetime:  defaultLifetime,
+				Gracetime: defaultGracetime,
+			}
+		}
+
+		return loaderResponse{data, meta, fetchRoutineSpan.SpanContext()}, err
+	})
+
+	response, _ := loaded.(loaderResponse)
+	if response.meta == nil {
+		response.meta = new(Meta)
+	}
+
+	span.AddAttributes(trace.StringAttribute("parenttrace", response.span.TraceID.String()))
+	span.AddAttributes(trace.StringAttribute("parentspan", response.span.SpanID.String()))
+
+	data, _ := response.data.(T)
+
+	if err != nil {
+		if keepExistingEntry || f.errorTTL <= 0 {
+			//nolint:contextcheck // this log entry should be done in new context
+			f.log(newContext).Debug("No store/overwrite in cache because we couldn't fetch new data", key)
+			return data, err
+		}
+
+		now := time.Now()
+		//nolint:contextcheck // this log entry should be done in new context
+		f.log(newContext).Debug("Store error in cache", key, err)
+		_ = f.backend.Set(key, &Entry{
+			Data: *new(T),
+			Meta: Meta{
+				Tags:      response.meta.Tags,
+				Lifetime:  f.errorTTL,
+				lifetime:  now.Add(f.errorTTL),
+				gracetime: now.Add(f.errorTTL),
+				err:       err,
+			},
+		})
+
+		return data, err
+	}
+
+	//nolint:contextcheck // this log entry should be done in new context
+	f.log(newContext).Debug("Store in cache", key, response.meta)
+	_ = f.backend.Set(key, &Entry{
+		Data: data,
+		Meta: f.meta(*response.meta),
+	})
+	//nolint:contextcheck // the metric is recorded in new context
+	recordEntries(newContext, f.name, f.backend)
+
+	return data, nil
+}
+
+// meta calculates the absolute times of the entry, the lifetime is shortened by the jitter
+func (f *Frontend[T]) meta(meta Meta) Meta {
+	lifetime := meta.Lifetime
+	if f.jitter > 0 && lifetime > 0 {
+		lifetime -= time.Duration(rand.Float64() * f.jitter * float64(lifetime)) //nolint:gosec // no cryptographic randomness needed
+	}
+
+	staleIfError := meta.StaleIfError
+	if staleIfError == 0 {
+		staleIfError = f.staleIfError
+	}
+
+	now := time.Now()
+	meta.lifetime = now.Add(lifetime)
+	meta.gracetime = meta.lifetime.Add(meta.Gracetime)
+	meta.staleIfError = meta.gracetime.Add(staleIfError)
+
+	return meta
+}
+
+func (f *Frontend[T]) log(ctx context.Context) flamingo.Logger {
+	return f.logger.WithContext(ctx).WithField(flamingo.LogKeyCategory, "cacheFrontend").WithField("frontend", f.name)
+}
@@ -0,0 +1,212 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"flamingo.me/flamingo/v3/framework/flamingo"
+)
+
+type frontendTestProduct struct {
+	ID   string
+	Name string
+}
+
+func TestFrontend_Get(t *testing.T) {
+	t.Parallel()
+
+	frontend := NewFrontend[frontendTestProduct](NewInMemoryCache(), flamingo.NullLogger{})
+
+	var calls int32
+	loader := func(ctx context.Context) (frontendTestProduct, *Meta, error) {
+		atomic.AddInt32(&calls, 1)
+		return frontendTestProduct{ID: "1", Name: "flamingo"}, &Meta{Lifetime: time.Minute}, nil
+	}
+
+	product, err := frontend.Get(context.Background(), "product-1", loader)
+	require.NoError(t, err)
+	assert.Equal(t, frontendTestProduct{ID: "1", Name: "flamingo"}, product)
+
+	product, err = frontend.Get(context.Background(), "product-1", loader)
+	require.NoError(t, err)
+	assert.Equal(t, "flamingo", product.Name)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&calls))
+}
+
+func TestFrontend_GetNoBackend(t *testing.T) {
+	t.Parallel()
+
+	_, err := new(Frontend[string]).Get(context.Background(), "key", nil)
+	assert.ErrorIs(t, err, ErrNoBackend)
+}
+
+func TestFrontend_Gracetime(t *testing.T) {
+	t.Parallel()
+
+	frontend := NewFrontend[int32](NewInMemoryCache(), flamingo.NullLogger{})
+
+	var calls int32
+	loader := func(ctx context.Context) (int32, *Meta, error) {
+		return atomic.AddInt32(&calls, 1), &Meta{Lifetime: 20 * time.Millisecond, Gracetime: time.Minute}, nil
+	}
+
+	value, err := frontend.Get(context.Background(), "key", loader)
+	require.NoError(t, err)
+	assert.Equal(t, int32(1), value)
+
+	time.Sleep(40 * time.Millisecond)
+
+	value, err = frontend.Get(context.Background(), "key", loader)
+	require.NoError(t, err)
+	assert.Equal(t, int32(1), value, "the stale value is served during the gracetime")
+
+	assert.Eventually(t, func() bool {
+		value, err := frontend.Get(context.Background(), "key", loader)
+		return err == nil && value > 1
+	}, time.Second, 5*time.Millisecond)
+}
+
+func TestFrontend_SingleFlight(t *testing.T) {
+	t.Parallel()
+
+	frontend := NewFrontend[string](NewInMemoryCache(), flamingo.NullLogger{})
+
+	var calls int32
+	release := make(chan struct{})
+	loader := func(ctx context.Context) (string, *Meta, error) {
+		atomic.AddInt32(&calls, 1)
+		<-release
+		return "value", nil, nil
+	}
+
+	var wg sync.WaitGroup
+	for range 10 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			value, err := frontend.Get(context.Background(), "key", loader)
+			assert.NoError(t, err)
+			assert.Equal(t, "value", value)
+		}()
+	}
+
+	time.Sleep(20 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	assert.Equal(t, int32(1), atomic.LoadInt32(&calls))
+}
+
+func TestFrontend_ErrorTTL(t *testing.T) {
+	t.Parallel()
+
+	errLoad := errors.New("backend unavailable")
+
+	t.Run("disabled", func(t *testing.T) {
+		t.Parallel()
+
+		frontend := NewFrontend[string](NewInMemoryCache(), flamingo.NullLogger{})
+
+		var calls int32
+		loader := func(ctx context.Context) (string, *Meta, error) {
+			atomic.AddInt32(&calls, 1)
+			return "", nil, errLoad
+		}
+
+		_, err := frontend.Get(context.Background(), "key", loader)
+		assert.ErrorIs(t, err, errLoad)
+		_, err = frontend.Get(context.Background(), "key", loader)
+		assert.ErrorIs(t, err, errLoad)
+		assert.Equal(t, int32(2), atomic.LoadInt32(&calls))
+	})
+
+	t.Run("enabled", func(t *testing.T) {
+		t.Parallel()
+
+		frontend := NewFrontend[string](NewInMemoryCache(), flamingo.NullLogger{}).WithErrorTTL(30 * time.Millisecond)
+
+		var calls int32
+		loader := func(ctx context.Context) (string, *Meta, error) {
+			if atomic.AddInt32(&calls, 1) == 1 {
+				return "", nil, errLoad
+			}
+			return "value", nil, nil
+		}
+
+		_, err := frontend.Get(context.Background(), "key", loader)
+		assert.ErrorIs(t, err, errLoad)
+		_, err = frontend.Get(context.Background(), "key", loader)
+		assert.ErrorIs(t, err, errLoad, "the error is served from the cache")
+		assert.Equal(t, int32(1), atomic.LoadInt32(&calls))
+
+		time.Sleep(50 * time.Millisecond)
+
+		value, err := frontend.Get(context.Background(), "key", loader)
+		assert.NoError(t, err)
+		assert.Equal(t, "value", value)
+	})
+}
+
+func TestFrontend_StaleIfError(t *testing.T) {
+	t.Parallel()
+
+	errLoad := errors.New("backend unavailable")
+
+	var fail atomic.Bool
+	loader := func(ctx context.Context) (string, *Meta, error) {
+		if fail.Load() {
+			return "", nil, errLoad
+		}
+		return "value", &Meta{Lifetime: 10 * time.Millisecond, StaleIfError: 50 * time.Millisecond}, nil
+	}
+
+	frontend := NewFrontend[string](NewInMemoryCache(), flamingo.NullLogger{}).WithErrorTTL(time.Minute)
+
+	_, err := frontend.Get(context.Background(), "key", loader)
+	require.NoError(t, err)
+
+	fail.Store(true)
+	time.Sleep(20 * time.Millisecond)
+
+	value, err := frontend.Get(context.Background(), "key", loader)
+	assert.NoError(t, err)
+	assert.Equal(t, "value", value, "the stale entry is served if loading fails")
+
+	time.Sleep(50 * time.Millisecond)
+
+	_, err = frontend.Get(context.Background(), "key", loader)
+	assert.ErrorIs(t, err, errLoad, "the stale entry is not served after stale-if-error")
+}
+
+func TestFrontend_LoaderPanic(t *testing.T) {
+	t.Parallel()
+
+	frontend := NewFrontend[string](NewInMemoryCache(), flamingo.NullLogger{})
+
+	_, err := frontend.Get(context.Background(), "key", func(ctx context.Context) (string, *Meta, error) {
+		panic("loader panic")
+	})
+	assert.ErrorContains(t, err, "loader panic")
+}
+
+func TestFrontend_Jitter(t *testing.T) {
+	t.Parallel()
+
+	frontend := NewFrontend[string](new(NullBackend), flamingo.NullLogger{}).WithJitter(0.5)
+
+	for range 100 {
+		before := time.Now()
+		meta := frontend.meta(Meta{Lifetime: time.Minute, Gracetime: time.Minute})
+
+		assert.False(t, meta.lifetime.Before(before.Add(30*time.Second)))
+		assert.False(t, meta.lifetime.After(time.Now().Add(time.Minute)))
+		assert.Equal(t, time.Minute, meta.gracetime.Sub(meta.lifetime))
+	}
+}
@@ -1,32 +1,114 @@
 package cache
 
 import (
-	"errors"
+	"container/heap"
+	"reflect"
+	"sync"
 	"time"
-
-	lru "github.com/hashicorp/golang-lru/v2"
 )
 
 const lurkerPeriod = 1 * time.Minute
 const cacheSize = 100
 
 type (
-	inMemoryCache struct {
-		pool *lru.TwoQueueCache[string, inMemoryCacheEntry]
+	// EvictionPolicy decides which entry is evicted if an InMemoryBackend exceeds its budget
+	EvictionPolicy string
+
+	// InMemoryBackendOptions configure the budget of an InMemoryBackend
+	InMemoryBackendOptions struct {
+		// Name of the backend in metrics, defaults to "inMemory"
+		Name string
+		// MaxEntries limits the number of entries, 0 disables the limit
+		MaxEntries int
+		// MaxBytes limits the estimated size of all entries, 0 disables the limit
+		MaxBytes int64
+		// Policy defaults to EvictionLRU
+		Policy EvictionPolicy
+		// SweepInterval is the interval in which entries past their gracetime are deleted, defaults to one minute
+		SweepInterval time.Duration
+		// Sizer estimates the size of an entry in bytes, defaults to a reflection based estimation of Entry.Data
+		Sizer func(key string, entry *Entry) int64
+	}
+
+	// InMemoryBackend caches entries in memory, within a budget of entries or bytes
+	InMemoryBackend struct {
+		mu      sync.Mutex
+		options InMemoryBackendOptions
+		entries map[string]*inMemoryCacheEntry
+		queue   *inMemoryEvictionQueue
+		size    int64
+		tick    uint64
+		stop    chan struct{}
 	}
 
 	inMemoryCacheEntry struct {
-		valid time.Time
-		data  interface{}
+		key       string
+		valid     time.Time
+		data      *Entry
+		size      int64
+		frequency uint64
+		used      uint64
+		index     int
+	}
+
+	// inMemoryEvictionQueue is a heap with the next entry to evict on top
+	inMemoryEvictionQueue struct {
+		entries []*inMemoryCacheEntry
+		less    func(a, b *inMemoryCacheEntry) bool
 	}
 )
 
-// NewInMemoryCache creates a new lru TwoQueue backed cache backend
+const (
+	// EvictionLRU evicts the least recently used entry
+	EvictionLRU EvictionPolicy = "lru"
+	// EvictionLFU evicts the least frequently used entry, ties are evicted least recently used first
+	EvictionLFU EvictionPolicy = "lfu"
+
+	evictionReasonBudget  = "budget"
+	evictionReasonExpired = "expired"
+)
+
+var (
+	_ Backend        = new(InMemoryBackend)
+	_ KeyLister      = new(InMemoryBackend)
+	_ EntryCounter   = new(InMemoryBackend)
+	_ heap.Interface = new(inMemoryEvictionQueue)
+)
+
+// NewInMemoryCache creates a new in-memory cache backend for 100 entries with LRU eviction
 func NewInMemoryCache() Backend {
-	cache, _ := lru.New2Q[string, inMemoryCacheEntry](cacheSize)
+	return NewInMemoryBackend(InMemoryBackendOptions{MaxEntries: cacheSize})
+}
 
-	m := &inMemoryCache{
-		pool: cache,
+// NewInMemoryBackend creates an in-memory cache backend, entries past their gracetime are swept until Close is called
+func NewInMemoryBackend(options InMemoryBackendOptions) *InMemoryBackend {
+	if options.Name == "" {
+		options.Name = "inMemory"
+	}
+	if options.SweepInterval <= 0 {
+		options.SweepInterval = lurkerPeriod
+	}
+	if options.Sizer == nil {
+		options.Sizer = estimateEntrySize
+	}
+
+	less := func(a, b *inMemoryCacheEntry) bool {
+		return a.used < b.used
+	}
+	if options.Policy == EvictionLFU {
+		less = func(a, b *inMemoryCacheEntry) bool {
+			if a.frequency != b.frequency {
+				return a.frequency < b.frequency
+			}
+			return a.used < b.used
+		}
+	}
+
+	m := &InMemoryBackend{
+		options: options,
+		entries: make(map[string]*inMemoryCacheEntry),
+		queue:   &inMemoryEvictionQueue{less: less},
+		stop:    make(chan struct{}),
 	}
 	go m.lurker()
 
@@ -34,55 +116,287 @@ func NewInMemoryCache() Backend {
 }
 
 // Get tries to get an object from cache
-func (m *inMemoryCache) Get(key string) (*Entry, bool) {
-	entry, ok := m.pool.Get(key)
+func (m *InMemoryBackend) Get(key string) (*Entry, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	entry, ok := m.entries[key]
 	if !ok {
-		return nil, ok
+		return nil, false
 	}
 
-	e, ok := entry.data.(*Entry)
+	if !entry.valid.IsZero() && entry.valid.Before(time.Now()) {
+		m.remove(entry)
+		recordEviction(m.options.Name, evictionReasonExpired)
+		return nil, false
+	}
+
+	m.tick++
+	entry.used = m.tick
+	entry.frequency++
+	heap.Fix(m.queue, entry.index)
 
-	return e, ok
+	return entry.data, true
 }
 
-// Set a cache entry with a key
-func (m *inMemoryCache) Set(key string, entry *Entry) error {
-	m.pool.Add(key, inMemoryCacheEntry{
-		data:  entry,
-		valid: entry.Meta.gracetime,
-	})
+// Set a cache entry with a key, entries are evicted if the budget is exceeded
+func (m *InMemoryBackend) Set(key string, entry *Entry) error {
+	valid := entry.Meta.validUntil()
+	if valid.IsZero() {
+		if ttl := entry.Meta.Lifetime + entry.Meta.Gracetime + entry.Meta.StaleIfError; ttl > 0 {
+			valid = time.Now().Add(ttl)
+		}
+	}
+
+	size := m.options.Sizer(key, entry)
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.tick++
+	cacheEntry, ok := m.entries[key]
+	if ok {
+		m.remove(cacheEntry)
+		cacheEntry.frequency++
+	} else {
+		cacheEntry = &inMemoryCacheEntry{key: key, frequency: 1}
+	}
+	cacheEntry.data = entry
+	cacheEntry.valid = valid
+	cacheEntry.size = size
+	cacheEntry.used = m.tick
+
+	// existing entries are evicted first, so a new entry is not evicted right away by the LFU policy
+	for len(m.entries) > 0 && m.exceedsBudget(1, size) {
+		m.remove(m.queue.entries[0])
+		recordEviction(m.options.Name, evictionReasonBudget)
+	}
+
+	if m.exceedsBudget(1, size) {
+		recordEviction(m.options.Name, evictionReasonBudget)
+		return nil
+	}
+
+	m.entries[key] = cacheEntry
+	heap.Push(m.queue, cacheEntry)
+	m.size += size
 
 	return nil
 }
 
 // Purge a cache key
-func (m *inMemoryCache) Purge(key string) error {
-	m.pool.Remove(key)
+func (m *InMemoryBackend) Purge(key string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if entry, ok := m.entries[key]; ok {
+		m.remove(entry)
+	}
 
 	return nil
 }
 
 // PurgeTags purges all entries with matching tags from the cache
-func (m *inMemoryCache) PurgeTags(_ []string) error {
-	//nolint:err113 // not worth introducing a dedicated error
-	return errors.New("not implemented")
+func (m *InMemoryBackend) PurgeTags(tags []string) error {
+	purge := make(map[string]bool, len(tags))
+	for _, tag := range tags {
+		purge[tag] = true
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, entry := range m.entries {
+		for _, tag := range entry.data.Meta.Tags {
+			if purge[tag] {
+				m.remove(entry)
+				break
+			}
+		}
+	}
+
+	return nil
 }
 
 // Flush purges all entries in the cache
-func (m *inMemoryCache) Flush() error {
-	m.pool.Purge()
+func (m *InMemoryBackend) Flush() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.entries = make(map[string]*inMemoryCacheEntry)
+	m.queue.entries = nil
+	m.size = 0
 
 	return nil
 }
 
-func (m *inMemoryCache) lurker() {
-	for range time.Tick(lurkerPeriod) {
-		for _, key := range m.pool.Keys() {
-			item, ok := m.pool.Peek(key)
-			if ok && item.valid.Before(time.Now()) {
-				m.pool.Remove(key)
-				break
-			}
+// Keys of all entries in the cache
+func (m *InMemoryBackend) Keys() ([]string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	keys := make([]string, 0, len(m.entries))
+	for key := range m.entries {
+		keys = append(keys, key)
+	}
+
+	return keys, nil
+}
+
+// Len is the number of entries in the cache
+func (m *InMemoryBackend) Len() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return len(m.entries)
+}
+
+// Size is the estimated size of all entries in bytes
+func (m *InMemoryBackend) Size() int64 {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return m.size
+}
+
+// Close stops sweeping expired entries
+func (m *InMemoryBackend) Close() {
+	close(m.stop)
+}
+
+// exceedsBudget checks if additional entries and bytes exceed the budget
+func (m *InMemoryBackend) exceedsBudget(entries int, size int64) bool {
+	return (m.options.MaxEntries > 0 && len(m.entries)+entries > m.options.MaxEntries) ||
+		(m.options.MaxBytes > 0 && m.size+size > m.options.MaxBytes)
+}
+
+func (m *InMemoryBackend) remove(entry *inMemoryCacheEntry) {
+	heap.Remove(m.queue, entry.index)
+	delete(m.entries, entry.key)
+	m.size -= entry.size
+}
+
+// sweep removes all entries past their gracetime
+func (m *InMemoryBackend) sweep() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := time.Now()
+	for _, entry := range m.entries {
+		if !entry.valid.IsZero() && entry.valid.Before(now) {
+			m.remove(entry)
+			recordEviction(m.options.Name, evictionReasonExpired)
+		}
+	}
+}
+
+func (m *InMemoryBackend) lurker() {
+	ticker := time.NewTicker(m.options.SweepInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			m.sweep()
+		case <-m.stop:
+			return
+		}
+	}
+}
+
+// Len of the queue
+func (q *inMemoryEvictionQueue) Len() int { return len(q.entries) }
+
+// Less orders the next entry to evict first
+func (q *inMemoryEvictionQueue) Less(i, j int) bool { return q.less(q.entries[i], q.entries[j]) }
+
+// Swap two entries
+func (q *inMemoryEvictionQueue) Swap(i, j int) {
+	q.entries[i], q.entries[j] = q.entries[j], q.entries[i]
+	q.entries[i].index = i
+	q.entries[j].index = j
+}
+
+// Push an entry
+func (q *inMemoryEvictionQueue) Push(x any) {
+	entry := x.(*inMemoryCacheEntry)
+	entry.index = len(q.entries)
+	q.entries = append(q.entries, entry)
+}
+
+// Pop the last entry
+func (q *inMemoryEvictionQueue) Pop() any {
+	last := len(q.entries) - 1
+	entry := q.entries[last]
+	q.entries[last] = nil
+	q.entries = q.entries[:last]
+	entry.index = -1
+
+	return entry
+}
+
+// estimateEntrySize estimates the memory used by key and data, shared pointers are counted once
+func estimateEntrySize(key string, entry *Entry) int64 {
+	size := int64(len(key))
+	for _, tag := range entry.Meta.Tags {
+		size += int64(len(tag))
+	}
+
+	return size + estimateSize(reflect.ValueOf(entry.Data), make(map[uintptr]bool))
+}
+
+func estimateSize(v reflect.Value, seen map[uintptr]bool) int64 {
+	if !v.IsValid() {
+		return 0
+	}
+
+	switch v.Kind() {
+	case reflect.Pointer:
+		if v.IsNil() || seen[v.Pointer()] {
+			return int64(v.Type().Size())
+		}
+		seen[v.Pointer()] = true
+		return int64(v.Type().Size()) + estimateSize(v.Elem(), seen)
+	case reflect.Interface:
+		if v.IsNil() {
+			return int64(v.Type().Size())
+		}
+		return int64(v.Type().Size()) + estimateSize(v.Elem(), seen)
+	case reflect.String:
+		return int64(v.Type().Size()) + int64(v.Len())
+	case reflect.Slice:
+		size := int64(v.Type().Size())
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			return size + int64(v.Cap())
+		}
+		for i := range v.Len() {
+			size += estimateSize(v.Index(i), seen)
+		}
+		return size + int64(v.Cap()-v.Len())*int64(v.Type().Elem().Size())
+	case reflect.Array:
+		var size int64
+		for i := range v.Len() {
+			size += estimateSize(v.Index(i), seen)
+		}
+		return size
+	case reflect.Map:
+		size := int64(v.Type().Size())
+		iter := v.MapRange()
+		for iter.Next() {
+			size += estimateSize(iter.Key(), seen) + estimateSize(iter.Value(), seen)
+		}
+		return size
+	case reflect.Struct:
+		var size int64
+		for i := range v.NumField() {
+			size += estimateSize(v.Field(i), seen)
+		}
+		// padding between the fields
+		if fields := int64(v.Type().Size()); fields > size {
+			return fields
 		}
+		return size
+	default:
+		return int64(v.Type().Size())
 	}
 }
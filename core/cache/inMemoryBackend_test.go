@@ -1,11 +1,15 @@
 package cache_test
 
 import (
+	"strings"
 	"testing"
 	"time"
 
-	"flamingo.me/flamingo/v3/core/cache"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opencensus.io/stats/view"
+
+	"flamingo.me/flamingo/v3/core/cache"
 )
 
 func Test_inMemoryCache_Flush(t *testing.T) {
@@ -97,3 +101,152 @@ func Test_inMemoryCache_SetGet(t *testing.T) {
 		Gracetime: 10 * time.Second,
 	}, entry.Meta)
 }
+
+func Test_inMemoryCache_PurgeTags(t *testing.T) {
+	t.Parallel()
+
+	inMemoryCache := cache.NewInMemoryCache()
+
+	for key, tags := range map[string][]string{"foo": {"bar"}, "baz": {"bar", "qux"}, "quux": {"qux"}} {
+		assert.NoError(t, inMemoryCache.Set(key, &cache.Entry{
+			Meta: cache.Meta{
+				Tags:      tags,
+				Lifetime:  5 * time.Second,
+				Gracetime: 10 * time.Second,
+			},
+			Data: "test",
+		}))
+	}
+
+	assert.NoError(t, inMemoryCache.PurgeTags([]string{"bar"}))
+
+	_, found := inMemoryCache.Get("foo")
+	assert.False(t, found)
+
+	_, found = inMemoryCache.Get("baz")
+	assert.False(t, found)
+
+	_, found = inMemoryCache.Get("quux")
+	assert.True(t, found)
+}
+
+func evictions(t *testing.T, backend, reason string) int64 {
+	t.Helper()
+
+	rows, err := view.RetrieveData("flamingo/cache/evictions")
+	require.NoError(t, err)
+
+	for _, row := range rows {
+		tags := make(map[string]string)
+		for _, tag := range row.Tags {
+			tags[tag.Key.Name()] = tag.Value
+		}
+		if tags["backend"] == backend && tags["reason"] == reason {
+			return row.Data.(*view.CountData).Value
+		}
+	}
+
+	return 0
+}
+
+func Test_InMemoryBackend_Eviction(t *testing.T) {
+	t.Parallel()
+
+	set := func(t *testing.T, backend cache.Backend, keys ...string) {
+		t.Helper()
+		for _, key := range keys {
+			require.NoError(t, backend.Set(key, &cache.Entry{Data: key}))
+		}
+	}
+
+	t.Run("lru", func(t *testing.T) {
+		t.Parallel()
+
+		backend := cache.NewInMemoryBackend(cache.InMemoryBackendOptions{Name: "test-lru", MaxEntries: 3})
+		t.Cleanup(backend.Close)
+
+		set(t, backend, "a", "b", "c")
+		_, found := backend.Get("a")
+		require.True(t, found)
+		set(t, backend, "d")
+
+		_, found = backend.Get("b")
+		assert.False(t, found, "the least recently used entry is evicted")
+		keys, err := backend.Keys()
+		require.NoError(t, err)
+		assert.ElementsMatch(t, []string{"a", "c", "d"}, keys)
+		assert.Equal(t, int64(1), evictions(t, "test-lru", "budget"))
+	})
+
+	t.Run("lfu", func(t *testing.T) {
+		t.Parallel()
+
+		backend := cache.NewInMemoryBackend(cache.InMemoryBackendOptions{Name: "test-lfu", MaxEntries: 3, Policy: cache.EvictionLFU})
+		t.Cleanup(backend.Close)
+
+		set(t, backend, "a", "b", "c")
+		for range 3 {
+			backend.Get("a")
+			backend.Get("c")
+		}
+		backend.Get("b")
+		set(t, backend, "d")
+
+		keys, err := backend.Keys()
+		require.NoError(t, err)
+		assert.ElementsMatch(t, []string{"a", "c", "d"}, keys, "the least frequently used entry is evicted")
+	})
+
+	t.Run("bytes", func(t *testing.T) {
+		t.Parallel()
+
+		backend := cache.NewInMemoryBackend(cache.InMemoryBackendOptions{
+			Name:     "test-bytes",
+			MaxBytes: 2500,
+			Sizer: func(key string, entry *cache.Entry) int64 {
+				return int64(len(entry.Data.(string)))
+			},
+		})
+		t.Cleanup(backend.Close)
+
+		for _, key := range []string{"a", "b", "c"} {
+			require.NoError(t, backend.Set(key, &cache.Entry{Data: strings.Repeat(key, 1000)}))
+		}
+
+		assert.Equal(t, 2, backend.Len())
+		assert.Equal(t, int64(2000), backend.Size())
+		_, found := backend.Get("a")
+		assert.False(t, found)
+	})
+
+	t.Run("estimated size", func(t *testing.T) {
+		t.Parallel()
+
+		backend := cache.NewInMemoryBackend(cache.InMemoryBackendOptions{})
+		t.Cleanup(backend.Close)
+
+		set(t, backend, "key")
+		small := backend.Size()
+		require.NoError(t, backend.Set("key", &cache.Entry{Data: map[string][]string{"key": {strings.Repeat("x", 1000)}}}))
+
+		assert.Greater(t, backend.Size(), small+1000)
+	})
+}
+
+func Test_InMemoryBackend_Sweep(t *testing.T) {
+	t.Parallel()
+
+	backend := cache.NewInMemoryBackend(cache.InMemoryBackendOptions{Name: "test-sweep", SweepInterval: 10 * time.Millisecond})
+	t.Cleanup(backend.Close)
+
+	require.NoError(t, backend.Set("expiring", &cache.Entry{Meta: cache.Meta{Lifetime: 10 * time.Millisecond}, Data: "foo"}))
+	require.NoError(t, backend.Set("permanent", &cache.Entry{Data: "bar"}))
+
+	assert.Eventually(t, func() bool {
+		return backend.Len() == 1
+	}, time.Second, 5*time.Millisecond)
+
+	_, found := backend.Get("permanent")
+	assert.True(t, found)
+	assert.Equal(t, int64(1), evictions(t, "test-sweep", "expired"))
+}
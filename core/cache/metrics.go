@@ -0,0 +1,75 @@
+package cache
+
+import (
+	"context"
+	"time"
+
+	"go.opencensus.io/stats"
+	"go.opencensus.io/stats/view"
+	"go.opencensus.io/tag"
+
+	"flamingo.me/flamingo/v3/framework/opencensus"
+)
+
+var (
+	hitMeasure       = stats.Int64("flamingo/cache/hits", "Count of entries served from the cache", stats.UnitDimensionless)
+	missMeasure      = stats.Int64("flamingo/cache/misses", "Count of entries not found in the cache", stats.UnitDimensionless)
+	graceHitMeasure  = stats.Int64("flamingo/cache/grace_hits", "Count of entries served during their gracetime", stats.UnitDimensionless)
+	loadMeasure      = stats.Int64("flamingo/cache/load", "Load times of cache entries", stats.UnitMilliseconds)
+	loadErrorMeasure = stats.Int64("flamingo/cache/load_errors", "Count of failed loads", stats.UnitDimensionless)
+	entriesMeasure   = stats.Int64("flamingo/cache/entries", "Count of entries in the backend", stats.UnitDimensionless)
+	evictionMeasure  = stats.Int64("flamingo/cache/evictions", "Count of entries evicted by the backend", stats.UnitDimensionless)
+
+	// KeyFrontend is the name of the cache frontend
+	KeyFrontend, _ = tag.NewKey("frontend")
+	// KeyBackend is the name of the cache backend
+	KeyBackend, _        = tag.NewKey("backend")
+	keyEvictionReason, _ = tag.NewKey("reason")
+)
+
+func init() {
+	for _, measure := range []*stats.Int64Measure{hitMeasure, missMeasure, graceHitMeasure, loadErrorMeasure} {
+		if err := opencensus.View(measure.Name(), measure, view.Count(), KeyFrontend); err != nil {
+			panic(err)
+		}
+	}
+
+	if err := opencensus.View("flamingo/cache/load", loadMeasure, view.Distribution(10, 50, 100, 250, 500, 1000, 2500, 5000), KeyFrontend); err != nil { //nolint:mnd // magic number is accepted here
+		panic(err)
+	}
+
+	if err := opencensus.View("flamingo/cache/entries", entriesMeasure, view.LastValue(), KeyFrontend); err != nil {
+		panic(err)
+	}
+
+	if err := opencensus.View("flamingo/cache/evictions", evictionMeasure, view.Count(), KeyBackend, keyEvictionReason); err != nil {
+		panic(err)
+	}
+}
+
+// record a measurement tagged with the frontend name
+func record(ctx context.Context, frontend string, measurement stats.Measurement) {
+	ctx, _ = tag.New(ctx, tag.Upsert(KeyFrontend, frontend))
+	stats.Record(ctx, measurement)
+}
+
+// recordLoad records the load time, and the failure of the load
+func recordLoad(ctx context.Context, frontend string, start time.Time, err error) {
+	record(ctx, frontend, loadMeasure.M(time.Since(start).Milliseconds()))
+	if err != nil {
+		record(ctx, frontend, loadErrorMeasure.M(1))
+	}
+}
+
+// recordEntries records the number of entries of backends which can count them
+func recordEntries(ctx context.Context, frontend string, backend Backend) {
+	if counter, ok := backend.(EntryCounter); ok {
+		record(ctx, frontend, entriesMeasure.M(int64(counter.Len())))
+	}
+}
+
+// recordEviction counts an entry evicted by the backend, because of its budget or expiry
+func recordEviction(backend, reason string) {
+	ctx, _ := tag.New(context.Background(), tag.Upsert(KeyBackend, backend), tag.Upsert(keyEvictionReason, reason))
+	stats.Record(ctx, evictionMeasure.M(1))
+}
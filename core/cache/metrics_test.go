@@ -0,0 +1,65 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opencensus.io/stats/view"
+
+	"flamingo.me/flamingo/v3/framework/flamingo"
+)
+
+func viewData(t *testing.T, name, frontend string) view.AggregationData {
+	t.Helper()
+
+	rows, err := view.RetrieveData(name)
+	require.NoError(t, err)
+
+	for _, row := range rows {
+		for _, tag := range row.Tags {
+			if tag.Key == KeyFrontend && tag.Value == frontend {
+				return row.Data
+			}
+		}
+	}
+
+	return nil
+}
+
+func TestFrontend_Metrics(t *testing.T) {
+	t.Parallel()
+
+	frontend := NewFrontend[string](NewInMemoryCache(), flamingo.NullLogger{}).WithName("metrics-test")
+
+	loader := func(ctx context.Context) (string, *Meta, error) {
+		return "value", &Meta{Lifetime: 20 * time.Millisecond, Gracetime: time.Minute}, nil
+	}
+
+	_, err := frontend.Get(context.Background(), "key", loader)
+	require.NoError(t, err)
+	_, err = frontend.Get(context.Background(), "key", loader)
+	require.NoError(t, err)
+	_, err = frontend.Get(context.Background(), "failing", func(ctx context.Context) (string, *Meta, error) {
+		return "", nil, errors.New("unavailable")
+	})
+	require.Error(t, err)
+
+	time.Sleep(30 * time.Millisecond)
+	_, err = frontend.Get(context.Background(), "key", loader)
+	require.NoError(t, err)
+
+	assert.Eventually(t, func() bool {
+		load, ok := viewData(t, "flamingo/cache/load", "metrics-test").(*view.DistributionData)
+		return ok && load.Count == 3
+	}, time.Second, 5*time.Millisecond, "the background reload is recorded")
+
+	assert.Equal(t, int64(1), viewData(t, "flamingo/cache/hits", "metrics-test").(*view.CountData).Value)
+	assert.Equal(t, int64(2), viewData(t, "flamingo/cache/misses", "metrics-test").(*view.CountData).Value)
+	assert.Equal(t, int64(1), viewData(t, "flamingo/cache/grace_hits", "metrics-test").(*view.CountData).Value)
+	assert.Equal(t, int64(1), viewData(t, "flamingo/cache/load_errors", "metrics-test").(*view.CountData).Value)
+	assert.Equal(t, float64(1), viewData(t, "flamingo/cache/entries", "metrics-test").(*view.LastValueData).Value)
+}
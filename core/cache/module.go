@@ -0,0 +1,98 @@
+package cache
+
+import (
+	"flamingo.me/dingo"
+
+	"flamingo.me/flamingo/v3/framework/systemendpoint"
+	"flamingo.me/flamingo/v3/framework/systemendpoint/domain"
+	"flamingo.me/flamingo/v3/framework/web"
+)
+
+type (
+	// PageCacheModule caches rendered pages and data responses of the configured routes.
+	// The in-memory backend can be replaced by overriding the Backend annotated with PageCacheBackend.
+	PageCacheModule struct{}
+
+	// AdminModule registers a systemendpoint handler to inspect, purge and flush the named backends.
+	// Backends are named with a map binding: injector.BindMap(new(cache.Backend), "products").ToInstance(backend)
+	AdminModule struct {
+		path string
+	}
+)
+
+// Configure DI
+func (m *PageCacheModule) Configure(injector *dingo.Injector) {
+	injector.Bind(new(Backend)).AnnotatedWith(PageCacheBackend).ToProvider(newPageCacheBackend).In(dingo.Singleton)
+	injector.BindMulti(new(web.Filter)).To(pageCacheFilter{})
+	injector.BindMap(new(Backend), "page").ToProvider(func(cfg *struct {
+		Backend Backend `inject:"core.cache.page"`
+	}) Backend {
+		return cfg.Backend
+	})
+}
+
+// CueConfig schema
+func (*PageCacheModule) CueConfig() string {
+	return `
+core: cache: page: {
+	lifetime: string | *"1m"
+	gracetime: string | *"10m"
+	// handler names of the cached routes
+	routes: [...string]
+	// budget of the default in-memory backend
+	inMemory: {
+		maxEntries: int | *100
+		// estimated size of all pages in bytes, 0 disables the limit
+		maxBytes: int | *0
+		policy: "lru" | "lfu" | *"lru"
+	}
+	vary: {
+		// query parameters which are part of the cache key, all others are ignored
+		query: [...string]
+		// request headers which are part of the cache key
+		headers: [...string]
+		// session values which are part of the cache key
+		session: [...string]
+	}
+}
+`
+}
+
+func newPageCacheBackend(cfg *struct {
+	MaxEntries float64 `inject:"config:core.cache.page.inMemory.maxEntries"`
+	MaxBytes   float64 `inject:"config:core.cache.page.inMemory.maxBytes"`
+	Policy     string  `inject:"config:core.cache.page.inMemory.policy"`
+}) Backend {
+	return NewInMemoryBackend(InMemoryBackendOptions{
+		Name:       "page",
+		MaxEntries: int(cfg.MaxEntries),
+		MaxBytes:   int64(cfg.MaxBytes),
+		Policy:     EvictionPolicy(cfg.Policy),
+	})
+}
+
+// Inject dependencies
+func (m *AdminModule) Inject(
+	config *struct {
+		Path string `inject:"config:core.cache.admin.path"`
+	},
+) {
+	m.path = config.Path
+}
+
+// Configure DI
+func (m *AdminModule) Configure(injector *dingo.Injector) {
+	injector.BindMap((*domain.Handler)(nil), m.path).To(adminHandler{})
+}
+
+// CueConfig schema
+func (*AdminModule) CueConfig() string {
+	return `core: cache: admin: path: string | *"/cache/"`
+}
+
+// Depends on other modules
+func (*AdminModule) Depends() []dingo.Module {
+	return []dingo.Module{
+		new(systemendpoint.Module),
+	}
+}
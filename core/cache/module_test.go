@@ -0,0 +1,20 @@
+package cache_test
+
+import (
+	"testing"
+
+	"flamingo.me/flamingo/v3/core/cache"
+	"flamingo.me/flamingo/v3/framework/config"
+)
+
+func TestPageCacheModule_Configure(t *testing.T) {
+	if err := config.TryModules(nil, new(cache.PageCacheModule)); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestAdminModule_Configure(t *testing.T) {
+	if err := config.TryModules(nil, new(cache.AdminModule)); err != nil {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,386 @@
+package cache
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/gob"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"go.opencensus.io/trace"
+	"golang.org/x/sync/singleflight"
+
+	"flamingo.me/flamingo/v3/framework/config"
+	"flamingo.me/flamingo/v3/framework/flamingo"
+	"flamingo.me/flamingo/v3/framework/web"
+)
+
+type (
+	pageCacheFilter struct {
+		singleflight.Group
+		loading   sync.Map
+		backend   Backend
+		responder *web.Responder
+		logger    flamingo.Logger
+		lifetime  time.Duration
+		gracetime time.Duration
+		routes    map[string]bool
+		query     []string
+		headers   []string
+		session   []string
+	}
+
+	// CachedPage is the stored representation of a rendered response, it is registered with gob for the GobCodec.
+	// Backends with other codecs must decode entries into a CachedPage, e.g. with the JSONCodec[CachedPage].
+	CachedPage struct {
+		Status int
+		Header http.Header
+		Body   []byte
+	}
+
+	pageLoad struct {
+		result web.Result
+		page   *CachedPage
+	}
+
+	pageTags struct {
+		mu   sync.Mutex
+		tags []string
+	}
+
+	pageTagsKey struct{}
+
+	pageRevalidationKey struct{}
+
+	pageResponseWriter struct {
+		header http.Header
+		status int
+		body   bytes.Buffer
+	}
+)
+
+const (
+	// PageCacheBackend is the annotation of the Backend used by the page cache
+	PageCacheBackend = "core.cache.page"
+)
+
+var (
+	_ web.Filter          = new(pageCacheFilter)
+	_ http.ResponseWriter = new(pageResponseWriter)
+)
+
+func init() {
+	gob.Register(CachedPage{})
+}
+
+// PageRouteTag returns the tag of all cached pages of a route, to purge them with Backend.PurgeTags
+func PageRouteTag(handler string) string {
+	return "route:" + handler
+}
+
+// AddPageTags tags the page which is currently rendered, so it can be purged with Backend.PurgeTags
+func AddPageTags(ctx context.Context, tags ...string) {
+	if collected, ok := ctx.Value(pageTagsKey{}).(*pageTags); ok {
+		collected.mu.Lock()
+		collected.tags = append(collected.tags, tags...)
+		collected.mu.Unlock()
+	}
+}
+
+// Inject dependencies
+func (f *pageCacheFilter) Inject(
+	responder *web.Responder,
+	logger flamingo.Logger,
+	cfg *struct {
+		Backend   Backend      `inject:"core.cache.page"`
+		Lifetime  string       `inject:"config:core.cache.page.lifetime"`
+		Gracetime string       `inject:"config:core.cache.page.gracetime"`
+		Routes    config.Slice `inject:"config:core.cache.page.routes,optional"`
+		Query     config.Slice `inject:"config:core.cache.page.vary.query,optional"`
+		Headers   config.Slice `inject:"config:core.cache.page.vary.headers,optional"`
+		Session   config.Slice `inject:"config:core.cache.page.vary.session,optional"`
+	},
+) *pageCacheFilter {
+	f.responder = responder
+	f.logger = logger.WithField(flamingo.LogKeyModule, "cache").WithField(flamingo.LogKeyCategory, "pageCache")
+
+	if cfg == nil {
+		return f
+	}
+
+	f.backend = cfg.Backend
+	f.lifetime = parsePageCacheDuration("core.cache.page.lifetime", cfg.Lifetime)
+	f.gracetime = parsePageCacheDuration("core.cache.page.gracetime", cfg.Gracetime)
+
+	var routes []string
+	_ = cfg.Routes.MapInto(&routes)
+	f.routes = make(map[string]bool, len(routes))
+	for _, route := range routes {
+		f.routes[route] = true
+	}
+
+	_ = cfg.Query.MapInto(&f.query)
+	_ = cfg.Headers.MapInto(&f.headers)
+	_ = cfg.Session.MapInto(&f.session)
+	sort.Strings(f.query)
+	for i, header := range f.headers {
+		f.headers[i] = http.CanonicalHeaderKey(header)
+	}
+	sort.Strings(f.headers)
+	sort.Strings(f.session)
+
+	return f
+}
+
+func parsePageCacheDuration(key, value string) time.Duration {
+	if value == "" {
+		return 0
+	}
+
+	duration, err := time.ParseDuration(value)
+	if err != nil {
+		panic(fmt.Errorf("invalid duration on %q: %q (%w)", key, value, err))
+	}
+
+	return duration
+}
+
+// Filter serves cached pages and stores rendered pages of the configured routes
+func (f *pageCacheFilter) Filter(ctx context.Context, r *web.Request, w http.ResponseWriter, chain *web.FilterChain) web.Result {
+	method := r.Request().Method
+	if f.backend == nil || (method != http.MethodGet && method != http.MethodHead) || r.Handler == nil || !f.routes[r.Handler.GetHandlerName()] {
+		return chain.Next(ctx, r, w)
+	}
+
+	ctx, span := trace.StartSpan(ctx, "flamingo/cache/pageCache/Filter")
+	defer span.End()
+
+	key := f.key(r)
+	span.Annotate(nil, key)
+
+	if revalidation, ok := ctx.Value(pageRevalidationKey{}).(*pageLoad); ok {
+		// the request is served again in the background to revalidate the stale page
+		*revalidation = f.load(ctx, key, r, w, chain)
+		return revalidation.result
+	}
+
+	if entry, ok := f.backend.Get(key); ok {
+		page, ok := entry.Data.(CachedPage)
+		if !ok {
+			f.logger.WithContext(ctx).Warn(fmt.Sprintf("page cache: entry %q is a %T instead of a cache.CachedPage, check the codec of the backend", key, entry.Data))
+		} else {
+			now := time.Now()
+			if entry.Meta.lifetime.After(now) {
+				f.logger.WithContext(ctx).Debug("Serving from cache", key)
+				return page.response()
+			}
+
+			if entry.Meta.gracetime.After(now) {
+				f.logger.WithContext(ctx).Debug("Gracetime! Serving from cache", key)
+				f.revalidate(ctx, key, r)
+				return page.response()
+			}
+		}
+	}
+
+	executed := false
+	loaded, _, _ := f.Do(key, func() (interface{}, error) {
+		executed = true
+		f.loading.Store(key, struct{}{})
+		defer f.loading.Delete(key)
+
+		return f.load(ctx, key, r, w, chain), nil
+	})
+
+	load := loaded.(pageLoad)
+	if executed {
+		return load.result
+	}
+
+	if load.page == nil {
+		// the shared result is not cacheable and must not be served for this request
+		return chain.Next(ctx, r, w)
+	}
+
+	return load.page.response()
+}
+
+// revalidate renders the page again in the background, unless it is already being rendered.
+// The request is served again by the router, because the request and filter chain must not be used after the request is finished.
+func (f *pageCacheFilter) revalidate(ctx context.Context, key string, r *web.Request) {
+	if _, loading := f.loading.LoadOrStore(key, struct{}{}); loading {
+		return
+	}
+
+	revalidation := new(pageLoad)
+	//nolint:contextcheck // the revalidation outlives the request
+	serve, ok := web.Redispatch(context.WithValue(context.WithoutCancel(ctx), pageRevalidationKey{}, revalidation), r)
+	if !ok {
+		f.loading.Delete(key)
+		return
+	}
+
+	go func() {
+		defer f.loading.Delete(key)
+
+		_, _, _ = f.Do(key, func() (interface{}, error) {
+			serve(new(pageResponseWriter))
+			return *revalidation, nil
+		})
+	}()
+}
+
+// load runs the rest of the filter chain and stores the rendered page if it is cacheable
+func (f *pageCacheFilter) load(ctx context.Context, key string, r *web.Request, w http.ResponseWriter, chain *web.FilterChain) pageLoad {
+	ctx, span := trace.StartSpan(ctx, "flamingo/cache/pageCache/load")
+	defer span.End()
+
+	tags := &pageTags{tags: []string{PageRouteTag(r.Handler.GetHandlerName())}}
+	ctx = context.WithValue(ctx, pageTagsKey{}, tags)
+
+	result := chain.Next(ctx, r, w)
+
+	var response *web.Response
+	switch result := result.(type) {
+	case *web.RenderResponse:
+		response = &result.Response
+	case *web.DataResponse:
+		response = &result.Response
+	default:
+		return pageLoad{result: result}
+	}
+
+	directive := response.CacheDirective
+	if directive != nil && (directive.NoStore || directive.NoCache || directive.Visibility == web.CacheVisibilityPrivate) {
+		return pageLoad{result: result}
+	}
+
+	buffered := &pageResponseWriter{header: make(http.Header)}
+	if err := result.Apply(ctx, buffered); err != nil {
+		return pageLoad{result: f.responder.ServerErrorWithContext(ctx, fmt.Errorf("page cache: %w", err))}
+	}
+
+	page := &CachedPage{Status: buffered.status, Header: buffered.header, Body: buffered.body.Bytes()}
+	if !f.storable(page) {
+		return pageLoad{result: page.response()}
+	}
+
+	lifetime := f.lifetime
+	if directive != nil && directive.SMaxAge > 0 {
+		lifetime = time.Duration(directive.SMaxAge) * time.Second
+	} else if directive != nil && directive.MaxAge > 0 {
+		lifetime = time.Duration(directive.MaxAge) * time.Second
+	}
+
+	tags.mu.Lock()
+	meta := Meta{Tags: append([]string(nil), tags.tags...), Lifetime: lifetime, Gracetime: f.gracetime}
+	tags.mu.Unlock()
+	meta.lifetime = time.Now().Add(meta.Lifetime)
+	meta.gracetime = meta.lifetime.Add(meta.Gracetime)
+
+	if err := f.backend.Set(key, &Entry{Meta: meta, Data: *page}); err != nil {
+		f.logger.WithContext(ctx).Warn("page cache: failed to store", key, err)
+	} else {
+		f.logger.WithContext(ctx).Debug("Store in cache", key, meta.Tags)
+	}
+
+	return pageLoad{result: page.response(), page: page}
+}
+
+// storable checks the status and that the page does not depend on anything not covered by the cache key
+func (f *pageCacheFilter) storable(page *CachedPage) bool {
+	if page.Status != http.StatusOK || len(page.Header.Values("Set-Cookie")) > 0 {
+		return false
+	}
+
+	cacheControl := strings.ToLower(strings.Join(page.Header.Values("Cache-Control"), ","))
+	if strings.Contains(cacheControl, "no-store") || strings.Contains(cacheControl, "no-cache") || strings.Contains(cacheControl, "private") {
+		return false
+	}
+
+	for _, vary := range page.Header.Values("Vary") {
+		for _, header := range strings.Split(vary, ",") {
+			header = http.CanonicalHeaderKey(strings.TrimSpace(header))
+			if header == "" {
+				continue
+			}
+			index := sort.SearchStrings(f.headers, header)
+			if header == "*" || index == len(f.headers) || f.headers[index] != header {
+				return false
+			}
+		}
+	}
+
+	return true
+}
+
+// key builds the cache key from the host, path and the configured vary rules
+func (f *pageCacheFilter) key(r *web.Request) string {
+	req := r.Request()
+
+	h := sha256.New()
+	writeKeyPart(h, "host", req.Host)
+	writeKeyPart(h, "path", req.URL.Path)
+
+	query := req.URL.Query()
+	for _, name := range f.query {
+		for _, value := range query[name] {
+			writeKeyPart(h, "query", name+"="+value)
+		}
+	}
+
+	for _, name := range f.headers {
+		writeKeyPart(h, "header", name+"="+strings.Join(req.Header.Values(name), ","))
+	}
+
+	for _, name := range f.session {
+		if value, ok := r.Session().Load(name); ok {
+			writeKeyPart(h, "session", fmt.Sprintf("%s=%v", name, value))
+		}
+	}
+
+	return "page:" + hex.EncodeToString(h.Sum(nil))
+}
+
+func writeKeyPart(h hash.Hash, kind, value string) {
+	_, _ = fmt.Fprintf(h, "%s:%d:%s\n", kind, len(value), value)
+}
+
+// response creates a new result serving the cached page
+func (p CachedPage) response() web.Result {
+	return &web.Response{
+		Status: uint(p.Status), //nolint:gosec // status codes are positive
+		Header: p.Header.Clone(),
+		Body:   bytes.NewReader(p.Body),
+	}
+}
+
+// Header of the buffered page
+func (w *pageResponseWriter) Header() http.Header {
+	if w.header == nil {
+		w.header = make(http.Header)
+	}
+	return w.header
+}
+
+// Write to the page body
+func (w *pageResponseWriter) Write(p []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	return w.body.Write(p)
+}
+
+// WriteHeader records the status
+func (w *pageResponseWriter) WriteHeader(status int) {
+	if w.status == 0 {
+		w.status = status
+	}
+}
@@ -0,0 +1,237 @@
+package cache
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"flamingo.me/flamingo/v3/framework/config"
+	"flamingo.me/flamingo/v3/framework/flamingo"
+	"flamingo.me/flamingo/v3/framework/web"
+)
+
+type pageCacheRoutes func(registry *web.RouterRegistry)
+
+func (f pageCacheRoutes) Routes(registry *web.RouterRegistry) {
+	f(registry)
+}
+
+func newPageCacheTestServer(t *testing.T, filter web.Filter, routes pageCacheRoutes) *httptest.Server {
+	t.Helper()
+
+	router := new(web.Router)
+	router.Inject(
+		&struct {
+			Scheme           string       `inject:"config:flamingo.router.scheme,optional"`
+			Host             string       `inject:"config:flamingo.router.host,optional"`
+			Path             string       `inject:"config:flamingo.router.path,optional"`
+			External         string       `inject:"config:flamingo.router.external,optional"`
+			SessionName      string       `inject:"config:flamingo.session.name,optional"`
+			MethodNotAllowed bool         `inject:"config:flamingo.router.methodNotAllowed,optional"`
+			AutoOptions      bool         `inject:"config:flamingo.router.autoOptions,optional"`
+			AutoHead         bool         `inject:"config:flamingo.router.autoHead,optional"`
+			TrustedProxies   config.Slice `inject:"config:flamingo.router.trustedProxies,optional"`
+			ForwardedHeaders string       `inject:"config:flamingo.router.forwardedHeaders,optional"`
+		}{},
+		nil,
+		new(flamingo.DefaultEventRouter),
+		func() []web.Filter { return []web.Filter{filter} },
+		func() []web.RoutesModule { return []web.RoutesModule{routes} },
+		flamingo.NullLogger{},
+		new(config.Area),
+		func() *web.Responder { return new(web.Responder) },
+	)
+
+	server := httptest.NewServer(router.Handler())
+	t.Cleanup(server.Close)
+
+	return server
+}
+
+func newPageCacheFilter(backend Backend, lifetime, gracetime string) *pageCacheFilter {
+	return new(pageCacheFilter).Inject(new(web.Responder), flamingo.NullLogger{}, &struct {
+		Backend   Backend      `inject:"core.cache.page"`
+		Lifetime  string       `inject:"config:core.cache.page.lifetime"`
+		Gracetime string       `inject:"config:core.cache.page.gracetime"`
+		Routes    config.Slice `inject:"config:core.cache.page.routes,optional"`
+		Query     config.Slice `inject:"config:core.cache.page.vary.query,optional"`
+		Headers   config.Slice `inject:"config:core.cache.page.vary.headers,optional"`
+		Session   config.Slice `inject:"config:core.cache.page.vary.session,optional"`
+	}{
+		Backend:   backend,
+		Lifetime:  lifetime,
+		Gracetime: gracetime,
+		Routes:    config.Slice{"product", "account"},
+		Query:     config.Slice{"page"},
+		Headers:   config.Slice{"Accept-Language"},
+	})
+}
+
+func TestPageCacheFilter(t *testing.T) {
+	backend := NewInMemoryCache()
+	var calls, accountCalls, uncachedCalls int32
+
+	server := newPageCacheTestServer(t, newPageCacheFilter(backend, "1m", "10m"), func(registry *web.RouterRegistry) {
+		registry.MustRoute("/product", "product")
+		registry.HandleGet("product", func(ctx context.Context, r *web.Request) web.Result {
+			AddPageTags(ctx, "product-1")
+			call := atomic.AddInt32(&calls, 1)
+			return new(web.Responder).Data(map[string]string{"call": strconv.Itoa(int(call)), "lang": r.Request().Header.Get("Accept-Language")})
+		})
+
+		registry.MustRoute("/account", "account")
+		registry.HandleGet("account", func(ctx context.Context, r *web.Request) web.Result {
+			atomic.AddInt32(&accountCalls, 1)
+			response := new(web.Responder).Data("account")
+			response.CacheDirective = web.CacheDirectiveBuilder{IsReusable: true}.Build()
+			return response
+		})
+
+		registry.MustRoute("/uncached", "uncached")
+		registry.HandleGet("uncached", func(ctx context.Context, r *web.Request) web.Result {
+			atomic.AddInt32(&uncachedCalls, 1)
+			return new(web.Responder).Data("uncached")
+		})
+	})
+
+	get := func(t *testing.T, path string, header http.Header) string {
+		t.Helper()
+		request, err := http.NewRequest(http.MethodGet, server.URL+path, nil)
+		require.NoError(t, err)
+		for key, values := range header {
+			request.Header[key] = values
+		}
+		response, err := http.DefaultClient.Do(request)
+		require.NoError(t, err)
+		defer response.Body.Close()
+		require.Equal(t, http.StatusOK, response.StatusCode)
+		body, err := io.ReadAll(response.Body)
+		require.NoError(t, err)
+		return string(body)
+	}
+
+	first := get(t, "/product", nil)
+	assert.Equal(t, first, get(t, "/product", nil))
+	assert.Equal(t, int32(1), atomic.LoadInt32(&calls))
+
+	t.Run("vary rules", func(t *testing.T) {
+		assert.Equal(t, first, get(t, "/product?utm_source=newsletter", nil), "query parameters not in the allow list are ignored")
+		assert.Equal(t, int32(1), atomic.LoadInt32(&calls))
+
+		assert.NotEqual(t, first, get(t, "/product?page=2", nil))
+		assert.Equal(t, int32(2), atomic.LoadInt32(&calls))
+
+		assert.Contains(t, get(t, "/product", http.Header{"Accept-Language": {"de"}}), `"lang":"de"`)
+		assert.Equal(t, int32(3), atomic.LoadInt32(&calls))
+	})
+
+	t.Run("cache directive", func(t *testing.T) {
+		get(t, "/account", nil)
+		get(t, "/account", nil)
+		assert.Equal(t, int32(2), atomic.LoadInt32(&accountCalls), "private responses are not stored")
+
+		get(t, "/uncached", nil)
+		get(t, "/uncached", nil)
+		assert.Equal(t, int32(2), atomic.LoadInt32(&uncachedCalls), "only configured routes are cached")
+	})
+
+	t.Run("purge tags", func(t *testing.T) {
+		require.NoError(t, backend.PurgeTags([]string{"product-1"}))
+		assert.NotEqual(t, first, get(t, "/product", nil))
+		assert.Equal(t, int32(4), atomic.LoadInt32(&calls))
+
+		require.NoError(t, backend.PurgeTags([]string{PageRouteTag("product")}))
+		get(t, "/product", nil)
+		assert.Equal(t, int32(5), atomic.LoadInt32(&calls))
+	})
+}
+
+func TestPageCacheFilterGracetime(t *testing.T) {
+	var calls int32
+	rendering := make(chan struct{})
+	release := make(chan struct{})
+
+	server := newPageCacheTestServer(t, newPageCacheFilter(NewInMemoryCache(), "50ms", "1m"), func(registry *web.RouterRegistry) {
+		registry.MustRoute("/product", "product")
+		registry.HandleGet("product", func(ctx context.Context, r *web.Request) web.Result {
+			call := atomic.AddInt32(&calls, 1)
+			if call == 2 {
+				close(rendering)
+				<-release
+			}
+			return new(web.Responder).Data(call)
+		})
+	})
+
+	get := func() string {
+		response, err := http.Get(server.URL + "/product")
+		if err != nil {
+			return err.Error()
+		}
+		defer response.Body.Close()
+		body, _ := io.ReadAll(response.Body)
+		return strings.TrimSpace(string(body))
+	}
+
+	assert.Equal(t, "1", get())
+	time.Sleep(100 * time.Millisecond)
+
+	assert.Equal(t, "1", get(), "the stale page is served during the gracetime")
+	<-rendering
+	assert.Equal(t, "1", get(), "the page is rendered again only once")
+
+	close(release)
+	assert.Eventually(t, func() bool { return get() == "2" }, time.Second, 10*time.Millisecond, "the page is rendered again in the background")
+	assert.Equal(t, int32(2), atomic.LoadInt32(&calls))
+}
+
+func TestPageCacheFilterBackends(t *testing.T) {
+	server := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: server.Addr()})
+	t.Cleanup(func() { _ = client.Close() })
+
+	fileBackend := NewFileBackend(t.TempDir())
+	t.Cleanup(fileBackend.Close)
+
+	backends := map[string]Backend{
+		"redis": NewRedisBackend(client).WithKeyPrefix("page:"),
+		"file":  fileBackend,
+	}
+
+	for name, backend := range backends {
+		t.Run(name, func(t *testing.T) {
+			var calls int32
+
+			server := newPageCacheTestServer(t, newPageCacheFilter(backend, "1m", "1m"), func(registry *web.RouterRegistry) {
+				registry.MustRoute("/product", "product")
+				registry.HandleGet("product", func(ctx context.Context, r *web.Request) web.Result {
+					return new(web.Responder).Data(atomic.AddInt32(&calls, 1))
+				})
+			})
+
+			for i := 0; i < 2; i++ {
+				response, err := http.Get(server.URL + "/product")
+				require.NoError(t, err)
+				body, err := io.ReadAll(response.Body)
+				require.NoError(t, err)
+				_ = response.Body.Close()
+
+				assert.Equal(t, "1", strings.TrimSpace(string(body)))
+				assert.Equal(t, "application/json; charset=utf-8", response.Header.Get("Content-Type"))
+			}
+
+			assert.Equal(t, int32(1), atomic.LoadInt32(&calls), "the page is stored with the gob codec")
+		})
+	}
+}
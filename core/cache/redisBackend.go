@@ -0,0 +1,311 @@
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+
+	"flamingo.me/flamingo/v3/core/healthcheck/domain/healthcheck"
+)
+
+type (
+	// RedisBackend stores cache entries in redis, standalone or cluster.
+	// Tags are stored as sets, so PurgeTags works across all instances sharing the redis.
+	RedisBackend struct {
+		client    redis.UniversalClient
+		keyPrefix string
+		codec     Codec
+		timeout   time.Duration
+	}
+)
+
+const (
+	redisFieldData         = "data"
+	redisFieldTags         = "tags"
+	redisFieldLifetime     = "lifetime"
+	redisFieldGracetime    = "gracetime"
+	redisFieldStaleIfError = "staleIfError"
+	redisFieldError        = "error"
+)
+
+var (
+	// ErrFlushWithoutKeyPrefix is returned by Flush if no key prefix is set, which would delete the whole database
+	ErrFlushWithoutKeyPrefix = errors.New("redis backend without key prefix can not be flushed")
+
+	// redisExtendTTL sets the ttl of a key unless it already lives longer, like EXPIRE NX and GT which need redis 7
+	redisExtendTTL = redis.NewScript(`
+local ttl = redis.call("PTTL", KEYS[1])
+if ttl == -1 or ttl < tonumber(ARGV[1]) then
+	return redis.call("PEXPIRE", KEYS[1], ARGV[1])
+end
+return 0
+`)
+
+	_ Backend            = new(RedisBackend)
+	_ KeyLister          = new(RedisBackend)
+	_ healthcheck.Status = new(RedisBackend)
+)
+
+// NewRedisBackend creates a backend for the client, entries are encoded with the GobCodec by default
+func NewRedisBackend(client redis.UniversalClient) *RedisBackend {
+	return &RedisBackend{
+		client:  client,
+		codec:   GobCodec{},
+		timeout: 5 * time.Second,
+	}
+}
+
+// WithKeyPrefix sets the prefix of all keys written by the backend
+func (rb *RedisBackend) WithKeyPrefix(prefix string) *RedisBackend {
+	rb.keyPrefix = prefix
+	return rb
+}
+
+// WithCodec sets the codec for Entry.Data
+func (rb *RedisBackend) WithCodec(codec Codec) *RedisBackend {
+	rb.codec = codec
+	return rb
+}
+
+// WithTimeout sets the timeout of redis operations
+func (rb *RedisBackend) WithTimeout(timeout time.Duration) *RedisBackend {
+	rb.timeout = timeout
+	return rb
+}
+
+func (rb *RedisBackend) context() (context.Context, context.CancelFunc) {
+	if rb.timeout > 0 {
+		return context.WithTimeout(context.Background(), rb.timeout)
+	}
+	return context.WithCancel(context.Background())
+}
+
+func (rb *RedisBackend) entryKey(key string) string {
+	return rb.keyPrefix + "entry:" + key
+}
+
+func (rb *RedisBackend) tagKey(tag string) string {
+	return rb.keyPrefix + "tag:" + tag
+}
+
+// Get reads a cache entry
+func (rb *RedisBackend) Get(key string) (*Entry, bool) {
+	ctx, cancel := rb.context()
+	defer cancel()
+
+	fields, err := rb.client.HGetAll(ctx, rb.entryKey(key)).Result()
+	if err != nil || len(fields) == 0 {
+		return nil, false
+	}
+
+	data, err := rb.codec.Decode([]byte(fields[redisFieldData]))
+	if err != nil {
+		return nil, false
+	}
+
+	entry := &Entry{Data: data}
+	if tags := fields[redisFieldTags]; tags != "" {
+		_ = json.Unmarshal([]byte(tags), &entry.Meta.Tags)
+	}
+	entry.Meta.lifetime = parseRedisTime(fields[redisFieldLifetime])
+	entry.Meta.gracetime = parseRedisTime(fields[redisFieldGracetime])
+	entry.Meta.staleIfError = parseRedisTime(fields[redisFieldStaleIfError])
+	if message, ok := fields[redisFieldError]; ok {
+		//nolint:err113 // the original error type is lost in redis
+		entry.Meta.err = errors.New(message)
+	}
+
+	return entry, true
+}
+
+// Set writes a cache entry and adds its key to the sets of its tags
+func (rb *RedisBackend) Set(key string, entry *Entry) error {
+	data, err := rb.codec.Encode(entry.Data)
+	if err != nil {
+		return fmt.Errorf("redis backend: failed to encode %q: %w", key, err)
+	}
+
+	var ttl time.Duration
+	if validUntil := entry.Meta.validUntil(); !validUntil.IsZero() {
+		ttl = time.Until(validUntil)
+		if ttl <= 0 {
+			return nil
+		}
+	} else {
+		ttl = entry.Meta.Lifetime + entry.Meta.Gracetime + entry.Meta.StaleIfError
+	}
+
+	tags, err := json.Marshal(entry.Meta.Tags)
+	if err != nil {
+		return fmt.Errorf("redis backend: failed to encode tags of %q: %w", key, err)
+	}
+
+	fields := map[string]interface{}{
+		redisFieldData:         data,
+		redisFieldTags:         tags,
+		redisFieldLifetime:     formatRedisTime(entry.Meta.lifetime),
+		redisFieldGracetime:    formatRedisTime(entry.Meta.gracetime),
+		redisFieldStaleIfError: formatRedisTime(entry.Meta.staleIfError),
+	}
+	if entry.Meta.err != nil {
+		fields[redisFieldError] = entry.Meta.err.Error()
+	}
+
+	ctx, cancel := rb.context()
+	defer cancel()
+
+	entryKey := rb.entryKey(key)
+	_, err = rb.client.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.Del(ctx, entryKey)
+		pipe.HSet(ctx, entryKey, fields)
+		if ttl > 0 {
+			pipe.PExpire(ctx, entryKey, ttl)
+		}
+
+		for _, tag := range entry.Meta.Tags {
+			tagKey := rb.tagKey(tag)
+			pipe.SAdd(ctx, tagKey, key)
+			if ttl > 0 {
+				// the tag set lives as long as its longest living entry
+				redisExtendTTL.Eval(ctx, pipe, []string{tagKey}, ttl.Milliseconds())
+			} else {
+				pipe.Persist(ctx, tagKey)
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("redis backend: failed to set %q: %w", key, err)
+	}
+
+	return nil
+}
+
+// Purge deletes a cache entry
+func (rb *RedisBackend) Purge(key string) error {
+	ctx, cancel := rb.context()
+	defer cancel()
+
+	return rb.client.Del(ctx, rb.entryKey(key)).Err()
+}
+
+// PurgeTags deletes all entries with one of the tags
+func (rb *RedisBackend) PurgeTags(tags []string) error {
+	ctx, cancel := rb.context()
+	defer cancel()
+
+	for _, tag := range tags {
+		tagKey := rb.tagKey(tag)
+		keys, err := rb.client.SMembers(ctx, tagKey).Result()
+		if err != nil {
+			return fmt.Errorf("redis backend: failed to read tag %q: %w", tag, err)
+		}
+
+		// keys are deleted one by one, since they might be in different cluster slots
+		_, err = rb.client.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+			for _, key := range keys {
+				pipe.Del(ctx, rb.entryKey(key))
+			}
+			pipe.Del(ctx, tagKey)
+			return nil
+		})
+		if err != nil {
+			return fmt.Errorf("redis backend: failed to purge tag %q: %w", tag, err)
+		}
+	}
+
+	return nil
+}
+
+// Flush deletes all entries and tags with the key prefix of the backend
+func (rb *RedisBackend) Flush() error {
+	if rb.keyPrefix == "" {
+		return ErrFlushWithoutKeyPrefix
+	}
+
+	ctx, cancel := rb.context()
+	defer cancel()
+
+	flush := func(ctx context.Context, client redis.UniversalClient) error {
+		iter := client.Scan(ctx, 0, rb.keyPrefix+"*", 100).Iterator()
+		for iter.Next(ctx) {
+			if err := client.Del(ctx, iter.Val()).Err(); err != nil {
+				return err
+			}
+		}
+		return iter.Err()
+	}
+
+	if cluster, ok := rb.client.(*redis.ClusterClient); ok {
+		return cluster.ForEachMaster(ctx, func(ctx context.Context, client *redis.Client) error {
+			return flush(ctx, client)
+		})
+	}
+
+	return flush(ctx, rb.client)
+}
+
+// Keys of all entries with the key prefix of the backend
+func (rb *RedisBackend) Keys() ([]string, error) {
+	ctx, cancel := rb.context()
+	defer cancel()
+
+	var (
+		mu   sync.Mutex
+		keys []string
+	)
+	prefix := rb.entryKey("")
+	scan := func(ctx context.Context, client redis.UniversalClient) error {
+		iter := client.Scan(ctx, 0, prefix+"*", 100).Iterator()
+		for iter.Next(ctx) {
+			mu.Lock()
+			keys = append(keys, strings.TrimPrefix(iter.Val(), prefix))
+			mu.Unlock()
+		}
+		return iter.Err()
+	}
+
+	if cluster, ok := rb.client.(*redis.ClusterClient); ok {
+		err := cluster.ForEachMaster(ctx, func(ctx context.Context, client *redis.Client) error {
+			return scan(ctx, client)
+		})
+		return keys, err
+	}
+
+	return keys, scan(ctx, rb.client)
+}
+
+// Status checks if the redis server is available
+func (rb *RedisBackend) Status() (bool, string) {
+	ctx, cancel := rb.context()
+	defer cancel()
+
+	if err := rb.client.Ping(ctx).Err(); err != nil {
+		return false, err.Error()
+	}
+
+	return true, "success"
+}
+
+func formatRedisTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return strconv.FormatInt(t.UnixNano(), 10)
+}
+
+func parseRedisTime(value string) time.Time {
+	nanos, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return time.Time{}
+	}
+	return time.Unix(0, nanos)
+}
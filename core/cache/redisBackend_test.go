@@ -0,0 +1,187 @@
+package cache_test
+
+import (
+	"context"
+	"encoding/gob"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"flamingo.me/flamingo/v3/core/cache"
+	"flamingo.me/flamingo/v3/framework/flamingo"
+)
+
+type redisTestProduct struct {
+	ID   string
+	Name string
+}
+
+func init() {
+	gob.Register(redisTestProduct{})
+}
+
+func newRedisTestBackend(t *testing.T) (*miniredis.Miniredis, redis.UniversalClient) {
+	t.Helper()
+
+	server := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: server.Addr()})
+	t.Cleanup(func() { _ = client.Close() })
+
+	return server, client
+}
+
+func TestRedisBackend_SetGet(t *testing.T) {
+	t.Parallel()
+
+	server, client := newRedisTestBackend(t)
+	backend := cache.NewRedisBackend(client).WithKeyPrefix("test:")
+
+	require.NoError(t, backend.Set("product", &cache.Entry{
+		Meta: cache.Meta{Tags: []string{"product-1"}, Lifetime: time.Minute, Gracetime: time.Minute},
+		Data: redisTestProduct{ID: "1", Name: "flamingo"},
+	}))
+
+	entry, found := backend.Get("product")
+	require.True(t, found)
+	assert.Equal(t, redisTestProduct{ID: "1", Name: "flamingo"}, entry.Data)
+	assert.Equal(t, []string{"product-1"}, entry.Meta.Tags)
+
+	assert.True(t, server.Exists("test:entry:product"))
+	assert.Equal(t, 2*time.Minute, server.TTL("test:entry:product"))
+
+	server.FastForward(3 * time.Minute)
+	_, found = backend.Get("product")
+	assert.False(t, found)
+
+	_, found = backend.Get("unknown")
+	assert.False(t, found)
+}
+
+func TestRedisBackend_Frontend(t *testing.T) {
+	t.Parallel()
+
+	_, client := newRedisTestBackend(t)
+	frontend := cache.NewFrontend[redisTestProduct](cache.NewRedisBackend(client), flamingo.NullLogger{}).WithErrorTTL(time.Minute)
+
+	product, err := frontend.Get(context.Background(), "product", func(ctx context.Context) (redisTestProduct, *cache.Meta, error) {
+		return redisTestProduct{ID: "1"}, nil, nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "1", product.ID)
+
+	product, err = frontend.Get(context.Background(), "product", func(ctx context.Context) (redisTestProduct, *cache.Meta, error) {
+		return redisTestProduct{ID: "2"}, nil, nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "1", product.ID, "the entry is served from redis")
+
+	errLoad := errors.New("unavailable")
+	_, err = frontend.Get(context.Background(), "failing", func(ctx context.Context) (redisTestProduct, *cache.Meta, error) {
+		return redisTestProduct{}, nil, errLoad
+	})
+	assert.ErrorIs(t, err, errLoad)
+
+	_, err = frontend.Get(context.Background(), "failing", func(ctx context.Context) (redisTestProduct, *cache.Meta, error) {
+		return redisTestProduct{ID: "3"}, nil, nil
+	})
+	assert.EqualError(t, err, "unavailable", "errors are cached in redis")
+}
+
+func TestRedisBackend_PurgeTags(t *testing.T) {
+	t.Parallel()
+
+	server, client := newRedisTestBackend(t)
+	// two instances sharing the same redis
+	first := cache.NewRedisBackend(client)
+	second := cache.NewRedisBackend(client)
+
+	for key, tags := range map[string][]string{"foo": {"bar"}, "baz": {"bar", "qux"}, "quux": {"qux"}} {
+		require.NoError(t, first.Set(key, &cache.Entry{Meta: cache.Meta{Tags: tags, Lifetime: time.Minute}, Data: key}))
+	}
+	assert.Equal(t, time.Minute, server.TTL("tag:bar"))
+
+	require.NoError(t, second.PurgeTags([]string{"bar"}))
+
+	_, found := first.Get("foo")
+	assert.False(t, found)
+	_, found = first.Get("baz")
+	assert.False(t, found)
+	_, found = first.Get("quux")
+	assert.True(t, found)
+	assert.False(t, server.Exists("tag:bar"))
+
+	require.NoError(t, second.Purge("quux"))
+	_, found = first.Get("quux")
+	assert.False(t, found)
+}
+
+func TestRedisBackend_TagLifetime(t *testing.T) {
+	t.Parallel()
+
+	server, client := newRedisTestBackend(t)
+	backend := cache.NewRedisBackend(client)
+
+	require.NoError(t, backend.Set("foo", &cache.Entry{Meta: cache.Meta{Tags: []string{"bar"}, Lifetime: 2 * time.Minute}, Data: "foo"}))
+	assert.Equal(t, 2*time.Minute, server.TTL("tag:bar"))
+
+	require.NoError(t, backend.Set("baz", &cache.Entry{Meta: cache.Meta{Tags: []string{"bar"}, Lifetime: time.Minute}, Data: "baz"}))
+	assert.Equal(t, 2*time.Minute, server.TTL("tag:bar"), "shorter living entries keep the lifetime of the tag")
+
+	require.NoError(t, backend.Set("qux", &cache.Entry{Meta: cache.Meta{Tags: []string{"bar"}, Lifetime: 3 * time.Minute}, Data: "qux"}))
+	assert.Equal(t, 3*time.Minute, server.TTL("tag:bar"), "longer living entries extend the lifetime of the tag")
+}
+
+func TestRedisBackend_Flush(t *testing.T) {
+	t.Parallel()
+
+	server, client := newRedisTestBackend(t)
+	require.NoError(t, server.Set("other", "value"))
+
+	backend := cache.NewRedisBackend(client).WithKeyPrefix("cache:")
+	require.NoError(t, backend.Set("foo", &cache.Entry{Meta: cache.Meta{Tags: []string{"bar"}}, Data: "foo"}))
+
+	require.NoError(t, backend.Flush())
+
+	_, found := backend.Get("foo")
+	assert.False(t, found)
+	assert.Equal(t, []string{"other"}, server.Keys(), "keys without the prefix are kept")
+
+	backend = cache.NewRedisBackend(client)
+	require.NoError(t, backend.Set("foo", &cache.Entry{Data: "foo"}))
+	assert.ErrorIs(t, backend.Flush(), cache.ErrFlushWithoutKeyPrefix)
+	assert.Equal(t, []string{"entry:foo", "other"}, server.Keys(), "backends without prefix must not flush the database")
+}
+
+func TestRedisBackend_JSONCodec(t *testing.T) {
+	t.Parallel()
+
+	_, client := newRedisTestBackend(t)
+	backend := cache.NewRedisBackend(client).WithCodec(cache.JSONCodec[map[string]int]{})
+
+	require.NoError(t, backend.Set("counts", &cache.Entry{Meta: cache.Meta{Lifetime: time.Minute}, Data: map[string]int{"a": 1}}))
+
+	entry, found := backend.Get("counts")
+	require.True(t, found)
+	assert.Equal(t, map[string]int{"a": 1}, entry.Data)
+}
+
+func TestRedisBackend_Status(t *testing.T) {
+	t.Parallel()
+
+	server, client := newRedisTestBackend(t)
+	backend := cache.NewRedisBackend(client).WithTimeout(time.Second)
+
+	alive, details := backend.Status()
+	assert.True(t, alive)
+	assert.Equal(t, "success", details)
+
+	server.Close()
+
+	alive, _ = backend.Status()
+	assert.False(t, alive)
+}
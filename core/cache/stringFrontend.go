@@ -1,10 +1,7 @@
 package cache
 
 import (
-	"time"
-
-	"go.opencensus.io/trace"
-	"golang.org/x/sync/singleflight"
+	"context"
 )
 
 type (
@@ -13,56 +10,22 @@ type (
 
 	// StringFrontend manages cache entries as strings
 	StringFrontend struct {
-		singleflight.Group
-		backend Backend
+		frontend *Frontend[string]
 	}
 )
 
 // Inject StringFrontend dependencies
 func (sf *StringFrontend) Inject(backend Backend) {
-	sf.backend = backend
+	sf.frontend = NewFrontend[string](backend, nil).WithName("string")
 }
 
 // Get and load string cache entries
 func (sf *StringFrontend) Get(key string, loader StringLoader) (string, error) {
-	if entry, ok := sf.backend.Get(key); ok {
-		if entry.Meta.lifetime.After(time.Now()) {
-			return entry.Data.(string), nil
-		}
-
-		if entry.Meta.gracetime.After(time.Now()) {
-			go sf.load(key, loader)
-			return entry.Data.(string), nil
-		}
+	if sf.frontend == nil {
+		return "", ErrNoBackend
 	}
 
-	return sf.load(key, loader)
-}
-
-func (sf *StringFrontend) load(key string, loader StringLoader) (string, error) {
-	data, err, _ := sf.Do(key, func() (interface{}, error) {
-		data, meta, err := loader()
-		if meta == nil {
-			meta = &Meta{
-				Lifetime:  30 * time.Second,
-				Gracetime: 10 * time.Minute,
-			}
-		}
-		return loaderResponse{data, meta, trace.SpanContext{}}, err
+	return sf.frontend.Get(context.Background(), key, func(context.Context) (string, *Meta, error) {
+		return loader()
 	})
-
-	if err != nil {
-		return "", err
-	}
-
-	sf.backend.Set(key, &Entry{
-		Data: data.(loaderResponse).data,
-		Meta: Meta{
-			lifetime:  time.Now().Add(data.(loaderResponse).meta.Lifetime),
-			gracetime: time.Now().Add(data.(loaderResponse).meta.Lifetime + data.(loaderResponse).meta.Gracetime),
-			Tags:      data.(loaderResponse).meta.Tags,
-		},
-	})
-
-	return data.(string), nil
 }
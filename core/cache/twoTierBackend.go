@@ -0,0 +1,236 @@
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/gofrs/uuid"
+	"github.com/redis/go-redis/v9"
+)
+
+type (
+	// TwoTierBackend reads from a process-local backend first and falls back to a shared backend.
+	// Purges and flushes are broadcast to the other instances, so they drop their local copies.
+	TwoTierBackend struct {
+		local       Backend
+		shared      Backend
+		transport   InvalidationTransport
+		origin      string
+		unsubscribe func()
+	}
+
+	// Invalidation is broadcast to other instances when entries are purged or flushed
+	Invalidation struct {
+		Origin string   `json:"origin"`
+		Keys   []string `json:"keys,omitempty"`
+		Tags   []string `json:"tags,omitempty"`
+		Flush  bool     `json:"flush,omitempty"`
+	}
+
+	// InvalidationTransport broadcasts invalidations between instances
+	InvalidationTransport interface {
+		Publish(ctx context.Context, invalidation Invalidation) error
+		Subscribe(handler func(Invalidation)) (unsubscribe func(), err error)
+	}
+
+	// InProcessTransport broadcasts invalidations to all subscribers in the same process
+	InProcessTransport struct {
+		mu       sync.RWMutex
+		handlers map[int]func(Invalidation)
+		next     int
+	}
+
+	// RedisTransport broadcasts invalidations with redis pub/sub
+	RedisTransport struct {
+		client  redis.UniversalClient
+		channel string
+	}
+)
+
+var (
+	_ Backend               = new(TwoTierBackend)
+	_ KeyLister             = new(TwoTierBackend)
+	_ InvalidationTransport = new(InProcessTransport)
+	_ InvalidationTransport = new(RedisTransport)
+)
+
+// NewTwoTierBackend creates a two tier backend and subscribes to invalidations of other instances
+func NewTwoTierBackend(local, shared Backend, transport InvalidationTransport) (*TwoTierBackend, error) {
+	backend := &TwoTierBackend{
+		local:     local,
+		shared:    shared,
+		transport: transport,
+		origin:    uuid.Must(uuid.NewV4()).String(),
+	}
+
+	unsubscribe, err := transport.Subscribe(backend.invalidate)
+	if err != nil {
+		return nil, fmt.Errorf("two tier backend: failed to subscribe to invalidations: %w", err)
+	}
+	backend.unsubscribe = unsubscribe
+
+	return backend, nil
+}
+
+// Close stops receiving invalidations
+func (tb *TwoTierBackend) Close() {
+	if tb.unsubscribe != nil {
+		tb.unsubscribe()
+	}
+}
+
+// invalidate the local tier for invalidations of other instances
+func (tb *TwoTierBackend) invalidate(invalidation Invalidation) {
+	if invalidation.Origin == tb.origin {
+		return
+	}
+
+	if invalidation.Flush {
+		_ = tb.local.Flush()
+		return
+	}
+
+	for _, key := range invalidation.Keys {
+		_ = tb.local.Purge(key)
+	}
+
+	if len(invalidation.Tags) > 0 {
+		_ = tb.local.PurgeTags(invalidation.Tags)
+	}
+}
+
+func (tb *TwoTierBackend) publish(invalidation Invalidation) error {
+	invalidation.Origin = tb.origin
+	if err := tb.transport.Publish(context.Background(), invalidation); err != nil {
+		return fmt.Errorf("two tier backend: failed to publish invalidation: %w", err)
+	}
+	return nil
+}
+
+// Get an entry from the local tier, or from the shared tier which then fills the local tier
+func (tb *TwoTierBackend) Get(key string) (*Entry, bool) {
+	if entry, found := tb.local.Get(key); found {
+		return entry, true
+	}
+
+	entry, found := tb.shared.Get(key)
+	if !found {
+		return nil, false
+	}
+
+	_ = tb.local.Set(key, entry)
+
+	return entry, true
+}
+
+// Set an entry in both tiers
+func (tb *TwoTierBackend) Set(key string, entry *Entry) error {
+	if err := tb.shared.Set(key, entry); err != nil {
+		return err
+	}
+
+	return tb.local.Set(key, entry)
+}
+
+// Purge an entry from both tiers and the local tier of all other instances
+func (tb *TwoTierBackend) Purge(key string) error {
+	return errors.Join(tb.local.Purge(key), tb.shared.Purge(key), tb.publish(Invalidation{Keys: []string{key}}))
+}
+
+// PurgeTags purges tagged entries from both tiers and the local tier of all other instances
+func (tb *TwoTierBackend) PurgeTags(tags []string) error {
+	return errors.Join(tb.local.PurgeTags(tags), tb.shared.PurgeTags(tags), tb.publish(Invalidation{Tags: tags}))
+}
+
+// Flush both tiers and the local tier of all other instances
+func (tb *TwoTierBackend) Flush() error {
+	return errors.Join(tb.local.Flush(), tb.shared.Flush(), tb.publish(Invalidation{Flush: true}))
+}
+
+// Keys of the shared tier, if it can list its keys
+func (tb *TwoTierBackend) Keys() ([]string, error) {
+	lister, ok := tb.shared.(KeyLister)
+	if !ok {
+		return nil, ErrKeysNotSupported
+	}
+
+	return lister.Keys()
+}
+
+// NewInProcessTransport creates a transport for backends in the same process
+func NewInProcessTransport() *InProcessTransport {
+	return &InProcessTransport{handlers: make(map[int]func(Invalidation))}
+}
+
+// Publish calls all subscribers
+func (t *InProcessTransport) Publish(_ context.Context, invalidation Invalidation) error {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	for _, handler := range t.handlers {
+		handler(invalidation)
+	}
+
+	return nil
+}
+
+// Subscribe adds the handler until unsubscribe is called
+func (t *InProcessTransport) Subscribe(handler func(Invalidation)) (func(), error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	id := t.next
+	t.next++
+	t.handlers[id] = handler
+
+	return func() {
+		t.mu.Lock()
+		defer t.mu.Unlock()
+		delete(t.handlers, id)
+	}, nil
+}
+
+// NewRedisTransport creates a transport publishing to the redis channel
+func NewRedisTransport(client redis.UniversalClient, channel string) *RedisTransport {
+	return &RedisTransport{client: client, channel: channel}
+}
+
+// Publish the invalidation to the channel
+func (t *RedisTransport) Publish(ctx context.Context, invalidation Invalidation) error {
+	payload, err := json.Marshal(invalidation)
+	if err != nil {
+		return err
+	}
+
+	return t.client.Publish(ctx, t.channel, payload).Err()
+}
+
+// Subscribe to the channel, the handler is called for each received invalidation until unsubscribe is called
+func (t *RedisTransport) Subscribe(handler func(Invalidation)) (func(), error) {
+	ctx := context.Background()
+	pubsub := t.client.Subscribe(ctx, t.channel)
+	if _, err := pubsub.Receive(ctx); err != nil {
+		_ = pubsub.Close()
+		return nil, err
+	}
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for message := range pubsub.Channel() {
+			var invalidation Invalidation
+			if err := json.Unmarshal([]byte(message.Payload), &invalidation); err != nil {
+				continue
+			}
+			handler(invalidation)
+		}
+	}()
+
+	return func() {
+		_ = pubsub.Close()
+		<-done
+	}, nil
+}
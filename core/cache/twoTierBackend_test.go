@@ -0,0 +1,104 @@
+package cache_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"flamingo.me/flamingo/v3/core/cache"
+)
+
+func TestTwoTierBackend(t *testing.T) {
+	t.Parallel()
+
+	shared := cache.NewInMemoryCache()
+	transport := cache.NewInProcessTransport()
+
+	firstLocal, secondLocal := cache.NewInMemoryCache(), cache.NewInMemoryCache()
+	first, err := cache.NewTwoTierBackend(firstLocal, shared, transport)
+	require.NoError(t, err)
+	t.Cleanup(first.Close)
+	second, err := cache.NewTwoTierBackend(secondLocal, shared, transport)
+	require.NoError(t, err)
+	t.Cleanup(second.Close)
+
+	entry := func(data string, tags ...string) *cache.Entry {
+		return &cache.Entry{Meta: cache.Meta{Tags: tags, Lifetime: time.Minute}, Data: data}
+	}
+
+	require.NoError(t, first.Set("foo", entry("foo", "bar")))
+	require.NoError(t, first.Set("baz", entry("baz", "qux")))
+
+	_, found := secondLocal.Get("foo")
+	assert.False(t, found)
+
+	got, found := second.Get("foo")
+	require.True(t, found)
+	assert.Equal(t, "foo", got.Data)
+	_, found = secondLocal.Get("foo")
+	assert.True(t, found, "the local tier is filled on read")
+
+	t.Run("purge", func(t *testing.T) {
+		_, found := second.Get("baz")
+		require.True(t, found)
+
+		require.NoError(t, first.Purge("baz"))
+
+		_, found = secondLocal.Get("baz")
+		assert.False(t, found, "the purge is broadcast")
+		_, found = second.Get("baz")
+		assert.False(t, found)
+	})
+
+	t.Run("purge tags", func(t *testing.T) {
+		require.NoError(t, first.PurgeTags([]string{"bar"}))
+
+		_, found := secondLocal.Get("foo")
+		assert.False(t, found, "the purge is broadcast")
+		_, found = second.Get("foo")
+		assert.False(t, found)
+	})
+
+	t.Run("flush", func(t *testing.T) {
+		require.NoError(t, first.Set("foo", entry("foo")))
+		_, found := second.Get("foo")
+		require.True(t, found)
+
+		require.NoError(t, second.Flush())
+
+		_, found = firstLocal.Get("foo")
+		assert.False(t, found, "the flush is broadcast")
+		_, found = first.Get("foo")
+		assert.False(t, found)
+	})
+}
+
+func TestRedisTransport(t *testing.T) {
+	t.Parallel()
+
+	_, client := newRedisTestBackend(t)
+	shared := cache.NewRedisBackend(client)
+
+	firstLocal, secondLocal := cache.NewInMemoryCache(), cache.NewInMemoryCache()
+	first, err := cache.NewTwoTierBackend(firstLocal, shared, cache.NewRedisTransport(client, "invalidations"))
+	require.NoError(t, err)
+	t.Cleanup(first.Close)
+	second, err := cache.NewTwoTierBackend(secondLocal, shared, cache.NewRedisTransport(client, "invalidations"))
+	require.NoError(t, err)
+	t.Cleanup(second.Close)
+
+	require.NoError(t, first.Set("foo", &cache.Entry{Meta: cache.Meta{Tags: []string{"bar"}, Lifetime: time.Minute}, Data: "foo"}))
+
+	got, found := second.Get("foo")
+	require.True(t, found)
+	assert.Equal(t, "foo", got.Data)
+
+	require.NoError(t, first.PurgeTags([]string{"bar"}))
+
+	assert.Eventually(t, func() bool {
+		_, found := secondLocal.Get("foo")
+		return !found
+	}, time.Second, 5*time.Millisecond, "the purge is broadcast via redis")
+}
@@ -0,0 +1,260 @@
+package csrf
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"net/http"
+	"net/url"
+	"path"
+
+	"flamingo.me/flamingo/v3/framework/config"
+	"flamingo.me/flamingo/v3/framework/web"
+)
+
+type (
+	// Service creates and validates CSRF tokens.
+	// In session mode the token is stored in the session (synchronizer token),
+	// in cookie mode it is stored in a cookie which must be sent back as form value or header (double submit cookie).
+	Service struct {
+		mode           string
+		formField      string
+		header         string
+		cookieName     string
+		cookiePath     string
+		cookieSecure   bool
+		cookieSameSite http.SameSite
+		checkOrigin    bool
+		trustedOrigins []string
+	}
+)
+
+const (
+	// ModeSession stores the token in the session
+	ModeSession = "session"
+	// ModeCookie stores the token in a cookie, for areas without sessions
+	ModeCookie = "cookie"
+
+	tokenLength = 32
+
+	// requestValueToken caches the raw token of the request in web.Request.Values
+	requestValueToken = "core.csrf.token"
+)
+
+var (
+	// ErrTokenMissing is returned if the request or the session/cookie has no token
+	ErrTokenMissing = errors.New("csrf token missing")
+	// ErrTokenInvalid is returned if the submitted token does not match
+	ErrTokenInvalid = errors.New("csrf token invalid")
+	// ErrOriginMismatch is returned if the Origin or Referer header points to a foreign origin
+	ErrOriginMismatch = errors.New("csrf origin mismatch")
+
+	sessionToken = web.NewSessionKey[string]("core.csrf.token")
+)
+
+// Inject dependencies
+func (s *Service) Inject(
+	cfg *struct {
+		Mode           string       `inject:"config:core.csrf.mode"`
+		FormField      string       `inject:"config:core.csrf.formField"`
+		Header         string       `inject:"config:core.csrf.header"`
+		CookieName     string       `inject:"config:core.csrf.cookie.name"`
+		CookiePath     string       `inject:"config:core.csrf.cookie.path"`
+		CookieSecure   bool         `inject:"config:core.csrf.cookie.secure"`
+		CookieSameSite string       `inject:"config:core.csrf.cookie.sameSite"`
+		CheckOrigin    bool         `inject:"config:core.csrf.checkOrigin"`
+		TrustedOrigins config.Slice `inject:"config:core.csrf.trustedOrigins"`
+	},
+) *Service {
+	if cfg != nil {
+		s.mode = cfg.Mode
+		s.formField = cfg.FormField
+		s.header = cfg.Header
+		s.cookieName = cfg.CookieName
+		s.cookiePath = cfg.CookiePath
+		s.cookieSecure = cfg.CookieSecure
+		s.cookieSameSite = parseSameSite(cfg.CookieSameSite)
+		s.checkOrigin = cfg.CheckOrigin
+		_ = cfg.TrustedOrigins.MapInto(&s.trustedOrigins)
+	}
+
+	return s
+}
+
+func parseSameSite(sameSite string) http.SameSite {
+	switch sameSite {
+	case "strict":
+		return http.SameSiteStrictMode
+	case "none":
+		return http.SameSiteNoneMode
+	case "lax":
+		return http.SameSiteLaxMode
+	default:
+		return http.SameSiteDefaultMode
+	}
+}
+
+// FormField is the name of the form value which contains the token
+func (s *Service) FormField() string {
+	return s.formField
+}
+
+// Token returns a masked token for the request, every call returns a different representation of the same token.
+// Missing tokens are created, in cookie mode the filter sets the cookie.
+func (s *Service) Token(req *web.Request) string {
+	return mask(s.rawToken(req))
+}
+
+// Validate the submitted token and the origin of the request
+func (s *Service) Validate(req *web.Request) error {
+	if s.checkOrigin {
+		if err := s.verifyOrigin(req); err != nil {
+			return err
+		}
+	}
+
+	expected := s.storedToken(req)
+	if expected == nil {
+		return ErrTokenMissing
+	}
+
+	submitted := req.Request().Header.Get(s.header)
+	if submitted == "" {
+		submitted = req.Request().PostFormValue(s.formField)
+	}
+	if submitted == "" {
+		return ErrTokenMissing
+	}
+
+	token, ok := unmask(submitted)
+	if !ok || subtle.ConstantTimeCompare(token, expected) != 1 {
+		return ErrTokenInvalid
+	}
+
+	return nil
+}
+
+// rawToken returns the token of the request and creates it if necessary
+func (s *Service) rawToken(req *web.Request) []byte {
+	if token, ok := req.Values.Load(requestValueToken); ok {
+		return token.([]byte)
+	}
+
+	token := s.storedToken(req)
+	if token == nil {
+		token = make([]byte, tokenLength)
+		_, _ = rand.Read(token)
+
+		if s.mode == ModeSession {
+			_ = sessionToken.Set(req.Session(), base64.RawURLEncoding.EncodeToString(token))
+		}
+	}
+
+	req.Values.Store(requestValueToken, token)
+
+	return token
+}
+
+// storedToken returns the token from the session or cookie, nil if there is none
+func (s *Service) storedToken(req *web.Request) []byte {
+	var encoded string
+	if s.mode == ModeCookie {
+		cookie, err := req.Request().Cookie(s.cookieName)
+		if err != nil {
+			return nil
+		}
+		encoded = cookie.Value
+	} else {
+		var found bool
+		if encoded, found = sessionToken.Get(req.Session()); !found {
+			return nil
+		}
+	}
+
+	token, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil || len(token) != tokenLength {
+		return nil
+	}
+
+	return token
+}
+
+// ensureCookie sets the token cookie if the request has none
+func (s *Service) ensureCookie(req *web.Request, rw http.ResponseWriter) {
+	if s.storedToken(req) != nil {
+		return
+	}
+
+	http.SetCookie(rw, &http.Cookie{
+		Name:     s.cookieName,
+		Value:    base64.RawURLEncoding.EncodeToString(s.rawToken(req)),
+		Path:     s.cookiePath,
+		Secure:   s.cookieSecure,
+		SameSite: s.cookieSameSite,
+		// the cookie is readable by scripts, so they can send it in the header
+		HttpOnly: false,
+	})
+}
+
+// verifyOrigin checks the Origin header, or the Referer header if there is no Origin, against the requested host and the trusted origins.
+// Requests without both headers are only checked by their token.
+func (s *Service) verifyOrigin(req *web.Request) error {
+	origin := req.Request().Header.Get("Origin")
+	if origin == "" {
+		origin = req.Request().Referer()
+		if origin == "" {
+			return nil
+		}
+	}
+
+	u, err := url.Parse(origin)
+	if err != nil || u.Host == "" {
+		return ErrOriginMismatch
+	}
+
+	if u.Host == req.Host() {
+		return nil
+	}
+
+	for _, trusted := range s.trustedOrigins {
+		if matched, _ := path.Match(trusted, u.Scheme+"://"+u.Host); matched {
+			return nil
+		}
+	}
+
+	return ErrOriginMismatch
+}
+
+// mask the token with a one-time pad, so the token in responses changes on every request (BREACH)
+func mask(token []byte) string {
+	masked := make([]byte, 2*len(token))
+	_, _ = rand.Read(masked[:len(token)])
+
+	for i := range token {
+		masked[len(token)+i] = masked[i] ^ token[i]
+	}
+
+	return base64.RawURLEncoding.EncodeToString(masked)
+}
+
+// unmask a masked token, unmasked tokens e.g. from the cookie are returned as they are
+func unmask(encoded string) ([]byte, bool) {
+	masked, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, false
+	}
+
+	switch len(masked) {
+	case tokenLength:
+		return masked, true
+	case 2 * tokenLength:
+		token := make([]byte, tokenLength)
+		for i := range token {
+			token[i] = masked[i] ^ masked[tokenLength+i]
+		}
+		return token, true
+	}
+
+	return nil, false
+}
@@ -0,0 +1,179 @@
+package csrf
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"flamingo.me/flamingo/v3/framework/config"
+	"flamingo.me/flamingo/v3/framework/flamingo"
+	"flamingo.me/flamingo/v3/framework/web"
+)
+
+func testService(mode string) *Service {
+	return new(Service).Inject(&struct {
+		Mode           string       `inject:"config:core.csrf.mode"`
+		FormField      string       `inject:"config:core.csrf.formField"`
+		Header         string       `inject:"config:core.csrf.header"`
+		CookieName     string       `inject:"config:core.csrf.cookie.name"`
+		CookiePath     string       `inject:"config:core.csrf.cookie.path"`
+		CookieSecure   bool         `inject:"config:core.csrf.cookie.secure"`
+		CookieSameSite string       `inject:"config:core.csrf.cookie.sameSite"`
+		CheckOrigin    bool         `inject:"config:core.csrf.checkOrigin"`
+		TrustedOrigins config.Slice `inject:"config:core.csrf.trustedOrigins"`
+	}{
+		Mode:           mode,
+		FormField:      "csrftoken",
+		Header:         "X-CSRF-Token",
+		CookieName:     "csrf_token",
+		CookiePath:     "/",
+		CookieSameSite: "lax",
+		CheckOrigin:    true,
+		TrustedOrigins: config.Slice{"https://*.example.com"},
+	})
+}
+
+func TestServiceToken(t *testing.T) {
+	service := testService(ModeSession)
+	session := web.EmptySession()
+
+	first := service.Token(web.CreateRequest(nil, session))
+	second := service.Token(web.CreateRequest(nil, session))
+	assert.NotEqual(t, first, second, "tokens are masked differently")
+
+	firstToken, ok := unmask(first)
+	require.True(t, ok)
+	secondToken, ok := unmask(second)
+	require.True(t, ok)
+	assert.Equal(t, firstToken, secondToken)
+
+	_, ok = unmask("invalid")
+	assert.False(t, ok)
+}
+
+func TestFilter(t *testing.T) {
+	responder := new(web.Responder).Inject(&web.Router{}, flamingo.NullLogger{}, &struct {
+		Engine                flamingo.TemplateEngine `inject:",optional"`
+		Debug                 bool                    `inject:"config:flamingo.debug.mode"`
+		TemplateBadRequest    string                  `inject:"config:flamingo.template.err400"`
+		TemplateForbidden     string                  `inject:"config:flamingo.template.err403"`
+		TemplateNotFound      string                  `inject:"config:flamingo.template.err404"`
+		TemplateUnavailable   string                  `inject:"config:flamingo.template.err503"`
+		TemplateErrorWithCode string                  `inject:"config:flamingo.template.errWithCode"`
+		Encoders              []web.Encoder           `inject:",optional"`
+		DefaultMediaType      string                  `inject:"config:flamingo.web.dataResponse.defaultMediaType,optional"`
+	}{})
+
+	sessionService := testService(ModeSession)
+	sessionFilter := new(filter).Inject(sessionService, responder, flamingo.NullLogger{}, &struct {
+		Exempt config.Slice `inject:"config:core.csrf.exempt"`
+	}{Exempt: config.Slice{"webhook"}})
+
+	session := web.EmptySession()
+	token := sessionService.Token(web.CreateRequest(nil, session))
+
+	cookieService := testService(ModeCookie)
+	cookieFilter := new(filter).Inject(cookieService, responder, flamingo.NullLogger{}, nil)
+
+	cookieRequest := web.CreateRequest(httptest.NewRequest(http.MethodGet, "/", nil), nil)
+	cookieRecorder := httptest.NewRecorder()
+	cookieFilter.Filter(context.Background(), cookieRequest, cookieRecorder, web.NewFilterChain(func(context.Context, *web.Request, http.ResponseWriter) web.Result {
+		return &web.Response{Status: http.StatusOK}
+	}))
+	require.Len(t, cookieRecorder.Result().Cookies(), 1)
+	cookie := cookieRecorder.Result().Cookies()[0]
+	assert.Equal(t, "csrf_token", cookie.Name)
+	cookieToken := cookieService.Token(cookieRequest)
+
+	tests := []struct {
+		name       string
+		filter     *filter
+		method     string
+		session    *web.Session
+		cookie     *http.Cookie
+		form       url.Values
+		header     http.Header
+		handler    string
+		err        error
+		setsCookie bool
+	}{
+		{name: "safe methods are not validated", filter: sessionFilter, method: http.MethodGet, session: web.EmptySession()},
+		{name: "form token", filter: sessionFilter, session: session, form: url.Values{"csrftoken": {token}}},
+		{name: "header token", filter: sessionFilter, session: session, header: http.Header{"X-Csrf-Token": {sessionService.Token(web.CreateRequest(nil, session))}}},
+		{name: "missing token", filter: sessionFilter, session: session, err: ErrTokenMissing},
+		{name: "token of another session", filter: sessionFilter, session: session, form: url.Values{"csrftoken": {sessionService.Token(web.CreateRequest(nil, web.EmptySession()))}}, err: ErrTokenInvalid},
+		{name: "session without token", filter: sessionFilter, session: web.EmptySession(), form: url.Values{"csrftoken": {token}}, err: ErrTokenMissing},
+		{name: "exempt handler", filter: sessionFilter, session: session, handler: "webhook"},
+		{name: "same origin", filter: sessionFilter, session: session, form: url.Values{"csrftoken": {token}}, header: http.Header{"Origin": {"http://shop.test"}}},
+		{name: "trusted origin", filter: sessionFilter, session: session, form: url.Values{"csrftoken": {token}}, header: http.Header{"Origin": {"https://checkout.example.com"}}},
+		{name: "foreign origin", filter: sessionFilter, session: session, form: url.Values{"csrftoken": {token}}, header: http.Header{"Origin": {"https://evil.test"}}, err: ErrOriginMismatch},
+		{name: "null origin", filter: sessionFilter, session: session, form: url.Values{"csrftoken": {token}}, header: http.Header{"Origin": {"null"}}, err: ErrOriginMismatch},
+		{name: "same referer", filter: sessionFilter, session: session, form: url.Values{"csrftoken": {token}}, header: http.Header{"Referer": {"http://shop.test/cart"}}},
+		{name: "foreign referer", filter: sessionFilter, session: session, form: url.Values{"csrftoken": {token}}, header: http.Header{"Referer": {"https://evil.test/form"}}, err: ErrOriginMismatch},
+		{name: "cookie is set on safe requests", filter: cookieFilter, method: http.MethodGet, setsCookie: true},
+		{name: "masked form token", filter: cookieFilter, cookie: cookie, form: url.Values{"csrftoken": {cookieToken}}},
+		{name: "cookie value in the header", filter: cookieFilter, cookie: cookie, header: http.Header{"X-Csrf-Token": {cookie.Value}}},
+		{name: "missing cookie", filter: cookieFilter, form: url.Values{"csrftoken": {cookieToken}}, err: ErrTokenMissing, setsCookie: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			method := tt.method
+			if method == "" {
+				method = http.MethodPost
+			}
+
+			httpRequest := httptest.NewRequest(method, "http://shop.test/checkout", strings.NewReader(tt.form.Encode()))
+			httpRequest.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+			for key, values := range tt.header {
+				httpRequest.Header[key] = values
+			}
+			if tt.cookie != nil {
+				httpRequest.AddCookie(tt.cookie)
+			}
+
+			req := web.CreateRequest(httpRequest, tt.session)
+			if tt.handler != "" {
+				req.Handler = web.NewRegistry().MustRoute("/"+tt.handler, tt.handler)
+			}
+
+			called := false
+			recorder := httptest.NewRecorder()
+			result := tt.filter.Filter(context.Background(), req, recorder, web.NewFilterChain(func(context.Context, *web.Request, http.ResponseWriter) web.Result {
+				called = true
+				return &web.Response{Status: http.StatusOK}
+			}))
+
+			assert.Equal(t, tt.setsCookie, len(recorder.Result().Cookies()) > 0, "existing cookies are kept")
+
+			if tt.err == nil {
+				assert.True(t, called)
+				return
+			}
+
+			assert.False(t, called)
+			require.IsType(t, new(web.ServerErrorResponse), result)
+			assert.Equal(t, uint(http.StatusForbidden), result.(*web.ServerErrorResponse).Response.Status)
+			assert.ErrorIs(t, result.(*web.ServerErrorResponse).Error, tt.err)
+		})
+	}
+}
+
+func TestTokenFunc(t *testing.T) {
+	service := testService(ModeSession)
+	tokenFunc := new(tokenFunc).Inject(service).Func
+
+	assert.Empty(t, tokenFunc(context.Background()).(func() string)())
+
+	session := web.EmptySession()
+	req := web.CreateRequest(nil, session)
+	token, ok := unmask(tokenFunc(web.ContextWithRequest(context.Background(), req)).(func() string)())
+	require.True(t, ok)
+	assert.Equal(t, service.storedToken(req), token)
+}
@@ -0,0 +1,74 @@
+package csrf
+
+import (
+	"context"
+	"net/http"
+
+	"flamingo.me/flamingo/v3/framework/config"
+	"flamingo.me/flamingo/v3/framework/flamingo"
+	"flamingo.me/flamingo/v3/framework/web"
+)
+
+type (
+	// filter validates the token of all requests with unsafe methods
+	filter struct {
+		service   *Service
+		responder *web.Responder
+		logger    flamingo.Logger
+		exempt    map[string]bool
+	}
+)
+
+var (
+	_ web.Filter = new(filter)
+
+	safeMethods = map[string]bool{
+		http.MethodGet:     true,
+		http.MethodHead:    true,
+		http.MethodOptions: true,
+		http.MethodTrace:   true,
+	}
+)
+
+// Inject dependencies
+func (f *filter) Inject(
+	service *Service,
+	responder *web.Responder,
+	logger flamingo.Logger,
+	cfg *struct {
+		Exempt config.Slice `inject:"config:core.csrf.exempt"`
+	},
+) *filter {
+	f.service = service
+	f.responder = responder
+	f.logger = logger.WithField(flamingo.LogKeyModule, "csrf")
+
+	f.exempt = make(map[string]bool)
+	if cfg != nil {
+		var exempt []string
+		_ = cfg.Exempt.MapInto(&exempt)
+		for _, handler := range exempt {
+			f.exempt[handler] = true
+		}
+	}
+
+	return f
+}
+
+// Filter rejects requests with unsafe methods and an invalid token, unless the handler is exempt
+func (f *filter) Filter(ctx context.Context, req *web.Request, rw http.ResponseWriter, chain *web.FilterChain) web.Result {
+	if f.service.mode == ModeCookie {
+		f.service.ensureCookie(req, rw)
+	}
+
+	if safeMethods[req.Request().Method] || (req.Handler != nil && f.exempt[req.Handler.GetHandlerName()]) {
+		return chain.Next(ctx, req, rw)
+	}
+
+	if err := f.service.Validate(req); err != nil {
+		f.logger.WithContext(ctx).Info("rejected ", req.Request().Method, " ", req.Request().URL.Path, ": ", err)
+		return f.responder.ForbiddenWithContext(ctx, err)
+	}
+
+	return chain.Next(ctx, req, rw)
+}
@@ -0,0 +1,46 @@
+// Package csrf protects forms and other state changing requests against cross-site request forgery
+package csrf
+
+import (
+	"flamingo.me/dingo"
+
+	"flamingo.me/flamingo/v3/framework/flamingo"
+	"flamingo.me/flamingo/v3/framework/web"
+)
+
+type (
+	// Module for core/csrf
+	Module struct{}
+)
+
+// Configure DI
+func (*Module) Configure(injector *dingo.Injector) {
+	injector.BindMulti(new(web.Filter)).To(filter{})
+	flamingo.BindTemplateFunc(injector, "csrfToken", new(tokenFunc))
+}
+
+// CueConfig for the module
+func (*Module) CueConfig() string {
+	return `
+core: csrf: {
+	// session stores a synchronizer token in the session, cookie uses a double submit cookie for areas without sessions
+	mode: *"session" | "cookie"
+	// name of the form value which contains the token
+	formField: string | *"csrftoken"
+	// name of the header which contains the token, e.g. for javascript clients
+	header: string | *"X-CSRF-Token"
+	cookie: {
+		name: string | *"csrf_token"
+		path: string | *"/"
+		secure: bool | *true
+		sameSite: *"lax" | "strict" | "none" | "default"
+	}
+	// checkOrigin rejects requests whose Origin or Referer header is neither the host nor a trusted origin
+	checkOrigin: bool | *true
+	// trusted origins, e.g. "https://checkout.example.com" or "https://*.example.com"
+	trustedOrigins: [...string] | *[]
+	// handler names which are not validated, e.g. webhooks
+	exempt: [...string] | *[]
+}
+`
+}
@@ -0,0 +1,14 @@
+package csrf_test
+
+import (
+	"testing"
+
+	"flamingo.me/flamingo/v3/core/csrf"
+	"flamingo.me/flamingo/v3/framework/config"
+)
+
+func TestModule_Configure(t *testing.T) {
+	if err := config.TryModules(nil, new(csrf.Module)); err != nil {
+		t.Error(err)
+	}
+}
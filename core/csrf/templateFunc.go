@@ -0,0 +1,32 @@
+package csrf
+
+import (
+	"context"
+
+	"flamingo.me/flamingo/v3/framework/web"
+)
+
+type (
+	// tokenFunc provides the csrfToken template function
+	tokenFunc struct {
+		service *Service
+	}
+)
+
+// Inject dependencies
+func (tf *tokenFunc) Inject(service *Service) *tokenFunc {
+	tf.service = service
+	return tf
+}
+
+// Func returns the masked token of the current request
+func (tf *tokenFunc) Func(ctx context.Context) interface{} {
+	return func() string {
+		req := web.RequestFromContext(ctx)
+		if req == nil {
+			return ""
+		}
+
+		return tf.service.Token(req)
+	}
+}
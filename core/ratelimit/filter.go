@@ -0,0 +1,191 @@
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"flamingo.me/flamingo/v3/framework/config"
+	"flamingo.me/flamingo/v3/framework/flamingo"
+	"flamingo.me/flamingo/v3/framework/web"
+)
+
+type (
+	// filter takes every request of a limited handler from the limits of its policies
+	filter struct {
+		store      Store
+		responder  *web.Responder
+		logger     flamingo.Logger
+		extractors map[string]KeyExtractor
+		handlers   map[string][]*policy
+	}
+
+	// policy limits the requests of the handlers per key
+	policy struct {
+		name  string
+		key   string
+		limit Limit
+	}
+
+	policyConfig struct {
+		Algorithm string   `json:"algorithm"`
+		Requests  int      `json:"requests"`
+		Window    string   `json:"window"`
+		Burst     int      `json:"burst"`
+		Key       string   `json:"key"`
+		Handlers  []string `json:"handlers"`
+	}
+)
+
+var (
+	_ web.Filter = new(filter)
+
+	// ErrLimitExceeded is the error of the 429 response
+	ErrLimitExceeded = errors.New("rate limit exceeded")
+)
+
+// Inject dependencies
+func (f *filter) Inject(
+	store Store,
+	responder *web.Responder,
+	logger flamingo.Logger,
+	extractors map[string]KeyExtractor,
+	cfg *struct {
+		Policies config.Map `inject:"config:core.ratelimit.policies"`
+	},
+) *filter {
+	f.store = store
+	f.responder = responder
+	f.logger = logger.WithField(flamingo.LogKeyModule, "ratelimit")
+	f.extractors = extractors
+	f.handlers = make(map[string][]*policy)
+
+	if cfg == nil {
+		return f
+	}
+
+	for name, value := range cfg.Policies {
+		policyMap, ok := value.(config.Map)
+		if !ok {
+			continue
+		}
+
+		var pc policyConfig
+		if err := policyMap.MapInto(&pc); err != nil {
+			panic(fmt.Errorf("invalid rate limit policy on %q: %w", "core.ratelimit.policies."+name, err))
+		}
+
+		p, err := f.newPolicy(name, pc)
+		if err != nil {
+			panic(fmt.Errorf("invalid rate limit policy on %q: %w", "core.ratelimit.policies."+name, err))
+		}
+
+		for _, handler := range pc.Handlers {
+			f.handlers[handler] = append(f.handlers[handler], p)
+		}
+	}
+
+	return f
+}
+
+func (f *filter) newPolicy(name string, pc policyConfig) (*policy, error) {
+	window, err := time.ParseDuration(pc.Window)
+	if err != nil {
+		return nil, fmt.Errorf("%w: window %q: %w", ErrInvalidLimit, pc.Window, err)
+	}
+
+	p := &policy{
+		name: name,
+		key:  pc.Key,
+		limit: Limit{
+			Algorithm: Algorithm(pc.Algorithm),
+			Requests:  pc.Requests,
+			Window:    window,
+			Burst:     pc.Burst,
+		},
+	}
+
+	if err := p.limit.Validate(); err != nil {
+		return nil, err
+	}
+
+	if _, ok := f.extractors[p.key]; !ok {
+		return nil, fmt.Errorf("unknown key extractor %q", p.key)
+	}
+
+	return p, nil
+}
+
+// Filter rejects requests which exceed the limit of one of the policies of the handler with 429 Too Many Requests.
+// The RateLimit headers describe the most restrictive policy, requests are allowed if the store fails.
+func (f *filter) Filter(ctx context.Context, req *web.Request, rw http.ResponseWriter, chain *web.FilterChain) web.Result {
+	if req.Handler == nil {
+		return chain.Next(ctx, req, rw)
+	}
+
+	var (
+		strictest       *Decision
+		strictestPolicy *policy
+	)
+
+	for _, p := range f.handlers[req.Handler.GetHandlerName()] {
+		key, ok := f.extractors[p.key].Key(ctx, req)
+		if !ok {
+			continue
+		}
+
+		decision, err := f.store.Take(ctx, p.name+":"+key, p.limit)
+		if err != nil {
+			recordStoreError(ctx, p.name)
+			f.logger.WithContext(ctx).Warn("rate limit policy ", p.name, " not applied: ", err)
+			continue
+		}
+
+		recordDecision(ctx, p.name, decision)
+
+		if strictest == nil || stricter(decision, *strictest) {
+			strictest, strictestPolicy = &decision, p
+		}
+	}
+
+	if strictest == nil {
+		return chain.Next(ctx, req, rw)
+	}
+
+	if !strictest.Allowed {
+		response := f.responder.TooManyRequestsWithContext(ctx, fmt.Errorf("%w: policy %s", ErrLimitExceeded, strictestPolicy.name), strictest.RetryAfter)
+		setHeaders(response.Header, strictestPolicy, *strictest)
+		return response
+	}
+
+	setHeaders(rw.Header(), strictestPolicy, *strictest)
+
+	return chain.Next(ctx, req, rw)
+}
+
+// stricter reports whether the decision is more restrictive than the other one
+func stricter(decision, other Decision) bool {
+	if decision.Allowed != other.Allowed {
+		return !decision.Allowed
+	}
+	if !decision.Allowed {
+		return decision.RetryAfter > other.RetryAfter
+	}
+	return decision.Remaining < other.Remaining
+}
+
+// setHeaders sets the RateLimit headers, times are in seconds
+func setHeaders(header http.Header, p *policy, decision Decision) {
+	header.Set("RateLimit-Limit", strconv.Itoa(decision.Limit))
+	header.Set("RateLimit-Remaining", strconv.Itoa(decision.Remaining))
+	header.Set("RateLimit-Reset", strconv.FormatInt(seconds(decision.Reset), 10))
+	header.Set("RateLimit-Policy", fmt.Sprintf("%d;w=%d", p.limit.Requests, seconds(p.limit.Window)))
+}
+
+func seconds(d time.Duration) int64 {
+	return int64(math.Ceil(d.Seconds()))
+}
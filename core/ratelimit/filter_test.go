@@ -0,0 +1,269 @@
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opencensus.io/stats/view"
+
+	"flamingo.me/flamingo/v3/framework/config"
+	"flamingo.me/flamingo/v3/framework/flamingo"
+	"flamingo.me/flamingo/v3/framework/web"
+)
+
+type (
+	headerExtractor struct{}
+
+	failingStore struct{}
+)
+
+func (headerExtractor) Key(_ context.Context, req *web.Request) (string, bool) {
+	key := req.Request().Header.Get("X-Api-Key")
+	return "api:" + key, key != ""
+}
+
+func (failingStore) Take(context.Context, string, Limit) (Decision, error) {
+	return Decision{}, errors.New("store unavailable")
+}
+
+func countData(t *testing.T, name, policy, result string) int64 {
+	t.Helper()
+
+	rows, err := view.RetrieveData(name)
+	require.NoError(t, err)
+
+	for _, row := range rows {
+		matches := 0
+		for _, tag := range row.Tags {
+			if (tag.Key == KeyPolicy && tag.Value == policy) || (tag.Key == KeyResult && tag.Value == result) {
+				matches++
+			}
+		}
+		if matches == len(row.Tags) {
+			return row.Data.(*view.CountData).Value
+		}
+	}
+
+	return 0
+}
+
+func TestFilter(t *testing.T) {
+	responder := new(web.Responder).Inject(&web.Router{}, flamingo.NullLogger{}, &struct {
+		Engine                flamingo.TemplateEngine `inject:",optional"`
+		Debug                 bool                    `inject:"config:flamingo.debug.mode"`
+		TemplateBadRequest    string                  `inject:"config:flamingo.template.err400"`
+		TemplateForbidden     string                  `inject:"config:flamingo.template.err403"`
+		TemplateNotFound      string                  `inject:"config:flamingo.template.err404"`
+		TemplateUnavailable   string                  `inject:"config:flamingo.template.err503"`
+		TemplateErrorWithCode string                  `inject:"config:flamingo.template.errWithCode"`
+		Encoders              []web.Encoder           `inject:",optional"`
+		DefaultMediaType      string                  `inject:"config:flamingo.web.dataResponse.defaultMediaType,optional"`
+	}{})
+
+	extractors := map[string]KeyExtractor{
+		KeyRemoteAddress: new(RemoteAddressExtractor),
+		KeySession:       new(SessionExtractor),
+		"apiKey":         headerExtractor{},
+	}
+
+	store := NewInMemoryStore(0)
+	store.now = newClock().Now
+	defer store.Close()
+
+	limiter := new(filter).Inject(store, responder, flamingo.NullLogger{}, extractors, &struct {
+		Policies config.Map `inject:"config:core.ratelimit.policies"`
+	}{Policies: config.Map{
+		"login": config.Map{
+			"algorithm": "tokenBucket",
+			"requests":  2.0,
+			"window":    "1m",
+			"key":       KeyRemoteAddress,
+			"handlers":  config.Slice{"auth.callback"},
+		},
+		"login-burst": config.Map{
+			"algorithm": "slidingWindow",
+			"requests":  10.0,
+			"window":    "1s",
+			"key":       KeyRemoteAddress,
+			"handlers":  config.Slice{"auth.callback"},
+		},
+		"api": config.Map{
+			"algorithm": "slidingWindow",
+			"requests":  1.0,
+			"window":    "1m",
+			"key":       "apiKey",
+			"handlers":  config.Slice{"api"},
+		},
+	}})
+
+	failing := new(filter).Inject(failingStore{}, responder, flamingo.NullLogger{}, extractors, &struct {
+		Policies config.Map `inject:"config:core.ratelimit.policies"`
+	}{Policies: config.Map{
+		"checkout": config.Map{
+			"algorithm": "tokenBucket",
+			"requests":  1.0,
+			"window":    "1m",
+			"key":       KeySession,
+			"handlers":  config.Slice{"checkout"},
+		},
+	}})
+
+	// the cases share the store and run in order
+	tests := []struct {
+		name       string
+		filter     *filter
+		handler    string
+		remoteAddr string
+		apiKey     string
+		limited    bool
+		header     http.Header
+	}{
+		{
+			name:       "allowed requests get the headers of the strictest policy",
+			filter:     limiter,
+			handler:    "auth.callback",
+			remoteAddr: "192.0.2.1:1234",
+			header:     http.Header{"Ratelimit-Limit": {"2"}, "Ratelimit-Remaining": {"1"}, "Ratelimit-Reset": {"30"}, "Ratelimit-Policy": {"2;w=60"}},
+		},
+		{
+			name:       "requests from another port of the client count",
+			filter:     limiter,
+			handler:    "auth.callback",
+			remoteAddr: "192.0.2.1:4321",
+			header:     http.Header{"Ratelimit-Limit": {"2"}, "Ratelimit-Remaining": {"0"}, "Ratelimit-Reset": {"60"}, "Ratelimit-Policy": {"2;w=60"}},
+		},
+		{
+			name:       "over-limit requests are rejected",
+			filter:     limiter,
+			handler:    "auth.callback",
+			remoteAddr: "192.0.2.1:1234",
+			limited:    true,
+			header:     http.Header{"Retry-After": {"30"}, "Ratelimit-Limit": {"2"}, "Ratelimit-Remaining": {"0"}, "Ratelimit-Reset": {"60"}, "Ratelimit-Policy": {"2;w=60"}},
+		},
+		{
+			name:       "clients are limited independently",
+			filter:     limiter,
+			handler:    "auth.callback",
+			remoteAddr: "192.0.2.2:1234",
+			header:     http.Header{"Ratelimit-Limit": {"2"}, "Ratelimit-Remaining": {"1"}, "Ratelimit-Reset": {"30"}, "Ratelimit-Policy": {"2;w=60"}},
+		},
+		{
+			name:       "requests without key are not limited",
+			filter:     limiter,
+			handler:    "api",
+			remoteAddr: "192.0.2.1:1234",
+			header:     http.Header{},
+		},
+		{
+			name:       "requests without key are not counted",
+			filter:     limiter,
+			handler:    "api",
+			remoteAddr: "192.0.2.1:1234",
+			header:     http.Header{},
+		},
+		{
+			name:       "custom key extractor",
+			filter:     limiter,
+			handler:    "api",
+			remoteAddr: "192.0.2.1:1234",
+			apiKey:     "secret",
+			header:     http.Header{"Ratelimit-Limit": {"1"}, "Ratelimit-Remaining": {"0"}, "Ratelimit-Reset": {"60"}, "Ratelimit-Policy": {"1;w=60"}},
+		},
+		{
+			name:       "custom keys are limited across addresses",
+			filter:     limiter,
+			handler:    "api",
+			remoteAddr: "192.0.2.3:1234",
+			apiKey:     "secret",
+			limited:    true,
+			header:     http.Header{"Retry-After": {"60"}, "Ratelimit-Limit": {"1"}, "Ratelimit-Remaining": {"0"}, "Ratelimit-Reset": {"60"}, "Ratelimit-Policy": {"1;w=60"}},
+		},
+		{
+			name:       "requests are allowed if the store fails",
+			filter:     failing,
+			handler:    "checkout",
+			remoteAddr: "192.0.2.1:1234",
+			header:     http.Header{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			httpRequest := httptest.NewRequest(http.MethodPost, "http://shop.test/login", nil)
+			httpRequest.RemoteAddr = tt.remoteAddr
+			if tt.apiKey != "" {
+				httpRequest.Header.Set("X-Api-Key", tt.apiKey)
+			}
+
+			req := web.CreateRequest(httpRequest, nil)
+			req.Handler = web.NewRegistry().MustRoute("/login", tt.handler)
+
+			called := false
+			recorder := httptest.NewRecorder()
+			result := tt.filter.Filter(context.Background(), req, recorder, web.NewFilterChain(func(context.Context, *web.Request, http.ResponseWriter) web.Result {
+				called = true
+				return &web.Response{Status: http.StatusOK}
+			}))
+
+			if !tt.limited {
+				assert.True(t, called)
+				assert.Equal(t, tt.header, recorder.Header())
+				return
+			}
+
+			assert.False(t, called)
+			assert.Empty(t, recorder.Header())
+			require.IsType(t, &web.ServerErrorResponse{}, result)
+
+			response := result.(*web.ServerErrorResponse)
+			assert.ErrorIs(t, response.Error, ErrLimitExceeded)
+			assert.Equal(t, uint(http.StatusTooManyRequests), response.Response.Status)
+			assert.Equal(t, tt.header, response.Header)
+		})
+	}
+
+	assert.Equal(t, int64(1), countData(t, "flamingo/ratelimit/requests", "login", resultLimited))
+	assert.Equal(t, int64(3), countData(t, "flamingo/ratelimit/requests", "login", resultAllowed))
+	assert.Equal(t, int64(1), countData(t, "flamingo/ratelimit/store_errors", "checkout", ""))
+}
+
+func TestFilterInvalidPolicies(t *testing.T) {
+	extractors := map[string]KeyExtractor{KeyRemoteAddress: new(RemoteAddressExtractor)}
+
+	for name, policy := range map[string]config.Map{
+		"window":    {"algorithm": "tokenBucket", "requests": 1.0, "window": "1 minute", "key": KeyRemoteAddress},
+		"algorithm": {"algorithm": "fixed", "requests": 1.0, "window": "1m", "key": KeyRemoteAddress},
+		"extractor": {"algorithm": "tokenBucket", "requests": 1.0, "window": "1m", "key": "unknown"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			assert.Panics(t, func() {
+				new(filter).Inject(nil, nil, flamingo.NullLogger{}, extractors, &struct {
+					Policies config.Map `inject:"config:core.ratelimit.policies"`
+				}{Policies: config.Map{"invalid": policy}})
+			})
+		})
+	}
+}
+
+func TestRemoteAddressExtractor(t *testing.T) {
+	httpRequest := httptest.NewRequest(http.MethodGet, "/", nil)
+	httpRequest.RemoteAddr = "192.0.2.1:1234"
+	req := web.CreateRequest(httpRequest, nil)
+
+	key, ok := new(RemoteAddressExtractor).Key(context.Background(), req)
+	assert.True(t, ok)
+	assert.Equal(t, "ip:192.0.2.1", key)
+
+	req.Request().Header.Set("X-Forwarded-For", "198.51.100.7")
+	key, _ = new(RemoteAddressExtractor).Key(context.Background(), req)
+	assert.Equal(t, "ip:192.0.2.1", key, "forwarding headers of untrusted proxies are ignored")
+
+	session := web.EmptySession()
+	key, _ = new(SessionExtractor).Key(context.Background(), web.CreateRequest(httptest.NewRequest(http.MethodGet, "/", nil), session))
+	assert.Equal(t, "ip:192.0.2.1", key, "requests without session id use the remote address of httptest")
+}
@@ -0,0 +1,133 @@
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+type (
+	// InMemoryStore counts requests in the memory of the instance,
+	// use the RedisStore to share the limits between several instances
+	InMemoryStore struct {
+		mu      sync.Mutex
+		entries map[string]*inMemoryEntry
+		now     func() time.Time
+		done    chan struct{}
+	}
+
+	inMemoryEntry struct {
+		// tokens of the token bucket
+		tokens float64
+		// last update of the token bucket in milliseconds
+		last int64
+		// window index and counts of the sliding window
+		window   int64
+		current  int64
+		previous int64
+		expires  time.Time
+	}
+)
+
+var (
+	_ Store = new(InMemoryStore)
+)
+
+// NewInMemoryStore creates a store which removes expired entries every cleanupInterval, 0 disables the cleanup
+func NewInMemoryStore(cleanupInterval time.Duration) *InMemoryStore {
+	store := &InMemoryStore{
+		entries: make(map[string]*inMemoryEntry),
+		now:     time.Now,
+		done:    make(chan struct{}),
+	}
+
+	if cleanupInterval > 0 {
+		go store.cleanup(cleanupInterval)
+	}
+
+	return store
+}
+
+// Take a request from the limit of the key
+func (s *InMemoryStore) Take(_ context.Context, key string, limit Limit) (Decision, error) {
+	if err := limit.Validate(); err != nil {
+		return Decision{}, err
+	}
+
+	now := s.now()
+	ms := now.UnixMilli()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entry, ok := s.entries[key]
+	if !ok || now.After(entry.expires) {
+		entry = &inMemoryEntry{tokens: float64(limit.capacity()), last: ms, window: -1}
+		s.entries[key] = entry
+	}
+
+	if limit.Algorithm == AlgorithmSlidingWindow {
+		window := limit.Window.Milliseconds()
+		index, elapsed := ms/window, ms%window
+
+		var allowed bool
+		entry.current, entry.previous, allowed = takeSlidingWindow(limit, entry.window, entry.current, entry.previous, index, elapsed)
+		entry.window = index
+		entry.expires = now.Add(2 * limit.Window)
+
+		return slidingWindowDecision(limit, entry.current, entry.previous, elapsed, allowed), nil
+	}
+
+	var allowed bool
+	entry.tokens, allowed = takeToken(limit, entry.tokens, ms-entry.last)
+	entry.last = ms
+
+	decision := tokenBucketDecision(limit, entry.tokens, allowed)
+	entry.expires = now.Add(decision.Reset)
+
+	return decision, nil
+}
+
+// Len returns the number of tracked keys
+func (s *InMemoryStore) Len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return len(s.entries)
+}
+
+// Close stops the cleanup
+func (s *InMemoryStore) Close() {
+	select {
+	case <-s.done:
+	default:
+		close(s.done)
+	}
+}
+
+func (s *InMemoryStore) cleanup(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			s.removeExpired()
+		case <-s.done:
+			return
+		}
+	}
+}
+
+func (s *InMemoryStore) removeExpired() {
+	now := s.now()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for key, entry := range s.entries {
+		if now.After(entry.expires) {
+			delete(s.entries, key)
+		}
+	}
+}
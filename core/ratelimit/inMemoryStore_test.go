@@ -0,0 +1,160 @@
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// clock is a settable time source for the stores
+type clock struct {
+	now time.Time
+}
+
+func (c *clock) Now() time.Time {
+	return c.now
+}
+
+func (c *clock) Add(d time.Duration) {
+	c.now = c.now.Add(d)
+}
+
+func newClock() *clock {
+	return &clock{now: time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)}
+}
+
+// testStore runs the algorithm tests against a store using the clock
+func testStore(t *testing.T, newStore func(t *testing.T, c *clock) Store) {
+	t.Helper()
+
+	t.Run("token bucket allows bursts and refills continuously", func(t *testing.T) {
+		t.Parallel()
+
+		c := newClock()
+		store := newStore(t, c)
+		limit := Limit{Algorithm: AlgorithmTokenBucket, Requests: 2, Window: 2 * time.Second, Burst: 3}
+
+		for i := 2; i >= 0; i-- {
+			decision, err := store.Take(context.Background(), "client", limit)
+			require.NoError(t, err)
+			assert.True(t, decision.Allowed)
+			assert.Equal(t, 3, decision.Limit)
+			assert.Equal(t, i, decision.Remaining)
+		}
+
+		decision, err := store.Take(context.Background(), "client", limit)
+		require.NoError(t, err)
+		assert.False(t, decision.Allowed)
+		assert.Equal(t, 0, decision.Remaining)
+		assert.Equal(t, time.Second, decision.RetryAfter)
+		assert.Equal(t, 3*time.Second, decision.Reset)
+
+		c.Add(time.Second)
+		decision, err = store.Take(context.Background(), "client", limit)
+		require.NoError(t, err)
+		assert.True(t, decision.Allowed)
+		assert.Equal(t, 0, decision.Remaining)
+
+		decision, err = store.Take(context.Background(), "other", limit)
+		require.NoError(t, err)
+		assert.True(t, decision.Allowed, "keys are limited independently")
+	})
+
+	t.Run("sliding window weights the previous window", func(t *testing.T) {
+		t.Parallel()
+
+		c := newClock()
+		store := newStore(t, c)
+		limit := Limit{Algorithm: AlgorithmSlidingWindow, Requests: 4, Window: 10 * time.Second}
+
+		for i := 3; i >= 0; i-- {
+			decision, err := store.Take(context.Background(), "client", limit)
+			require.NoError(t, err)
+			assert.True(t, decision.Allowed)
+			assert.Equal(t, 4, decision.Limit)
+			assert.Equal(t, i, decision.Remaining)
+		}
+
+		decision, err := store.Take(context.Background(), "client", limit)
+		require.NoError(t, err)
+		assert.False(t, decision.Allowed)
+		assert.Equal(t, 10*time.Second, decision.RetryAfter, "the current window is exhausted")
+
+		// 5s into the next window the previous window counts half
+		c.Add(15 * time.Second)
+		for i := 0; i < 2; i++ {
+			decision, err = store.Take(context.Background(), "client", limit)
+			require.NoError(t, err)
+			assert.True(t, decision.Allowed)
+		}
+
+		decision, err = store.Take(context.Background(), "client", limit)
+		require.NoError(t, err)
+		assert.False(t, decision.Allowed)
+		assert.Equal(t, 5*time.Second, decision.Reset)
+		assert.Equal(t, 2500*time.Millisecond, decision.RetryAfter)
+
+		c.Add(2500 * time.Millisecond)
+		decision, err = store.Take(context.Background(), "client", limit)
+		require.NoError(t, err)
+		assert.True(t, decision.Allowed)
+
+		// after two windows all requests are forgotten
+		c.Add(20 * time.Second)
+		decision, err = store.Take(context.Background(), "client", limit)
+		require.NoError(t, err)
+		assert.True(t, decision.Allowed)
+		assert.Equal(t, 3, decision.Remaining)
+	})
+
+	t.Run("invalid limits are rejected", func(t *testing.T) {
+		t.Parallel()
+
+		store := newStore(t, newClock())
+		_, err := store.Take(context.Background(), "client", Limit{Algorithm: "fixed", Requests: 1, Window: time.Second})
+		assert.ErrorIs(t, err, ErrInvalidLimit)
+
+		_, err = store.Take(context.Background(), "client", Limit{Algorithm: AlgorithmTokenBucket, Window: time.Second})
+		assert.ErrorIs(t, err, ErrInvalidLimit)
+	})
+}
+
+func TestInMemoryStore(t *testing.T) {
+	t.Parallel()
+
+	testStore(t, func(t *testing.T, c *clock) Store {
+		t.Helper()
+
+		store := NewInMemoryStore(0)
+		store.now = c.Now
+		t.Cleanup(store.Close)
+
+		return store
+	})
+}
+
+func TestInMemoryStore_RemoveExpired(t *testing.T) {
+	t.Parallel()
+
+	c := newClock()
+	store := NewInMemoryStore(0)
+	store.now = c.Now
+	defer store.Close()
+
+	_, err := store.Take(context.Background(), "bucket", Limit{Algorithm: AlgorithmTokenBucket, Requests: 1, Window: time.Second})
+	require.NoError(t, err)
+	_, err = store.Take(context.Background(), "window", Limit{Algorithm: AlgorithmSlidingWindow, Requests: 1, Window: time.Minute})
+	require.NoError(t, err)
+	assert.Equal(t, 2, store.Len())
+
+	c.Add(2 * time.Second)
+	store.removeExpired()
+	assert.Equal(t, 1, store.Len(), "the refilled bucket is removed")
+
+	c.Add(2 * time.Minute)
+	store.removeExpired()
+	assert.Equal(t, 0, store.Len())
+}
@@ -0,0 +1,78 @@
+package ratelimit
+
+import (
+	"context"
+
+	"flamingo.me/flamingo/v3/core/auth"
+	"flamingo.me/flamingo/v3/framework/web"
+)
+
+type (
+	// KeyExtractor identifies the client of a request, requests without a key are not limited.
+	// Custom extractors are registered with a map binding and referenced by name in the policy:
+	// injector.BindMap(new(ratelimit.KeyExtractor), "apiKey").To(apiKeyExtractor{})
+	KeyExtractor interface {
+		Key(ctx context.Context, req *web.Request) (key string, ok bool)
+	}
+
+	// RemoteAddressExtractor uses the ip address of the client, see web.Request.ClientIP
+	RemoteAddressExtractor struct{}
+
+	// SessionExtractor uses the hashed session id, requests without a session are keyed by their remote address
+	SessionExtractor struct{}
+
+	// SubjectExtractor uses the broker and subject of the authenticated identity,
+	// anonymous requests are keyed by their remote address
+	SubjectExtractor struct {
+		identityService *auth.WebIdentityService
+	}
+)
+
+const (
+	// KeyRemoteAddress is the name of the RemoteAddressExtractor
+	KeyRemoteAddress = "remoteAddress"
+	// KeySession is the name of the SessionExtractor
+	KeySession = "session"
+	// KeySubject is the name of the SubjectExtractor
+	KeySubject = "subject"
+)
+
+var (
+	_ KeyExtractor = new(RemoteAddressExtractor)
+	_ KeyExtractor = new(SessionExtractor)
+	_ KeyExtractor = new(SubjectExtractor)
+)
+
+// Key returns the client ip resolved with the trusted proxies
+func (*RemoteAddressExtractor) Key(_ context.Context, req *web.Request) (string, bool) {
+	address := req.ClientIP()
+	if address == "" {
+		return "", false
+	}
+
+	return "ip:" + address, true
+}
+
+// Key returns the hashed session id, so the id is not written to the store
+func (*SessionExtractor) Key(ctx context.Context, req *web.Request) (string, bool) {
+	if req.Session().ID() != "" {
+		return "session:" + req.Session().IDHash(), true
+	}
+
+	return new(RemoteAddressExtractor).Key(ctx, req)
+}
+
+// Inject dependencies
+func (e *SubjectExtractor) Inject(identityService *auth.WebIdentityService) *SubjectExtractor {
+	e.identityService = identityService
+	return e
+}
+
+// Key returns the broker and subject of the identity
+func (e *SubjectExtractor) Key(ctx context.Context, req *web.Request) (string, bool) {
+	if identity := e.identityService.Identify(ctx, req); identity != nil {
+		return "subject:" + identity.Broker() + ":" + identity.Subject(), true
+	}
+
+	return new(RemoteAddressExtractor).Key(ctx, req)
+}
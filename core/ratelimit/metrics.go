@@ -0,0 +1,53 @@
+package ratelimit
+
+import (
+	"context"
+
+	"go.opencensus.io/stats"
+	"go.opencensus.io/stats/view"
+	"go.opencensus.io/tag"
+
+	"flamingo.me/flamingo/v3/framework/opencensus"
+)
+
+var (
+	requestsMeasure   = stats.Int64("flamingo/ratelimit/requests", "Count of requests checked against a rate limit", stats.UnitDimensionless)
+	storeErrorMeasure = stats.Int64("flamingo/ratelimit/store_errors", "Count of failed rate limit store operations", stats.UnitDimensionless)
+
+	// KeyPolicy is the name of the rate limit policy
+	KeyPolicy, _ = tag.NewKey("policy")
+	// KeyResult is either allowed or limited
+	KeyResult, _ = tag.NewKey("result")
+)
+
+const (
+	resultAllowed = "allowed"
+	resultLimited = "limited"
+)
+
+func init() {
+	if err := opencensus.View("flamingo/ratelimit/requests", requestsMeasure, view.Count(), KeyPolicy, KeyResult); err != nil {
+		panic(err)
+	}
+
+	if err := opencensus.View("flamingo/ratelimit/store_errors", storeErrorMeasure, view.Count(), KeyPolicy); err != nil {
+		panic(err)
+	}
+}
+
+// recordDecision counts the request tagged with the policy and the result
+func recordDecision(ctx context.Context, policy string, decision Decision) {
+	result := resultAllowed
+	if !decision.Allowed {
+		result = resultLimited
+	}
+
+	ctx, _ = tag.New(ctx, tag.Upsert(KeyPolicy, policy), tag.Upsert(KeyResult, result))
+	stats.Record(ctx, requestsMeasure.M(1))
+}
+
+// recordStoreError counts a failed store operation
+func recordStoreError(ctx context.Context, policy string) {
+	ctx, _ = tag.New(ctx, tag.Upsert(KeyPolicy, policy))
+	stats.Record(ctx, storeErrorMeasure.M(1))
+}
@@ -0,0 +1,161 @@
+// Package ratelimit throttles requests per client with token bucket or sliding window limits
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"time"
+)
+
+type (
+	// Algorithm of a limit
+	Algorithm string
+
+	// Limit describes how many requests are allowed
+	Limit struct {
+		Algorithm Algorithm
+		// Requests allowed per Window
+		Requests int
+		Window   time.Duration
+		// Burst is the capacity of the token bucket, defaults to Requests
+		Burst int
+	}
+
+	// Decision is the result of taking a request from a limit
+	Decision struct {
+		Allowed   bool
+		Limit     int
+		Remaining int
+		// Reset is the time until the limit is fully available again
+		Reset time.Duration
+		// RetryAfter is the time until the next request is allowed, zero for allowed requests
+		RetryAfter time.Duration
+	}
+
+	// Store counts the requests per key
+	Store interface {
+		// Take a request from the limit of the key
+		Take(ctx context.Context, key string, limit Limit) (Decision, error)
+	}
+)
+
+const (
+	// AlgorithmTokenBucket refills the bucket continuously and allows bursts up to the capacity
+	AlgorithmTokenBucket Algorithm = "tokenBucket"
+	// AlgorithmSlidingWindow weights the count of the previous window, so there are no bursts at window boundaries
+	AlgorithmSlidingWindow Algorithm = "slidingWindow"
+)
+
+var (
+	// ErrInvalidLimit is returned for limits without requests, window or a known algorithm
+	ErrInvalidLimit = errors.New("invalid rate limit")
+)
+
+// Validate the limit
+func (l Limit) Validate() error {
+	if l.Requests <= 0 || l.Window <= 0 || l.Burst < 0 {
+		return fmt.Errorf("%w: requests and window must be positive", ErrInvalidLimit)
+	}
+
+	switch l.Algorithm {
+	case AlgorithmTokenBucket, AlgorithmSlidingWindow:
+		return nil
+	}
+
+	return fmt.Errorf("%w: unknown algorithm %q", ErrInvalidLimit, l.Algorithm)
+}
+
+// capacity of the token bucket
+func (l Limit) capacity() int {
+	if l.Burst > 0 {
+		return l.Burst
+	}
+	return l.Requests
+}
+
+// rate of the token bucket in tokens per millisecond
+func (l Limit) rate() float64 {
+	return float64(l.Requests) / float64(l.Window.Milliseconds())
+}
+
+// tokenBucketDecision computes the decision from the tokens left in the bucket
+func tokenBucketDecision(limit Limit, tokens float64, allowed bool) Decision {
+	capacity := limit.capacity()
+	rate := limit.rate()
+
+	decision := Decision{
+		Allowed:   allowed,
+		Limit:     capacity,
+		Remaining: int(math.Floor(tokens)),
+		Reset:     millis((float64(capacity) - tokens) / rate),
+	}
+	if !allowed {
+		decision.RetryAfter = millis((1 - tokens) / rate)
+	}
+
+	return decision
+}
+
+// takeToken refills the bucket for the elapsed milliseconds and takes a token if there is one
+func takeToken(limit Limit, tokens float64, elapsed int64) (float64, bool) {
+	tokens = math.Min(float64(limit.capacity()), tokens+float64(max(elapsed, 0))*limit.rate())
+	if tokens >= 1 {
+		return tokens - 1, true
+	}
+	return tokens, false
+}
+
+// slidingWindowDecision computes the decision from the counts of the current and the previous window,
+// elapsed is the time since the start of the current window in milliseconds
+func slidingWindowDecision(limit Limit, current, previous, elapsed int64, allowed bool) Decision {
+	window := limit.Window.Milliseconds()
+	estimated := slidingWindowEstimate(window, current, previous, elapsed)
+
+	decision := Decision{
+		Allowed:   allowed,
+		Limit:     limit.Requests,
+		Remaining: max(0, int(math.Floor(float64(limit.Requests)-estimated))),
+		Reset:     time.Duration(window-elapsed) * time.Millisecond,
+	}
+
+	if !allowed {
+		// the weight of the previous window must drop until the next request fits,
+		// if the current window alone is exhausted the next window has to be awaited
+		free := float64(int64(limit.Requests) - 1 - current)
+		if previous > 0 && free >= 0 {
+			decision.RetryAfter = millis(float64(window)-free*float64(window)/float64(previous)) - time.Duration(elapsed)*time.Millisecond
+		}
+		if decision.RetryAfter <= 0 || free < 0 {
+			decision.RetryAfter = decision.Reset
+		}
+	}
+
+	return decision
+}
+
+// slidingWindowEstimate weights the previous window by its overlap with the sliding window
+func slidingWindowEstimate(window, current, previous, elapsed int64) float64 {
+	return float64(previous)*float64(window-elapsed)/float64(window) + float64(current)
+}
+
+// takeSlidingWindow moves the counts to the window index and counts the request if it fits into the limit
+func takeSlidingWindow(limit Limit, stored, current, previous, index, elapsed int64) (int64, int64, bool) {
+	switch {
+	case index == stored+1:
+		previous, current = current, 0
+	case index != stored:
+		previous, current = 0, 0
+	}
+
+	if slidingWindowEstimate(limit.Window.Milliseconds(), current, previous, elapsed)+1 <= float64(limit.Requests) {
+		return current + 1, previous, true
+	}
+
+	return current, previous, false
+}
+
+func millis(ms float64) time.Duration {
+	return time.Duration(math.Ceil(ms)) * time.Millisecond
+}
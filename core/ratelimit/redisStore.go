@@ -0,0 +1,149 @@
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+type (
+	// RedisStore counts requests in redis, standalone or cluster, so all instances share the limits.
+	// The state is updated atomically by lua scripts.
+	RedisStore struct {
+		client    redis.UniversalClient
+		keyPrefix string
+		timeout   time.Duration
+		now       func() time.Time
+	}
+)
+
+var (
+	_ Store = new(RedisStore)
+
+	// tokenBucketScript refills and takes a token, it returns whether the request is allowed and the remaining tokens
+	tokenBucketScript = redis.NewScript(`
+local capacity = tonumber(ARGV[1])
+local rate = tonumber(ARGV[2])
+local now = tonumber(ARGV[3])
+local state = redis.call("HMGET", KEYS[1], "tokens", "last")
+local tokens = tonumber(state[1])
+local last = tonumber(state[2])
+if tokens == nil or last == nil then
+	tokens = capacity
+	last = now
+end
+tokens = math.min(capacity, tokens + math.max(0, now - last) * rate)
+local allowed = 0
+if tokens >= 1 then
+	tokens = tokens - 1
+	allowed = 1
+end
+redis.call("HSET", KEYS[1], "tokens", tostring(tokens), "last", now)
+redis.call("PEXPIRE", KEYS[1], math.ceil((capacity - tokens) / rate) + 1000)
+return {allowed, tostring(tokens)}
+`)
+
+	// slidingWindowScript moves the counts to the current window and counts the request,
+	// it returns whether the request is allowed and the counts of the current and previous window
+	slidingWindowScript = redis.NewScript(`
+local limit = tonumber(ARGV[1])
+local window = tonumber(ARGV[2])
+local now = tonumber(ARGV[3])
+local index = math.floor(now / window)
+local elapsed = now - index * window
+local state = redis.call("HMGET", KEYS[1], "window", "current", "previous")
+local stored = tonumber(state[1])
+local current = tonumber(state[2]) or 0
+local previous = tonumber(state[3]) or 0
+if stored == index - 1 then
+	previous = current
+	current = 0
+elseif stored ~= index then
+	previous = 0
+	current = 0
+end
+local allowed = 0
+if previous * (window - elapsed) / window + current + 1 <= limit then
+	current = current + 1
+	allowed = 1
+end
+redis.call("HSET", KEYS[1], "window", index, "current", current, "previous", previous)
+redis.call("PEXPIRE", KEYS[1], 2 * window)
+return {allowed, current, previous}
+`)
+)
+
+// NewRedisStore creates a store for the client
+func NewRedisStore(client redis.UniversalClient) *RedisStore {
+	return &RedisStore{
+		client:  client,
+		timeout: time.Second,
+		now:     time.Now,
+	}
+}
+
+// WithKeyPrefix sets the prefix of all keys written by the store
+func (s *RedisStore) WithKeyPrefix(prefix string) *RedisStore {
+	s.keyPrefix = prefix
+	return s
+}
+
+// WithTimeout sets the timeout of redis operations
+func (s *RedisStore) WithTimeout(timeout time.Duration) *RedisStore {
+	s.timeout = timeout
+	return s
+}
+
+// Take a request from the limit of the key
+func (s *RedisStore) Take(ctx context.Context, key string, limit Limit) (Decision, error) {
+	if err := limit.Validate(); err != nil {
+		return Decision{}, err
+	}
+
+	if s.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, s.timeout)
+		defer cancel()
+	}
+
+	ms := s.now().UnixMilli()
+	key = s.keyPrefix + key
+
+	if limit.Algorithm == AlgorithmSlidingWindow {
+		window := limit.Window.Milliseconds()
+		result, err := slidingWindowScript.Run(ctx, s.client, []string{key}, limit.Requests, window, ms).Int64Slice()
+		if err != nil {
+			return Decision{}, fmt.Errorf("redis rate limit store: failed to take %q: %w", key, err)
+		}
+		if len(result) != 3 {
+			return Decision{}, fmt.Errorf("redis rate limit store: unexpected result for %q: %v", key, result)
+		}
+
+		return slidingWindowDecision(limit, result[1], result[2], ms%window, result[0] == 1), nil
+	}
+
+	result, err := tokenBucketScript.Run(ctx, s.client, []string{key}, limit.capacity(), strconv.FormatFloat(limit.rate(), 'g', -1, 64), ms).Slice()
+	if err != nil {
+		return Decision{}, fmt.Errorf("redis rate limit store: failed to take %q: %w", key, err)
+	}
+	if len(result) != 2 {
+		return Decision{}, fmt.Errorf("redis rate limit store: unexpected result for %q: %v", key, result)
+	}
+
+	allowed, _ := result[0].(int64)
+	encoded, _ := result[1].(string)
+	tokens, err := strconv.ParseFloat(encoded, 64)
+	if err != nil {
+		return Decision{}, fmt.Errorf("redis rate limit store: invalid tokens for %q: %w", key, err)
+	}
+
+	return tokenBucketDecision(limit, tokens, allowed == 1), nil
+}
+
+// Ping checks if redis is available
+func (s *RedisStore) Ping(ctx context.Context) error {
+	return s.client.Ping(ctx).Err()
+}
@@ -0,0 +1,55 @@
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newRedisTestStore(t *testing.T, c *clock) (*miniredis.Miniredis, *RedisStore) {
+	t.Helper()
+
+	server := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: server.Addr()})
+	t.Cleanup(func() { _ = client.Close() })
+
+	store := NewRedisStore(client).WithKeyPrefix("test:")
+	store.now = c.Now
+
+	return server, store
+}
+
+func TestRedisStore(t *testing.T) {
+	t.Parallel()
+
+	testStore(t, func(t *testing.T, c *clock) Store {
+		t.Helper()
+
+		_, store := newRedisTestStore(t, c)
+		return store
+	})
+}
+
+func TestRedisStore_Keys(t *testing.T) {
+	t.Parallel()
+
+	server, store := newRedisTestStore(t, newClock())
+
+	_, err := store.Take(context.Background(), "bucket", Limit{Algorithm: AlgorithmTokenBucket, Requests: 10, Window: 10 * time.Second})
+	require.NoError(t, err)
+	_, err = store.Take(context.Background(), "window", Limit{Algorithm: AlgorithmSlidingWindow, Requests: 10, Window: time.Minute})
+	require.NoError(t, err)
+
+	assert.ElementsMatch(t, []string{"test:bucket", "test:window"}, server.Keys())
+	assert.Equal(t, 2*time.Second, server.TTL("test:bucket"), "the bucket expires when it is refilled")
+	assert.Equal(t, 2*time.Minute, server.TTL("test:window"))
+
+	server.SetError("unavailable")
+	_, err = store.Take(context.Background(), "bucket", Limit{Algorithm: AlgorithmTokenBucket, Requests: 10, Window: 10 * time.Second})
+	assert.Error(t, err)
+}
@@ -1,8 +1,10 @@
 package requestlogger
 
 import (
+	"bufio"
 	"context"
 	"fmt"
+	"net"
 	"net/http"
 	"strconv"
 	"strings"
@@ -45,6 +47,22 @@ func (r *responseWriterLogger) WriteHeader(statusCode int) {
 	r.rw.WriteHeader(statusCode)
 }
 
+// Flush the inner response writer if supported, noop otherwise
+func (r *responseWriterLogger) Flush() {
+	if f, ok := r.rw.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Hijack the inner connection, used for protocol upgrades
+func (r *responseWriterLogger) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	conn, rw, err := http.NewResponseController(r.rw).Hijack()
+	if err == nil {
+		r.statusCode = http.StatusSwitchingProtocols
+	}
+	return conn, rw, err
+}
+
 // Apply logger to request
 func (l *loggedResponse) Apply(ctx context.Context, rw http.ResponseWriter) error {
 	var err error
@@ -133,7 +151,7 @@ func (r *logger) Filter(ctx context.Context, req *web.Request, w http.ResponseWr
 					flamingo.LogKeyResponseCode: rwl.statusCode,
 					flamingo.LogKeyResponseTime: duration,
 					flamingo.LogKeyReferer:      req.Request().Referer(),
-					flamingo.LogKeyClientIP:     strings.Join(req.RemoteAddress(), ", "),
+					flamingo.LogKeyClientIP:     req.ClientIP(),
 					flamingo.LogKeyBusinessID:   req.Request().Header.Get("X-Business-ID"),
 				},
 			)
@@ -37,6 +37,8 @@ func TestLogger(t *testing.T) {
 		TemplateNotFound      string                  `inject:"config:flamingo.template.err404"`
 		TemplateUnavailable   string                  `inject:"config:flamingo.template.err503"`
 		TemplateErrorWithCode string                  `inject:"config:flamingo.template.errWithCode"`
+		Encoders              []web.Encoder           `inject:",optional"`
+		DefaultMediaType      string                  `inject:"config:flamingo.web.dataResponse.defaultMediaType,optional"`
 	}{})
 
 	tests := []struct {
@@ -64,12 +66,35 @@ func TestLogger(t *testing.T) {
 			logs := strings.Split(logSink.String(), "\n")
 
 			require.Len(t, logs, 3)
-			assert.Regexp(t, "^WithFields map\\[accesslog:1 businessId:business-id client_ip:192.0.2.1:1234 referer:https://example.com/ response_code:\\d+ response_time:\\d+(\\.\\d+)?..]$", logs[0])
+			assert.Regexp(t, "^WithFields map\\[accesslog:1 businessId:business-id client_ip:192.0.2.1 referer:https://example.com/ response_code:\\d+ response_time:\\d+(\\.\\d+)?..]$", logs[0])
 			assert.Regexp(t, test.regex, logs[1])
 		})
 	}
 }
 
+func TestLoggerStream(t *testing.T) {
+	logger := new(logger)
+	logSink := new(bytes.Buffer)
+	logger.Inject(&flamingo.StdLogger{Logger: *log.New(logSink, "", 0)}, nil)
+
+	request := web.CreateRequest(httptest.NewRequest(http.MethodGet, "/stream", nil), nil)
+	stream := new(web.Responder).Stream(func(ctx context.Context, w web.StreamWriter) error {
+		if _, err := w.Write([]byte("hello")); err != nil {
+			return err
+		}
+		return w.Flush()
+	})
+
+	recorder := httptest.NewRecorder()
+	require.NoError(t, logger.Filter(context.Background(), request, nil, web.NewFilterChain(func(ctx context.Context, req *web.Request, w http.ResponseWriter) web.Result {
+		return stream
+	})).Apply(context.Background(), recorder))
+
+	assert.True(t, recorder.Flushed, "flush must reach the underlying response writer")
+	assert.Equal(t, "hello", recorder.Body.String())
+	assert.Contains(t, logSink.String(), "GET /stream 200: 5b")
+}
+
 func TestHumanBytes(t *testing.T) {
 	assert.Equal(t, "100b", humanBytes(100))
 	assert.Equal(t, "100kb", humanBytes(100000))
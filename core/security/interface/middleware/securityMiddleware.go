@@ -5,7 +5,6 @@ import (
 	"fmt"
 	"net/url"
 	"path"
-	"strings"
 	"time"
 
 	"flamingo.me/flamingo/v3/core/security/application"
@@ -185,7 +184,7 @@ func (m *SecurityMiddleware) logIfNeeded(r *web.Request, message string) {
 		m.logger.WithField("security", "middleware").
 			WithField("Date", time.Now().Format(time.RFC3339)).
 			WithField("Path", r.Request().URL.Path).
-			WithField("RemoteAddress", strings.Join(r.RemoteAddress(), ", ")).
+			WithField("RemoteAddress", r.ClientIP()).
 			Info(message)
 	}
 }
@@ -0,0 +1,40 @@
+package healthcheck
+
+import (
+	"github.com/gorilla/securecookie"
+	"github.com/gorilla/sessions"
+
+	"flamingo.me/flamingo/v3/core/healthcheck/domain/healthcheck"
+)
+
+// CookieSession status check
+type CookieSession struct {
+	store sessions.Store
+}
+
+var _ healthcheck.Status = &CookieSession{}
+
+// Inject cookie session store
+func (s *CookieSession) Inject(store sessions.Store) {
+	s.store = store
+}
+
+// Status checks if the cookie codecs are able to encrypt and decrypt sessions
+func (s *CookieSession) Status() (bool, string) {
+	store, ok := s.store.(*sessions.CookieStore)
+	if !ok {
+		return false, "session store is not a cookie store"
+	}
+
+	encoded, err := securecookie.EncodeMulti("healthcheck", "success", store.Codecs...)
+	if err != nil {
+		return false, err.Error()
+	}
+
+	var decoded string
+	if err := securecookie.DecodeMulti("healthcheck", encoded, &decoded, store.Codecs...); err != nil {
+		return false, err.Error()
+	}
+
+	return true, decoded
+}
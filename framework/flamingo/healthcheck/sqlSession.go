@@ -0,0 +1,30 @@
+package healthcheck
+
+import (
+	"context"
+
+	"flamingo.me/flamingo/v3/core/healthcheck/domain/healthcheck"
+	"flamingo.me/flamingo/v3/framework/flamingo/sessionstore"
+)
+
+// SQLSession status check
+type SQLSession struct {
+	store *sessionstore.SQLStore
+}
+
+var _ healthcheck.Status = &SQLSession{}
+
+// Inject sql session store
+func (s *SQLSession) Inject(store *sessionstore.SQLStore) {
+	s.store = store
+}
+
+// Status checks if the database is available
+func (s *SQLSession) Status() (bool, string) {
+	err := s.store.Ping(context.Background())
+	if err != nil {
+		return false, err.Error()
+	}
+
+	return true, "success"
+}
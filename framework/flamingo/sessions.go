@@ -2,7 +2,10 @@ package flamingo
 
 import (
 	"context"
+	"crypto/hkdf"
+	"crypto/sha256"
 	"crypto/tls"
+	"database/sql"
 	"errors"
 	"fmt"
 	"net/http"
@@ -10,6 +13,7 @@ import (
 	"os"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"flamingo.me/dingo"
@@ -20,6 +24,7 @@ import (
 
 	"flamingo.me/flamingo/v3/core/healthcheck/domain/healthcheck"
 	sessionhealthcheck "flamingo.me/flamingo/v3/framework/flamingo/healthcheck"
+	"flamingo.me/flamingo/v3/framework/flamingo/sessionstore"
 )
 
 // SessionModule for session management
@@ -43,9 +48,23 @@ type (
 		redisTLS             bool
 		redisClusterMode     bool
 		redisTimeout         time.Duration
+		sqlDriver            string
+		sqlDSN               string
+		sqlDialect           sessionstore.SQLDialect
+		sqlTable             string
+		sqlCreateTable       bool
 		healthcheckSession   bool
 	}
 
+	// sqlSessionCleanup deletes expired sessions of the sql backend while the server is running
+	sqlSessionCleanup struct {
+		store    *sessionstore.SQLStore
+		logger   Logger
+		interval time.Duration
+		mu       sync.Mutex
+		stop     func()
+	}
+
 	CustomSessionBackend interface {
 		SessionStore() sessions.Store
 	}
@@ -56,6 +75,8 @@ type (
 var (
 	backendRedis  backendType = "redis"
 	backendFile   backendType = "file"
+	backendSQL    backendType = "sql"
+	backendCookie backendType = "cookie"
 	backendCustom backendType = "custom"
 )
 
@@ -82,6 +103,11 @@ func (m *SessionModule) Inject(
 		RedisTLS             bool    `inject:"config:flamingo.session.redis.tls,optional"`
 		RedisClusterMode     bool    `inject:"config:flamingo.session.redis.clusterMode,optional"`
 		RedisTimeout         string  `inject:"config:flamingo.session.redis.timeout,optional"`
+		SQLDriver            string  `inject:"config:flamingo.session.sql.driver,optional"`
+		SQLDSN               string  `inject:"config:flamingo.session.sql.dsn,optional"`
+		SQLDialect           string  `inject:"config:flamingo.session.sql.dialect,optional"`
+		SQLTable             string  `inject:"config:flamingo.session.sql.table,optional"`
+		SQLCreateTable       bool    `inject:"config:flamingo.session.sql.createTable,optional"`
 		CheckSession         bool    `inject:"config:flamingo.session.healthcheck,optional"`
 	},
 	optionals *struct {
@@ -130,6 +156,15 @@ func (m *SessionModule) Inject(
 		m.redisTimeout = redisTimeout
 	}
 
+	m.sqlDriver = config.SQLDriver
+	m.sqlDSN = config.SQLDSN
+	m.sqlDialect = sessionstore.SQLDialect(config.SQLDialect)
+	if m.sqlDialect == "" {
+		m.sqlDialect = sessionstore.DialectForDriver(config.SQLDriver)
+	}
+	m.sqlTable = config.SQLTable
+	m.sqlCreateTable = config.SQLCreateTable
+
 	m.sameSite = parseSameSite(config.SameSite)
 
 	if optionals != nil {
@@ -229,6 +264,47 @@ func (m *SessionModule) Configure(injector *dingo.Injector) {
 		if m.healthcheckSession {
 			injector.BindMap(new(healthcheck.Status), "session").To(sessionhealthcheck.FileSession{})
 		}
+	case backendSQL:
+		db, err := sql.Open(m.sqlDriver, m.sqlDSN)
+		if err != nil {
+			panic(fmt.Errorf("failed on opening database for sql session store: %w", err))
+		}
+
+		sessionStore, err := sessionstore.NewSQLStore(db, m.sqlDialect, m.sqlTable, []byte(m.secret))
+		if err != nil {
+			panic(fmt.Errorf("failed on creating sql session store: %w", err))
+		}
+
+		if m.sqlCreateTable {
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+			if err := sessionStore.CreateTable(ctx); err != nil {
+				panic(err)
+			}
+		}
+
+		sessionStore.MaxLength(m.storeLength)
+		sessionStore.MaxAge(m.maxAge)
+		m.setSessionstoreOptions(sessionStore.Options)
+
+		injector.Bind(new(sessions.Store)).ToInstance(sessionStore)
+		injector.Bind(new(sessionstore.SQLStore)).ToInstance(sessionStore)
+		BindEventSubscriber(injector).To(new(sqlSessionCleanup)).In(dingo.Singleton)
+
+		if m.healthcheckSession {
+			injector.BindMap(new(healthcheck.Status), "session").To(sessionhealthcheck.SQLSession{})
+		}
+	case backendCookie:
+		sessionStore := sessions.NewCookieStore(cookieSessionKeys(m.secret)...)
+
+		sessionStore.MaxAge(m.maxAge)
+		m.setSessionstoreOptions(sessionStore.Options)
+
+		injector.Bind(new(sessions.Store)).ToInstance(sessionStore)
+
+		if m.healthcheckSession {
+			injector.BindMap(new(healthcheck.Status), "session").To(sessionhealthcheck.CookieSession{})
+		}
 	case backendCustom:
 		if m.customBackend == nil {
 			panic("custom session store backend configured but no custom backend provided")
@@ -251,6 +327,57 @@ func (m *SessionModule) Configure(injector *dingo.Injector) {
 	}
 }
 
+// cookieSessionKeys derives the authentication and encryption key for cookie sessions from the secret
+func cookieSessionKeys(secret string) [][]byte {
+	hashKey, err := hkdf.Key(sha256.New, []byte(secret), nil, "flamingo session cookie authentication", 64)
+	if err != nil {
+		panic(err)
+	}
+
+	encryptionKey, err := hkdf.Key(sha256.New, []byte(secret), nil, "flamingo session cookie encryption", 32)
+	if err != nil {
+		panic(err)
+	}
+
+	return [][]byte{hashKey, encryptionKey}
+}
+
+// Inject dependencies
+func (c *sqlSessionCleanup) Inject(store *sessionstore.SQLStore, logger Logger, cfg *struct {
+	CleanupInterval string `inject:"config:flamingo.session.sql.cleanupInterval"`
+}) *sqlSessionCleanup {
+	c.store = store
+	c.logger = logger.WithField(LogKeyModule, "session").WithField(LogKeyCategory, "sqlSessionCleanup")
+
+	interval, err := time.ParseDuration(cfg.CleanupInterval)
+	if err != nil {
+		panic(fmt.Errorf("invalid duration on %q: %q (%w)", "flamingo.session.sql.cleanupInterval", cfg.CleanupInterval, err))
+	}
+	c.interval = interval
+
+	return c
+}
+
+// Notify starts the cleanup on server start and stops it on shutdown
+func (c *sqlSessionCleanup) Notify(_ context.Context, event Event) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	switch event.(type) {
+	case *ServerStartEvent:
+		if c.stop == nil && c.interval > 0 {
+			c.stop = c.store.StartCleanup(c.interval, func(err error) {
+				c.logger.Error(err)
+			})
+		}
+	case *ServerShutdownEvent, *ShutdownEvent:
+		if c.stop != nil {
+			c.stop()
+			c.stop = nil
+		}
+	}
+}
+
 func (m *SessionModule) setSessionstoreOptions(options *sessions.Options) {
 	options.Domain = ""
 	options.Path = m.path
@@ -265,7 +392,7 @@ func (*SessionModule) CueConfig() string {
 	// language=cue
 	return `
 flamingo: session: {
-	backend: *"memory" | "redis" | "file" | "custom"
+	backend: *"memory" | "redis" | "file" | "sql" | "cookie" | "custom"
 	secret: string | *"flamingosecret"
 	healthcheck: bool | *true
 	file: string | *"/sessions"
@@ -288,6 +415,16 @@ flamingo: session: {
 		timeout: string | *"5s"
 		keyPrefix?: string
 	}
+	sql: {
+		// name of the database/sql driver, which must be imported by the application
+		driver: string | *""
+		dsn: string | *""
+		// derived from the driver name if not set
+		dialect?: "postgres" | "mysql" | "sqlite"
+		table: string | *"flamingo_sessions"
+		createTable: bool | *true
+		cleanupInterval: string | *"10m"
+	}
 }
 `
 }
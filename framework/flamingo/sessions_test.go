@@ -3,8 +3,10 @@ package flamingo
 import (
 	"fmt"
 	"net/url"
+	"path/filepath"
 	"testing"
 
+	_ "github.com/mattn/go-sqlite3"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
@@ -23,6 +25,31 @@ func TestModule_Configure(t *testing.T) {
 		assert.Error(t, err)
 	})
 
+	t.Run("sql backend", func(t *testing.T) {
+		t.Parallel()
+
+		err := config.TryModules(config.Map{
+			"flamingo.session.backend":    "sql",
+			"flamingo.session.sql.driver": "sqlite3",
+			"flamingo.session.sql.dsn":    filepath.Join(t.TempDir(), "sessions.db"),
+		}, new(SessionModule))
+		assert.NoError(t, err)
+	})
+
+	t.Run("sql backend with unknown driver", func(t *testing.T) {
+		t.Parallel()
+
+		err := config.TryModules(config.Map{"flamingo.session.backend": "sql", "flamingo.session.sql.driver": "unknown"}, new(SessionModule))
+		assert.Error(t, err)
+	})
+
+	t.Run("cookie backend", func(t *testing.T) {
+		t.Parallel()
+
+		err := config.TryModules(config.Map{"flamingo.session.backend": "cookie"}, new(SessionModule))
+		assert.NoError(t, err)
+	})
+
 	t.Run("invalid empty username", func(t *testing.T) {
 		t.Parallel()
 
@@ -357,3 +384,14 @@ func TestGetRedisConnectionInformation(t *testing.T) {
 		})
 	}
 }
+
+func TestCookieSessionKeys(t *testing.T) {
+	t.Parallel()
+
+	keys := cookieSessionKeys("secret")
+	require.Len(t, keys, 2)
+	assert.Len(t, keys[0], 64)
+	assert.Len(t, keys[1], 32, "AES-256 encryption key")
+	assert.NotEqual(t, keys[0][:32], keys[1])
+	assert.Equal(t, keys, cookieSessionKeys("secret"))
+}
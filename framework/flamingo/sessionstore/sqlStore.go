@@ -0,0 +1,345 @@
+// Package sessionstore provides gorilla session stores which are not part of gorilla/sessions
+package sessionstore
+
+import (
+	"context"
+	"database/sql"
+	"encoding/base32"
+	"errors"
+	"fmt"
+	"net/http"
+	"regexp"
+	"time"
+
+	"github.com/gorilla/securecookie"
+	"github.com/gorilla/sessions"
+)
+
+type (
+	// SQLDialect describes the SQL flavour of the database
+	SQLDialect string
+
+	// SQLStore stores sessions in a table of a SQL database.
+	// Session values are encoded with the codecs, like the gorilla FilesystemStore, the cookie only contains the session id.
+	SQLStore struct {
+		Codecs  []securecookie.Codec
+		Options *sessions.Options
+		db      *sql.DB
+		dialect SQLDialect
+		table   string
+		timeout time.Duration
+	}
+)
+
+const (
+	// SQLDialectPostgres for PostgreSQL
+	SQLDialectPostgres SQLDialect = "postgres"
+	// SQLDialectMySQL for MySQL and MariaDB
+	SQLDialectMySQL SQLDialect = "mysql"
+	// SQLDialectSQLite for SQLite
+	SQLDialectSQLite SQLDialect = "sqlite"
+)
+
+var (
+	base32RawStdEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+	validTableName       = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_.]*$`)
+
+	// ErrUnknownDialect is returned for unsupported SQL dialects
+	ErrUnknownDialect = errors.New("unknown sql dialect")
+	// ErrInvalidTableName is returned for table names which are not plain identifiers
+	ErrInvalidTableName = errors.New("invalid table name")
+
+	_ sessions.Store = new(SQLStore)
+)
+
+// DialectForDriver guesses the dialect from the name of a database/sql driver
+func DialectForDriver(driver string) SQLDialect {
+	switch driver {
+	case "postgres", "pgx", "pq":
+		return SQLDialectPostgres
+	case "mysql":
+		return SQLDialectMySQL
+	case "sqlite", "sqlite3":
+		return SQLDialectSQLite
+	}
+
+	return ""
+}
+
+// NewSQLStore creates a store for the table, the key pairs are used like in sessions.NewFilesystemStore
+func NewSQLStore(db *sql.DB, dialect SQLDialect, table string, keyPairs ...[]byte) (*SQLStore, error) {
+	switch dialect {
+	case SQLDialectPostgres, SQLDialectMySQL, SQLDialectSQLite:
+	default:
+		return nil, fmt.Errorf("%w: %q", ErrUnknownDialect, dialect)
+	}
+
+	if !validTableName.MatchString(table) {
+		return nil, fmt.Errorf("%w: %q", ErrInvalidTableName, table)
+	}
+
+	store := &SQLStore{
+		Codecs: securecookie.CodecsFromPairs(keyPairs...),
+		Options: &sessions.Options{
+			Path:   "/",
+			MaxAge: 86400 * 30,
+		},
+		db:      db,
+		dialect: dialect,
+		table:   table,
+		timeout: 5 * time.Second,
+	}
+	store.MaxAge(store.Options.MaxAge)
+
+	return store, nil
+}
+
+// DB returns the database of the store
+func (s *SQLStore) DB() *sql.DB {
+	return s.db
+}
+
+// MaxLength restricts the maximum length of the encoded session values, 0 disables the limit
+func (s *SQLStore) MaxLength(l int) {
+	for _, c := range s.Codecs {
+		if codec, ok := c.(*securecookie.SecureCookie); ok {
+			codec.MaxLength(l)
+		}
+	}
+}
+
+// MaxAge sets the maximum age for the store and the underlying cookie implementation
+func (s *SQLStore) MaxAge(age int) {
+	s.Options.MaxAge = age
+
+	for _, c := range s.Codecs {
+		if codec, ok := c.(*securecookie.SecureCookie); ok {
+			codec.MaxAge(age)
+		}
+	}
+}
+
+// CreateTable creates the session table if it does not exist yet
+func (s *SQLStore) CreateTable(ctx context.Context) error {
+	dataType := "TEXT"
+	if s.dialect == SQLDialectMySQL {
+		dataType = "MEDIUMTEXT"
+	}
+
+	_, err := s.db.ExecContext(ctx, fmt.Sprintf(
+		"CREATE TABLE IF NOT EXISTS %s (id VARCHAR(64) NOT NULL PRIMARY KEY, data %s NOT NULL, expires_at BIGINT NOT NULL)",
+		s.table, dataType,
+	))
+	if err != nil {
+		return fmt.Errorf("sql session store: failed to create table %q: %w", s.table, err)
+	}
+
+	return nil
+}
+
+// Get returns a session for the given name after adding it to the registry
+func (s *SQLStore) Get(r *http.Request, name string) (*sessions.Session, error) {
+	return sessions.GetRegistry(r).Get(s, name)
+}
+
+// New returns a session for the given name without adding it to the registry.
+// Unknown or expired session ids result in a new session.
+func (s *SQLStore) New(r *http.Request, name string) (*sessions.Session, error) {
+	session := sessions.NewSession(s, name)
+	opts := *s.Options
+	session.Options = &opts
+	session.IsNew = true
+
+	cookie, err := r.Cookie(name)
+	if err != nil {
+		return session, nil
+	}
+
+	if err := securecookie.DecodeMulti(name, cookie.Value, &session.ID, s.Codecs...); err != nil {
+		session.ID = ""
+		return session, err
+	}
+
+	found, err := s.load(r.Context(), session)
+	if err != nil {
+		return session, err
+	}
+
+	if !found {
+		session.ID = ""
+		return session, nil
+	}
+
+	session.IsNew = false
+
+	return session, nil
+}
+
+// Save persists the session and sets the session cookie.
+// Sessions with Options.MaxAge < 0 are deleted.
+func (s *SQLStore) Save(r *http.Request, w http.ResponseWriter, session *sessions.Session) error {
+	if session.Options.MaxAge < 0 {
+		if session.ID != "" {
+			if err := s.Delete(r.Context(), session.ID); err != nil {
+				return err
+			}
+		}
+
+		http.SetCookie(w, sessions.NewCookie(session.Name(), "", session.Options))
+		return nil
+	}
+
+	if session.ID == "" {
+		session.ID = base32RawStdEncoding.EncodeToString(securecookie.GenerateRandomKey(32))
+	}
+
+	if err := s.save(r.Context(), session); err != nil {
+		return err
+	}
+
+	encoded, err := securecookie.EncodeMulti(session.Name(), session.ID, s.Codecs...)
+	if err != nil {
+		return err
+	}
+
+	http.SetCookie(w, sessions.NewCookie(session.Name(), encoded, session.Options))
+
+	return nil
+}
+
+// Delete the session with the id
+func (s *SQLStore) Delete(ctx context.Context, id string) error {
+	ctx, cancel := s.context(ctx)
+	defer cancel()
+
+	if _, err := s.db.ExecContext(ctx, s.query("DELETE FROM %s WHERE id = ?"), id); err != nil {
+		return fmt.Errorf("sql session store: failed to delete session: %w", err)
+	}
+
+	return nil
+}
+
+// Cleanup deletes all expired sessions and returns the number of deleted sessions
+func (s *SQLStore) Cleanup(ctx context.Context) (int64, error) {
+	ctx, cancel := s.context(ctx)
+	defer cancel()
+
+	result, err := s.db.ExecContext(ctx, s.query("DELETE FROM %s WHERE expires_at > 0 AND expires_at < ?"), time.Now().Unix())
+	if err != nil {
+		return 0, fmt.Errorf("sql session store: failed to delete expired sessions: %w", err)
+	}
+
+	return result.RowsAffected()
+}
+
+// StartCleanup deletes expired sessions in the interval until stop is called
+func (s *SQLStore) StartCleanup(interval time.Duration, onError func(error)) (stop func()) {
+	ticker := time.NewTicker(interval)
+	done := make(chan struct{})
+
+	go func() {
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				if _, err := s.Cleanup(context.Background()); err != nil && onError != nil {
+					onError(err)
+				}
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	return func() {
+		close(done)
+	}
+}
+
+// Ping checks if the database is available
+func (s *SQLStore) Ping(ctx context.Context) error {
+	ctx, cancel := s.context(ctx)
+	defer cancel()
+
+	return s.db.PingContext(ctx)
+}
+
+func (s *SQLStore) load(ctx context.Context, session *sessions.Session) (bool, error) {
+	ctx, cancel := s.context(ctx)
+	defer cancel()
+
+	var data string
+	err := s.db.QueryRowContext(
+		ctx,
+		s.query("SELECT data FROM %s WHERE id = ? AND (expires_at = 0 OR expires_at >= ?)"),
+		session.ID, time.Now().Unix(),
+	).Scan(&data)
+	if errors.Is(err, sql.ErrNoRows) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("sql session store: failed to load session: %w", err)
+	}
+
+	if err := securecookie.DecodeMulti(session.Name(), data, &session.Values, s.Codecs...); err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+func (s *SQLStore) save(ctx context.Context, session *sessions.Session) error {
+	encoded, err := securecookie.EncodeMulti(session.Name(), session.Values, s.Codecs...)
+	if err != nil {
+		return err
+	}
+
+	var expiresAt int64
+	if session.Options.MaxAge > 0 {
+		expiresAt = time.Now().Add(time.Duration(session.Options.MaxAge) * time.Second).Unix()
+	}
+
+	ctx, cancel := s.context(ctx)
+	defer cancel()
+
+	upsert := "INSERT INTO %s (id, data, expires_at) VALUES (?, ?, ?) ON CONFLICT (id) DO UPDATE SET data = excluded.data, expires_at = excluded.expires_at"
+	if s.dialect == SQLDialectMySQL {
+		upsert = "INSERT INTO %s (id, data, expires_at) VALUES (?, ?, ?) ON DUPLICATE KEY UPDATE data = VALUES(data), expires_at = VALUES(expires_at)"
+	}
+
+	if _, err := s.db.ExecContext(ctx, s.query(upsert), session.ID, encoded, expiresAt); err != nil {
+		return fmt.Errorf("sql session store: failed to save session: %w", err)
+	}
+
+	return nil
+}
+
+// query inserts the table name and replaces the placeholders for postgres
+func (s *SQLStore) query(query string) string {
+	query = fmt.Sprintf(query, s.table)
+	if s.dialect != SQLDialectPostgres {
+		return query
+	}
+
+	var (
+		result []byte
+		n      int
+	)
+	for i := 0; i < len(query); i++ {
+		if query[i] == '?' {
+			n++
+			result = fmt.Appendf(result, "$%d", n)
+			continue
+		}
+		result = append(result, query[i])
+	}
+
+	return string(result)
+}
+
+func (s *SQLStore) context(ctx context.Context) (context.Context, context.CancelFunc) {
+	if s.timeout > 0 {
+		return context.WithTimeout(ctx, s.timeout)
+	}
+	return context.WithCancel(ctx)
+}
@@ -0,0 +1,153 @@
+package sessionstore_test
+
+import (
+	"context"
+	"database/sql"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/sessions"
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"flamingo.me/flamingo/v3/framework/flamingo/sessionstore"
+)
+
+func newTestStore(t *testing.T) *sessionstore.SQLStore {
+	t.Helper()
+
+	db, err := sql.Open("sqlite3", filepath.Join(t.TempDir(), "sessions.db"))
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = db.Close() })
+
+	store, err := sessionstore.NewSQLStore(db, sessionstore.SQLDialectSQLite, "flamingo_sessions", []byte("secret"))
+	require.NoError(t, err)
+	require.NoError(t, store.CreateTable(context.Background()))
+
+	return store
+}
+
+func requestWithCookies(cookies []*http.Cookie) *http.Request {
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	for _, cookie := range cookies {
+		req.AddCookie(cookie)
+	}
+	return req
+}
+
+func TestNewSQLStore(t *testing.T) {
+	t.Parallel()
+
+	_, err := sessionstore.NewSQLStore(nil, "oracle", "sessions")
+	assert.ErrorIs(t, err, sessionstore.ErrUnknownDialect)
+
+	_, err = sessionstore.NewSQLStore(nil, sessionstore.SQLDialectPostgres, "sessions; DROP TABLE users")
+	assert.ErrorIs(t, err, sessionstore.ErrInvalidTableName)
+
+	assert.Equal(t, sessionstore.SQLDialectPostgres, sessionstore.DialectForDriver("pgx"))
+	assert.Equal(t, sessionstore.SQLDialectSQLite, sessionstore.DialectForDriver("sqlite3"))
+}
+
+func TestSQLStore_SaveLoad(t *testing.T) {
+	t.Parallel()
+
+	store := newTestStore(t)
+
+	session, err := store.New(httptest.NewRequest(http.MethodGet, "/", nil), "flamingo")
+	require.NoError(t, err)
+	assert.True(t, session.IsNew)
+
+	session.Values["user"] = "flamingo"
+	recorder := httptest.NewRecorder()
+	require.NoError(t, store.Save(httptest.NewRequest(http.MethodGet, "/", nil), recorder, session))
+	require.NotEmpty(t, session.ID)
+
+	cookies := recorder.Result().Cookies()
+	require.Len(t, cookies, 1)
+	assert.NotContains(t, cookies[0].Value, "flamingo", "the cookie only contains the session id")
+
+	loaded, err := store.New(requestWithCookies(cookies), "flamingo")
+	require.NoError(t, err)
+	assert.False(t, loaded.IsNew)
+	assert.Equal(t, session.ID, loaded.ID)
+	assert.Equal(t, "flamingo", loaded.Values["user"])
+
+	t.Run("update", func(t *testing.T) {
+		loaded.Values["user"] = "pink"
+		require.NoError(t, store.Save(httptest.NewRequest(http.MethodGet, "/", nil), httptest.NewRecorder(), loaded))
+
+		loaded, err := store.New(requestWithCookies(cookies), "flamingo")
+		require.NoError(t, err)
+		assert.Equal(t, "pink", loaded.Values["user"])
+	})
+
+	t.Run("delete", func(t *testing.T) {
+		loaded.Options.MaxAge = -1
+		recorder := httptest.NewRecorder()
+		require.NoError(t, store.Save(httptest.NewRequest(http.MethodGet, "/", nil), recorder, loaded))
+		assert.Contains(t, recorder.Header().Get("Set-Cookie"), "Max-Age=0")
+
+		deleted, err := store.New(requestWithCookies(cookies), "flamingo")
+		require.NoError(t, err)
+		assert.True(t, deleted.IsNew)
+		assert.Empty(t, deleted.ID, "unknown ids are not reused")
+	})
+}
+
+func TestSQLStore_InvalidCookie(t *testing.T) {
+	t.Parallel()
+
+	store := newTestStore(t)
+
+	session, err := store.New(requestWithCookies([]*http.Cookie{{Name: "flamingo", Value: "forged"}}), "flamingo")
+	assert.Error(t, err)
+	assert.True(t, session.IsNew)
+	assert.Empty(t, session.ID)
+}
+
+func TestSQLStore_MaxLength(t *testing.T) {
+	t.Parallel()
+
+	store := newTestStore(t)
+	store.MaxLength(100)
+
+	session := sessions.NewSession(store, "flamingo")
+	session.Options = &sessions.Options{MaxAge: 60}
+	session.Values["data"] = strings.Repeat("x", 200)
+
+	assert.Error(t, store.Save(httptest.NewRequest(http.MethodGet, "/", nil), httptest.NewRecorder(), session))
+}
+
+func TestSQLStore_Cleanup(t *testing.T) {
+	t.Parallel()
+
+	store := newTestStore(t)
+
+	save := func(maxAge int) *sessions.Session {
+		session := sessions.NewSession(store, "flamingo")
+		session.Options = &sessions.Options{MaxAge: maxAge}
+		require.NoError(t, store.Save(httptest.NewRequest(http.MethodGet, "/", nil), httptest.NewRecorder(), session))
+		return session
+	}
+
+	save(3600)
+	save(0)
+	expired := save(3600)
+	_, err := store.DB().Exec("UPDATE flamingo_sessions SET expires_at = ? WHERE id = ?", time.Now().Add(-time.Minute).Unix(), expired.ID)
+	require.NoError(t, err)
+
+	deleted, err := store.Cleanup(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), deleted)
+
+	var count int
+	require.NoError(t, store.DB().QueryRow("SELECT COUNT(*) FROM flamingo_sessions").Scan(&count))
+	assert.Equal(t, 2, count)
+
+	require.NoError(t, store.Ping(context.Background()))
+}
@@ -53,6 +53,9 @@ func (*InitModule) Configure(injector *dingo.Injector) {
 	injector.Bind(new(web.ReverseRouter)).To(web.Router{})
 	injector.Bind(web.RouterRegistry{}).In(dingo.Singleton).ToProvider(web.NewRegistry)
 	injector.BindMulti(new(web.Filter)).To(new(filter.MetricsFilter))
+	web.BindEncoder(injector, new(web.JSONEncoder))
+	web.BindEncoder(injector, new(web.XMLEncoder))
+	web.BindEncoder(injector, new(web.MsgpackEncoder))
 
 	flamingo.BindTemplateFunc(injector, "config", new(config.TemplateFunc))
 	flamingo.BindTemplateFunc(injector, "setPartialData", new(web.SetPartialDataFunc))
@@ -100,9 +103,16 @@ flamingo: {
 		notfound: string | *"flamingo.notfound"
 		error: string | *"flamingo.error"
 		timeout: int | *60000
+		methodNotAllowed: bool | *true
+		autoOptions: bool | *true
+		autoHead: bool | *true
 		scheme?: string
 		host?: string
 		path?: string
+		// CIDRs or addresses of reverse proxies, whose Forwarded, X-Forwarded-* and X-Real-IP headers are trusted
+		trustedProxies: [...string] | *[]
+		// the headers set by the trusted proxies, either the RFC 7239 Forwarded header or X-Forwarded-* and X-Real-IP
+		forwardedHeaders: "forwarded" | *"x-forwarded"
 	}
 	template: {
 		err400: string | *"error/400"
@@ -111,6 +121,11 @@ flamingo: {
 		errWithCode: string | *"error/withCode"
 		err503: string | *"error/503"
 	}
+	web: {
+		dataResponse: {
+			defaultMediaType: string | *"application/json"
+		}
+	}
 	session: {
 		name: string | *"flamingo"
 		saveMode: *"Always" | "OnRead" | "OnWrite" 
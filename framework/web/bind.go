@@ -0,0 +1,474 @@
+package web
+
+import (
+	"bytes"
+	"encoding"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type (
+	// BindError is returned by Bind if the request does not fit the target, it holds one error per failed field
+	BindError struct {
+		Fields []FieldError `json:"fields"`
+	}
+
+	// FieldError describes why a single field could not be bound or validated
+	FieldError struct {
+		// Field is the name of the field in its source, e.g. the query parameter name
+		Field string `json:"field"`
+		// Source is one of param, query, form, header or json
+		Source string `json:"source"`
+		// Rule is the failed validation rule, or "type" if the value could not be converted
+		Rule    string `json:"rule"`
+		Message string `json:"message"`
+	}
+
+	bindSource struct {
+		tag    string
+		values func(req *Request, name string) ([]string, error)
+	}
+)
+
+const (
+	bindSourceParam  = "param"
+	bindSourceQuery  = "query"
+	bindSourceForm   = "form"
+	bindSourceHeader = "header"
+	bindSourceJSON   = "json"
+)
+
+var (
+	bindSources = []bindSource{
+		{tag: bindSourceParam, values: paramValues},
+		{tag: bindSourceQuery, values: queryValues},
+		{tag: bindSourceForm, values: formValues},
+		{tag: bindSourceHeader, values: headerValues},
+	}
+
+	typeOfTextUnmarshaler = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+	typeOfDuration        = reflect.TypeOf(time.Duration(0))
+	typeOfTime            = reflect.TypeOf(time.Time{})
+)
+
+// Bind fills a new T from the request, T must be a struct.
+//
+// Fields are filled from the sources named by their struct tags:
+// `param:"id"` for route params (including the defaults declared on the route), `query:"q"`, `form:"name"`
+// and `header:"X-Name"`. A JSON request body fills the fields with a `json` tag, other fields are never set from the body.
+// Afterwards the `validate` tag is checked, supported rules are required, min=n, max=n, oneof=a b c and pattern=regex,
+// all rules except required are skipped for values which are not sent.
+//
+// Conversion and validation problems are returned as *BindError.
+func Bind[T any](req *Request) (T, error) {
+	var target T
+
+	value := reflect.ValueOf(&target).Elem()
+	if value.Kind() != reflect.Struct {
+		return target, fmt.Errorf("web.Bind: target %T is not a struct", target)
+	}
+
+	bindErr := new(BindError)
+
+	body, err := bindJSON(req)
+	if err != nil {
+		var syntaxErr *json.SyntaxError
+		var typeErr *json.UnmarshalTypeError
+		switch {
+		case errors.As(err, &typeErr):
+			bindErr.add("", bindSourceJSON, "type", "request body must be a JSON object")
+		case errors.As(err, &syntaxErr), errors.Is(err, io.ErrUnexpectedEOF):
+			bindErr.add("", bindSourceJSON, "syntax", "request body is not valid JSON")
+		default:
+			return target, err
+		}
+	}
+
+	if err := bindStruct(req, body, value, bindErr); err != nil {
+		return target, err
+	}
+
+	if len(bindErr.Fields) > 0 {
+		return target, bindErr
+	}
+
+	return target, nil
+}
+
+// Error joins all field errors
+func (e *BindError) Error() string {
+	messages := make([]string, len(e.Fields))
+	for i, field := range e.Fields {
+		messages[i] = field.Error()
+	}
+	return strings.Join(messages, ", ")
+}
+
+func (e *BindError) add(field, source, rule, message string) {
+	e.Fields = append(e.Fields, FieldError{Field: field, Source: source, Rule: rule, Message: message})
+}
+
+// Error message of the field
+func (e FieldError) Error() string {
+	if e.Field == "" {
+		return e.Source + ": " + e.Message
+	}
+	return e.Source + " " + e.Field + ": " + e.Message
+}
+
+func paramValues(req *Request, name string) ([]string, error) {
+	if value, ok := req.Params[name]; ok {
+		return []string{value}, nil
+	}
+	if req.Handler != nil {
+		if param, ok := req.Handler.params[name]; ok && param.value != "" {
+			return []string{param.value}, nil
+		}
+	}
+	return nil, nil
+}
+
+func queryValues(req *Request, name string) ([]string, error) {
+	return req.QueryAll()[name], nil
+}
+
+func formValues(req *Request, name string) ([]string, error) {
+	form, err := req.FormAll()
+	if err != nil {
+		return nil, err
+	}
+	return form[name], nil
+}
+
+func headerValues(req *Request, name string) ([]string, error) {
+	return req.Request().Header.Values(name), nil
+}
+
+// bindJSON decodes the members of a JSON object request body, the body is restored afterwards so it can be read again
+func bindJSON(req *Request) (map[string]json.RawMessage, error) {
+	httpRequest := req.Request()
+	if httpRequest.Body == nil {
+		return nil, nil
+	}
+
+	mediaType, _, _ := mime.ParseMediaType(httpRequest.Header.Get("Content-Type"))
+	if mediaType != "application/json" && !strings.HasSuffix(mediaType, "+json") {
+		return nil, nil
+	}
+
+	body, err := io.ReadAll(httpRequest.Body)
+	_ = httpRequest.Body.Close()
+	httpRequest.Body = io.NopCloser(bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+
+	if len(bytes.TrimSpace(body)) == 0 {
+		return nil, nil
+	}
+
+	var members map[string]json.RawMessage
+	if err := json.Unmarshal(body, &members); err != nil {
+		return nil, err
+	}
+
+	return members, nil
+}
+
+// jsonMember returns the member for the json tag, names are matched case-insensitive like encoding/json does
+func jsonMember(body map[string]json.RawMessage, field reflect.StructField) (json.RawMessage, string, bool) {
+	tag, ok := field.Tag.Lookup(bindSourceJSON)
+	if !ok {
+		return nil, "", false
+	}
+
+	name := strings.Split(tag, ",")[0]
+	if name == "-" {
+		return nil, "", false
+	}
+	if name == "" {
+		name = field.Name
+	}
+
+	if raw, ok := body[name]; ok {
+		return raw, name, true
+	}
+	for key, raw := range body {
+		if strings.EqualFold(key, name) {
+			return raw, name, true
+		}
+	}
+
+	return nil, name, false
+}
+
+func bindStruct(req *Request, body map[string]json.RawMessage, value reflect.Value, bindErr *BindError) error {
+	t := value.Type()
+
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+
+		if field.Anonymous && field.Type.Kind() == reflect.Struct {
+			if err := bindStruct(req, body, value.Field(i), bindErr); err != nil {
+				return err
+			}
+			continue
+		}
+
+		name, source := fieldName(field)
+		converted, supplied := true, false
+
+		if raw, jsonName, ok := jsonMember(body, field); ok {
+			supplied = true
+			if err := json.Unmarshal(raw, value.Field(i).Addr().Interface()); err != nil {
+				message := err.Error()
+				var typeErr *json.UnmarshalTypeError
+				if errors.As(err, &typeErr) {
+					message = fmt.Sprintf("must be of type %s", typeErr.Type)
+				}
+				bindErr.add(jsonName, bindSourceJSON, "type", message)
+				converted = false
+			}
+		}
+
+		for _, s := range bindSources {
+			tagName, ok := field.Tag.Lookup(s.tag)
+			if !ok || tagName == "-" {
+				continue
+			}
+
+			values, err := s.values(req, tagName)
+			if err != nil {
+				return err
+			}
+			if len(values) == 0 {
+				continue
+			}
+
+			supplied = true
+			if err := setValue(value.Field(i), values); err != nil {
+				bindErr.add(tagName, s.tag, "type", err.Error())
+				converted = false
+			}
+		}
+
+		if rules, ok := field.Tag.Lookup("validate"); ok && converted {
+			validateField(value.Field(i), supplied, name, source, rules, bindErr)
+		}
+	}
+
+	return nil
+}
+
+// fieldName returns the name and source used for errors
+func fieldName(field reflect.StructField) (string, string) {
+	for _, s := range bindSources {
+		if name, ok := field.Tag.Lookup(s.tag); ok && name != "-" {
+			return name, s.tag
+		}
+	}
+
+	if name, ok := field.Tag.Lookup(bindSourceJSON); ok {
+		if name = strings.Split(name, ",")[0]; name != "" && name != "-" {
+			return name, bindSourceJSON
+		}
+	}
+
+	return field.Name, bindSourceJSON
+}
+
+func setValue(field reflect.Value, values []string) error {
+	if field.Kind() == reflect.Ptr {
+		elem := reflect.New(field.Type().Elem())
+		if err := setValue(elem.Elem(), values); err != nil {
+			return err
+		}
+		field.Set(elem)
+		return nil
+	}
+
+	if field.Kind() == reflect.Slice && !field.Addr().Type().Implements(typeOfTextUnmarshaler) {
+		slice := reflect.MakeSlice(field.Type(), len(values), len(values))
+		for i, value := range values {
+			if err := setValue(slice.Index(i), []string{value}); err != nil {
+				return err
+			}
+		}
+		field.Set(slice)
+		return nil
+	}
+
+	return setScalar(field, values[0])
+}
+
+func setScalar(field reflect.Value, value string) error {
+	if field.Addr().Type().Implements(typeOfTextUnmarshaler) {
+		return field.Addr().Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(value))
+	}
+
+	switch {
+	case field.Type() == typeOfDuration:
+		d, err := time.ParseDuration(value)
+		if err != nil {
+			return fmt.Errorf("must be a duration")
+		}
+		field.SetInt(int64(d))
+		return nil
+	case field.Type() == typeOfTime:
+		t, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			return fmt.Errorf("must be a RFC 3339 date")
+		}
+		field.Set(reflect.ValueOf(t))
+		return nil
+	}
+
+	switch field.Kind() {
+	case reflect.String:
+		field.SetString(value)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return fmt.Errorf("must be a boolean")
+		}
+		field.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		i, err := strconv.ParseInt(value, 10, field.Type().Bits())
+		if err != nil {
+			return fmt.Errorf("must be an integer")
+		}
+		field.SetInt(i)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		u, err := strconv.ParseUint(value, 10, field.Type().Bits())
+		if err != nil {
+			return fmt.Errorf("must be a positive integer")
+		}
+		field.SetUint(u)
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(value, field.Type().Bits())
+		if err != nil {
+			return fmt.Errorf("must be a number")
+		}
+		field.SetFloat(f)
+	default:
+		return fmt.Errorf("unsupported type %s", field.Type())
+	}
+
+	return nil
+}
+
+// validateField checks the rules, all rules except required are only checked for supplied values, even if they are zero
+func validateField(field reflect.Value, supplied bool, name, source, rules string, bindErr *BindError) {
+	for _, rule := range splitRules(rules) {
+		rule, arg, _ := strings.Cut(rule, "=")
+
+		if rule == "required" {
+			if field.IsZero() {
+				bindErr.add(name, source, rule, "is required")
+				return
+			}
+			continue
+		}
+
+		// all other rules only apply to values which are sent
+		if !supplied {
+			return
+		}
+
+		value := field
+		for value.Kind() == reflect.Ptr {
+			if value.IsNil() {
+				return
+			}
+			value = value.Elem()
+		}
+
+		if message := checkRule(value, rule, arg); message != "" {
+			bindErr.add(name, source, rule, message)
+		}
+	}
+}
+
+// splitRules splits the comma separated rules, pattern must be the last rule and takes the rest verbatim,
+// so regular expressions may contain commas
+func splitRules(rules string) []string {
+	var result []string
+
+	for rules != "" {
+		rule, rest, _ := strings.Cut(rules, ",")
+		if trimmed := strings.TrimLeft(rules, " "); strings.HasPrefix(trimmed, "pattern=") {
+			rule, rest = trimmed, ""
+		}
+
+		result = append(result, strings.TrimSpace(rule))
+		rules = rest
+	}
+
+	return result
+}
+
+// checkRule validates a single rule and returns an error message if the value is invalid
+func checkRule(value reflect.Value, rule, arg string) string {
+	switch rule {
+	case "min", "max":
+		limit, err := strconv.ParseFloat(arg, 64)
+		if err != nil {
+			return fmt.Sprintf("invalid rule %s=%s", rule, arg)
+		}
+
+		var actual float64
+		var unit string
+		switch value.Kind() {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			actual = float64(value.Int())
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			actual = float64(value.Uint())
+		case reflect.Float32, reflect.Float64:
+			actual = value.Float()
+		case reflect.String:
+			actual, unit = float64(len([]rune(value.String()))), " characters"
+		case reflect.Slice, reflect.Map:
+			actual, unit = float64(value.Len()), " items"
+		default:
+			return ""
+		}
+
+		if rule == "min" && actual < limit {
+			return fmt.Sprintf("must be at least %s%s", arg, unit)
+		}
+		if rule == "max" && actual > limit {
+			return fmt.Sprintf("must be at most %s%s", arg, unit)
+		}
+	case "oneof":
+		actual := fmt.Sprint(value.Interface())
+		for _, allowed := range strings.Fields(arg) {
+			if actual == allowed {
+				return ""
+			}
+		}
+		return fmt.Sprintf("must be one of %s", strings.Join(strings.Fields(arg), ", "))
+	case "pattern":
+		re, err := regexp.Compile(arg)
+		if err != nil {
+			return fmt.Sprintf("invalid rule %s=%s", rule, arg)
+		}
+		if !re.MatchString(fmt.Sprint(value.Interface())) {
+			return fmt.Sprintf("must match %s", arg)
+		}
+	default:
+		return fmt.Sprintf("unknown rule %s", rule)
+	}
+
+	return ""
+}
@@ -0,0 +1,174 @@
+package web
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type bindTestTarget struct {
+	Token   string        `header:"X-Token" validate:"required"`
+	ID      int           `param:"id" validate:"required,min=1"`
+	Page    string        `param:"page"`
+	Sort    string        `query:"sort" validate:"oneof=name price"`
+	Tags    []string      `query:"tag" validate:"max=2"`
+	Amount  *float64      `form:"amount"`
+	Timeout time.Duration `query:"timeout"`
+	Note    string        `json:"note" validate:"max=5"`
+	Code    string        `query:"code" validate:"pattern=^[A-Z]+$"`
+	Year    string        `query:"year" validate:"min=2, pattern=^\\d{2,4}$"`
+	Limit   int           `query:"limit" validate:"min=1"`
+	User    string        `header:"X-User"`
+}
+
+func TestBind(t *testing.T) {
+	newRequest := func(method, target string, body io.Reader, header http.Header) *Request {
+		httpRequest := httptest.NewRequest(method, target, body)
+		for k, v := range header {
+			httpRequest.Header[k] = v
+		}
+		req := CreateRequest(httpRequest, nil)
+		req.Params = RequestParams{"id": "12"}
+		req.Handler = &Handler{params: map[string]*param{"page": {value: "home", optional: true}}}
+		return req
+	}
+
+	t.Run("binds all sources", func(t *testing.T) {
+		req := newRequest(
+			http.MethodPost,
+			"/?sort=price&tag=a&tag=b&timeout=2s&code=ABC&amount=1.5&year=2024",
+			strings.NewReader(`{"note":"hi"}`),
+			http.Header{"X-Token": {"secret"}, "Content-Type": {"application/json"}},
+		)
+
+		target, err := Bind[bindTestTarget](req)
+		require.NoError(t, err)
+
+		assert.Equal(t, "secret", target.Token)
+		assert.Equal(t, 12, target.ID)
+		assert.Equal(t, "home", target.Page)
+		assert.Equal(t, "price", target.Sort)
+		assert.Equal(t, []string{"a", "b"}, target.Tags)
+		require.NotNil(t, target.Amount)
+		assert.Equal(t, 1.5, *target.Amount)
+		assert.Equal(t, 2*time.Second, target.Timeout)
+		assert.Equal(t, "hi", target.Note)
+		assert.Equal(t, "ABC", target.Code)
+		assert.Equal(t, "2024", target.Year)
+
+		body, err := io.ReadAll(req.Request().Body)
+		require.NoError(t, err)
+		assert.Equal(t, `{"note":"hi"}`, string(body), "body must be readable again")
+	})
+
+	t.Run("binds form values", func(t *testing.T) {
+		form := url.Values{"amount": {"3"}}
+		req := newRequest(
+			http.MethodPost,
+			"/?sort=name",
+			strings.NewReader(form.Encode()),
+			http.Header{"X-Token": {"secret"}, "Content-Type": {"application/x-www-form-urlencoded"}},
+		)
+
+		target, err := Bind[bindTestTarget](req)
+		require.NoError(t, err)
+		require.NotNil(t, target.Amount)
+		assert.Equal(t, 3.0, *target.Amount)
+		assert.Equal(t, "name", target.Sort)
+	})
+
+	t.Run("returns field errors", func(t *testing.T) {
+		req := newRequest(
+			http.MethodPost,
+			"/?sort=date&tag=a&tag=b&tag=c&timeout=soon&code=abc&year=20245",
+			strings.NewReader(`{"note":"too long"}`),
+			http.Header{"Content-Type": {"application/json"}},
+		)
+		req.Params["id"] = "x"
+
+		_, err := Bind[bindTestTarget](req)
+		require.Error(t, err)
+
+		var bindErr *BindError
+		require.ErrorAs(t, err, &bindErr)
+
+		assert.ElementsMatch(t, []FieldError{
+			{Field: "X-Token", Source: "header", Rule: "required", Message: "is required"},
+			{Field: "id", Source: "param", Rule: "type", Message: "must be an integer"},
+			{Field: "sort", Source: "query", Rule: "oneof", Message: "must be one of name, price"},
+			{Field: "tag", Source: "query", Rule: "max", Message: "must be at most 2 items"},
+			{Field: "timeout", Source: "query", Rule: "type", Message: "must be a duration"},
+			{Field: "note", Source: "json", Rule: "max", Message: "must be at most 5 characters"},
+			{Field: "code", Source: "query", Rule: "pattern", Message: "must match ^[A-Z]+$"},
+			{Field: "year", Source: "query", Rule: "pattern", Message: `must match ^\d{2,4}$`},
+		}, bindErr.Fields)
+	})
+
+	t.Run("json only fills json fields", func(t *testing.T) {
+		req := newRequest(
+			http.MethodPost,
+			"/",
+			strings.NewReader(`{"note":"hi","User":"admin","XUser":"admin","Token":"forged"}`),
+			http.Header{"X-Token": {"secret"}, "Content-Type": {"application/json"}},
+		)
+
+		target, err := Bind[bindTestTarget](req)
+		require.NoError(t, err)
+		assert.Equal(t, "hi", target.Note)
+		assert.Empty(t, target.User)
+		assert.Equal(t, "secret", target.Token)
+	})
+
+	t.Run("zero values are validated if they are sent", func(t *testing.T) {
+		req := newRequest(http.MethodGet, "/?limit=0", nil, http.Header{"X-Token": {"secret"}})
+
+		_, err := Bind[bindTestTarget](req)
+
+		var bindErr *BindError
+		require.ErrorAs(t, err, &bindErr)
+		assert.Equal(t, []FieldError{{Field: "limit", Source: "query", Rule: "min", Message: "must be at least 1"}}, bindErr.Fields)
+
+		_, err = Bind[bindTestTarget](newRequest(http.MethodGet, "/", nil, http.Header{"X-Token": {"secret"}}))
+		assert.NoError(t, err, "rules of missing values are skipped")
+	})
+
+	t.Run("invalid json", func(t *testing.T) {
+		req := newRequest(http.MethodPost, "/", strings.NewReader(`{"note":`), http.Header{"Content-Type": {"application/json"}, "X-Token": {"a"}})
+
+		_, err := Bind[bindTestTarget](req)
+
+		var bindErr *BindError
+		require.ErrorAs(t, err, &bindErr)
+		assert.Equal(t, []FieldError{{Source: "json", Rule: "syntax", Message: "request body is not valid JSON"}}, bindErr.Fields)
+	})
+
+	t.Run("target must be a struct", func(t *testing.T) {
+		_, err := Bind[string](newRequest(http.MethodGet, "/", nil, nil))
+		assert.Error(t, err)
+	})
+
+	t.Run("bad request response contains fields", func(t *testing.T) {
+		_, err := Bind[bindTestTarget](newRequest(http.MethodGet, "/?sort=name", nil, nil))
+		require.Error(t, err)
+
+		responder := new(Responder)
+		recorder := httptest.NewRecorder()
+		require.NoError(t, responder.BadRequestWithContext(context.Background(), err).Apply(context.Background(), recorder))
+		assert.Equal(t, http.StatusBadRequest, recorder.Code)
+
+		var data struct {
+			Fields []FieldError `json:"fields"`
+		}
+		require.NoError(t, json.NewDecoder(recorder.Body).Decode(&data))
+		assert.Equal(t, []FieldError{{Field: "X-Token", Source: "header", Rule: "required", Message: "is required"}}, data.Fields)
+	})
+}
@@ -0,0 +1,288 @@
+package web
+
+import (
+	"context"
+	"encoding/json"
+	"encoding/xml"
+	"io"
+	"mime"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"flamingo.me/dingo"
+	"github.com/vmihailenco/msgpack/v5"
+)
+
+type (
+	// Encoder serializes the data of a DataResponse into a certain media type
+	Encoder interface {
+		// MediaType handled by the encoder, e.g. application/json
+		MediaType() string
+		// ContentType sent as Content-Type header, e.g. application/json; charset=utf-8
+		ContentType() string
+		// Encode writes the serialized data
+		Encode(ctx context.Context, w io.Writer, data interface{}) error
+	}
+
+	// JSONEncoder encodes data as application/json
+	JSONEncoder struct{}
+
+	// XMLEncoder encodes data as application/xml.
+	// Maps with string keys are encoded as elements named by their keys, wrapped in a response element.
+	XMLEncoder struct{}
+
+	// MsgpackEncoder encodes data as application/msgpack
+	MsgpackEncoder struct{}
+
+	// xmlMap marshals a map as child elements, keys which are no valid element names are written as entry elements with a key attribute
+	xmlMap map[string]interface{}
+
+	// encoders holds all bound encoders, the default encoder is always the first one
+	encoders struct {
+		list []Encoder
+	}
+
+	acceptRange struct {
+		mediaType string
+		q         float64
+	}
+)
+
+const (
+	specificityNone = iota
+	specificityAny
+	specificityType
+	specificityExact
+)
+
+var (
+	_ Encoder = new(JSONEncoder)
+	_ Encoder = new(XMLEncoder)
+	_ Encoder = new(MsgpackEncoder)
+
+	_ xml.Marshaler = xmlMap{}
+
+	xmlName = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_.-]*$`)
+)
+
+// BindEncoder registers an Encoder for content negotiation of data responses
+func BindEncoder(injector *dingo.Injector, encoder Encoder) {
+	injector.BindMulti(new(Encoder)).To(encoder)
+}
+
+// MediaType of JSON
+func (*JSONEncoder) MediaType() string {
+	return "application/json"
+}
+
+// ContentType of JSON
+func (*JSONEncoder) ContentType() string {
+	return "application/json; charset=utf-8"
+}
+
+// Encode data as JSON
+func (*JSONEncoder) Encode(_ context.Context, w io.Writer, data interface{}) error {
+	return json.NewEncoder(w).Encode(data)
+}
+
+// MediaType of XML
+func (*XMLEncoder) MediaType() string {
+	return "application/xml"
+}
+
+// ContentType of XML
+func (*XMLEncoder) ContentType() string {
+	return "application/xml; charset=utf-8"
+}
+
+// Encode data as XML
+func (*XMLEncoder) Encode(_ context.Context, w io.Writer, data interface{}) error {
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+
+	encoder := xml.NewEncoder(w)
+	if m, ok := xmlValue(data).(xmlMap); ok {
+		return encoder.EncodeElement(m, xml.StartElement{Name: xml.Name{Local: "response"}})
+	}
+
+	return encoder.Encode(data)
+}
+
+// xmlValue converts maps with string keys, also within slices, so encoding/xml can marshal them
+func xmlValue(data interface{}) interface{} {
+	v := reflect.ValueOf(data)
+
+	switch v.Kind() {
+	case reflect.Map:
+		if v.Type().Key().Kind() != reflect.String {
+			return data
+		}
+
+		m := make(xmlMap, v.Len())
+		for iter := v.MapRange(); iter.Next(); {
+			m[iter.Key().String()] = xmlValue(iter.Value().Interface())
+		}
+		return m
+
+	case reflect.Slice, reflect.Array:
+		if kind := v.Type().Elem().Kind(); kind != reflect.Interface && kind != reflect.Map {
+			return data
+		}
+
+		list := make([]interface{}, v.Len())
+		for i := range list {
+			list[i] = xmlValue(v.Index(i).Interface())
+		}
+		return list
+	}
+
+	return data
+}
+
+// MarshalXML writes the entries sorted by key
+func (m xmlMap) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
+	if err := e.EncodeToken(start); err != nil {
+		return err
+	}
+
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		element := xml.StartElement{Name: xml.Name{Local: key}}
+		if !xmlName.MatchString(key) {
+			element = xml.StartElement{Name: xml.Name{Local: "entry"}, Attr: []xml.Attr{{Name: xml.Name{Local: "key"}, Value: key}}}
+		}
+
+		if err := e.EncodeElement(m[key], element); err != nil {
+			return err
+		}
+	}
+
+	return e.EncodeToken(start.End())
+}
+
+// MediaType of MessagePack
+func (*MsgpackEncoder) MediaType() string {
+	return "application/msgpack"
+}
+
+// ContentType of MessagePack
+func (*MsgpackEncoder) ContentType() string {
+	return "application/msgpack"
+}
+
+// Encode data as MessagePack, struct fields use their json tags
+func (*MsgpackEncoder) Encode(_ context.Context, w io.Writer, data interface{}) error {
+	encoder := msgpack.NewEncoder(w)
+	encoder.SetCustomStructTag("json")
+	return encoder.Encode(data)
+}
+
+// newEncoders orders the encoders so that the one for defaultMediaType is first, JSON is used if no encoder is given
+func newEncoders(list []Encoder, defaultMediaType string) *encoders {
+	if len(list) == 0 {
+		list = []Encoder{new(JSONEncoder)}
+	}
+
+	ordered := make([]Encoder, 0, len(list))
+	for _, encoder := range list {
+		if encoder.MediaType() == defaultMediaType {
+			ordered = append(ordered, encoder)
+		}
+	}
+	for _, encoder := range list {
+		if encoder.MediaType() != defaultMediaType {
+			ordered = append(ordered, encoder)
+		}
+	}
+
+	return &encoders{list: ordered}
+}
+
+// negotiate returns the best encoder for the Accept header, or nil if none is acceptable.
+// Ties and wildcard matches fall back to the default encoder, another encoder is only picked
+// if its media type is listed explicitly and ranked above the quality the default one gets, e.g. by */*.
+func (e *encoders) negotiate(accept string) Encoder {
+	if strings.TrimSpace(accept) == "" {
+		return e.list[0]
+	}
+
+	ranges := parseAccept(accept)
+
+	best := e.list[0]
+	bestQ, _ := acceptQuality(ranges, best.MediaType())
+
+	explicitOnly := bestQ > 0
+	if !explicitOnly {
+		best = nil
+	}
+
+	for _, encoder := range e.list[1:] {
+		q, specificity := acceptQuality(ranges, encoder.MediaType())
+		if explicitOnly && specificity != specificityExact {
+			continue
+		}
+		if q > bestQ {
+			best, bestQ = encoder, q
+		}
+	}
+
+	return best
+}
+
+func parseAccept(accept string) []acceptRange {
+	var ranges []acceptRange
+
+	for _, part := range strings.Split(accept, ",") {
+		mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil {
+			continue
+		}
+
+		q := 1.0
+		if value, ok := params["q"]; ok {
+			if parsed, err := strconv.ParseFloat(value, 64); err == nil {
+				q = parsed
+			}
+		}
+
+		ranges = append(ranges, acceptRange{mediaType: mediaType, q: q})
+	}
+
+	return ranges
+}
+
+// acceptQuality returns the quality and the specificity of the most specific range matching the media type
+func acceptQuality(ranges []acceptRange, mediaType string) (float64, int) {
+	mainType := strings.SplitN(mediaType, "/", 2)[0]
+
+	q, specificity := 0.0, specificityNone
+	for _, r := range ranges {
+		switch {
+		case r.mediaType == mediaType:
+			return r.q, specificityExact
+		case r.mediaType == mainType+"/*" && specificity < specificityType:
+			q, specificity = r.q, specificityType
+		case r.mediaType == "*/*" && specificity < specificityAny:
+			q, specificity = r.q, specificityAny
+		}
+	}
+
+	return q, specificity
+}
+
+func (e *encoders) mediaTypes() []string {
+	mediaTypes := make([]string, len(e.list))
+	for i, encoder := range e.list {
+		mediaTypes[i] = encoder.MediaType()
+	}
+	return mediaTypes
+}
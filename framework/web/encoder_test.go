@@ -0,0 +1,157 @@
+package web
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vmihailenco/msgpack/v5"
+)
+
+type encoderTestData struct {
+	Name string `json:"name" xml:"name"`
+}
+
+func TestEncodersNegotiate(t *testing.T) {
+	e := newEncoders([]Encoder{new(JSONEncoder), new(XMLEncoder)}, "application/xml")
+
+	tests := []struct {
+		accept    string
+		mediaType string
+	}{
+		{accept: "", mediaType: "application/xml"},
+		{accept: "*/*", mediaType: "application/xml"},
+		{accept: "application/json", mediaType: "application/json"},
+		{accept: "application/json;q=0.5, application/xml;q=0.9", mediaType: "application/xml"},
+		{accept: "application/*;q=0.2, application/json", mediaType: "application/json"},
+		{accept: "text/html, */*;q=0.1", mediaType: "application/xml"},
+		{accept: "application/xml;q=0, */*", mediaType: "application/json"},
+		{accept: "text/html", mediaType: ""},
+		{accept: "application/json, application/xml", mediaType: "application/xml"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.accept, func(t *testing.T) {
+			encoder := e.negotiate(tt.accept)
+			if tt.mediaType == "" {
+				assert.Nil(t, encoder)
+				return
+			}
+			require.NotNil(t, encoder)
+			assert.Equal(t, tt.mediaType, encoder.MediaType())
+		})
+	}
+}
+
+func TestEncodersNegotiateDefaultJSON(t *testing.T) {
+	e := newEncoders([]Encoder{new(XMLEncoder), new(JSONEncoder), new(MsgpackEncoder)}, "application/json")
+
+	tests := []struct {
+		accept    string
+		mediaType string
+	}{
+		{accept: "text/html,application/xhtml+xml,application/xml;q=0.9,image/avif,image/webp,*/*;q=0.8", mediaType: "application/xml"},
+		{accept: "application/xml, */*;q=0.1", mediaType: "application/xml"},
+		{accept: "application/json;q=0.5, application/xml", mediaType: "application/xml"},
+		{accept: "application/*;q=0.5, application/msgpack", mediaType: "application/msgpack"},
+		{accept: "application/*", mediaType: "application/json"},
+		{accept: "application/xml;q=0.8, */*;q=0.8", mediaType: "application/json"},
+		{accept: "application/*;q=0.9, application/json;q=0.5", mediaType: "application/json"},
+		{accept: "application/msgpack", mediaType: "application/msgpack"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.accept, func(t *testing.T) {
+			encoder := e.negotiate(tt.accept)
+			require.NotNil(t, encoder)
+			assert.Equal(t, tt.mediaType, encoder.MediaType())
+		})
+	}
+}
+
+func TestXMLEncoderMaps(t *testing.T) {
+	buf := new(bytes.Buffer)
+	require.NoError(t, new(XMLEncoder).Encode(context.Background(), buf, map[string]interface{}{
+		"code":  uint(404),
+		"error": "missing",
+		"items": []interface{}{map[string]string{"id": "1"}, "two"},
+		"a key": true,
+	}))
+
+	assert.Equal(t, xml.Header+`<response><entry key="a key">true</entry><code>404</code><error>missing</error><items><id>1</id></items><items>two</items></response>`, buf.String())
+}
+
+func TestMsgpackEncoder(t *testing.T) {
+	buf := new(bytes.Buffer)
+	require.NoError(t, new(MsgpackEncoder).Encode(context.Background(), buf, encoderTestData{Name: "flamingo"}))
+
+	var decoded map[string]interface{}
+	require.NoError(t, msgpack.Unmarshal(buf.Bytes(), &decoded))
+	assert.Equal(t, map[string]interface{}{"name": "flamingo"}, decoded)
+}
+
+func TestDataResponseNegotiation(t *testing.T) {
+	responder := &Responder{encoders: newEncoders([]Encoder{new(JSONEncoder), new(XMLEncoder)}, "application/json")}
+
+	apply := func(t *testing.T, result Result, accept string) *httptest.ResponseRecorder {
+		t.Helper()
+		httpRequest := httptest.NewRequest(http.MethodGet, "/", nil)
+		httpRequest.Header.Set("Accept", accept)
+		ctx := ContextWithRequest(context.Background(), CreateRequest(httpRequest, nil))
+
+		recorder := httptest.NewRecorder()
+		require.NoError(t, result.Apply(ctx, recorder))
+		return recorder
+	}
+
+	t.Run("json", func(t *testing.T) {
+		recorder := apply(t, responder.Data(encoderTestData{Name: "flamingo"}), "application/json")
+		assert.Equal(t, http.StatusOK, recorder.Code)
+		assert.Equal(t, "application/json; charset=utf-8", recorder.Header().Get("Content-Type"))
+		assert.Equal(t, "Accept", recorder.Header().Get("Vary"))
+		assert.JSONEq(t, `{"name":"flamingo"}`, recorder.Body.String())
+	})
+
+	t.Run("xml", func(t *testing.T) {
+		response := responder.Data(encoderTestData{Name: "flamingo"})
+		recorder := apply(t, response, "application/xml")
+		assert.Equal(t, http.StatusOK, recorder.Code)
+		assert.Equal(t, "application/xml; charset=utf-8", recorder.Header().Get("Content-Type"))
+		assert.Contains(t, recorder.Body.String(), "<encoderTestData><name>flamingo</name></encoderTestData>")
+	})
+
+	t.Run("xml error pages", func(t *testing.T) {
+		recorder := apply(t, responder.NotFound(errors.New("missing")), "application/xml")
+		assert.Equal(t, http.StatusNotFound, recorder.Code)
+		assert.Equal(t, "application/xml; charset=utf-8", recorder.Header().Get("Content-Type"))
+		assert.Contains(t, recorder.Body.String(), "<response><code>404</code><error>missing</error></response>")
+	})
+
+	t.Run("not acceptable", func(t *testing.T) {
+		recorder := apply(t, responder.Data(encoderTestData{Name: "flamingo"}), "text/csv")
+		assert.Equal(t, http.StatusNotAcceptable, recorder.Code)
+		assert.Contains(t, recorder.Body.String(), "application/json, application/xml")
+	})
+
+	t.Run("errors fall back to the default", func(t *testing.T) {
+		recorder := apply(t, responder.NotFound(errors.New("missing")), "text/csv")
+		assert.Equal(t, http.StatusNotFound, recorder.Code)
+		assert.Equal(t, "application/json; charset=utf-8", recorder.Header().Get("Content-Type"))
+	})
+
+	t.Run("media type is exposed", func(t *testing.T) {
+		httpRequest := httptest.NewRequest(http.MethodGet, "/", nil)
+		httpRequest.Header.Set("Accept", "application/xml")
+		ctx := ContextWithRequest(context.Background(), CreateRequest(httpRequest, nil))
+
+		assert.Equal(t, "application/xml", responder.Data(nil).MediaType(ctx))
+		assert.Equal(t, "", new(DataResponse).MediaType(ctx), "responses without encoders only support json")
+		assert.Equal(t, "application/json", new(DataResponse).MediaType(context.Background()))
+	})
+}
@@ -0,0 +1,337 @@
+package filter
+
+import (
+	"compress/gzip"
+	"context"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+
+	"flamingo.me/dingo"
+	"github.com/andybalholm/brotli"
+	"github.com/klauspost/compress/zstd"
+
+	"flamingo.me/flamingo/v3/framework/config"
+	"flamingo.me/flamingo/v3/framework/web"
+)
+
+type (
+	// CompressionModule adds a filter which compresses responses according to the Accept-Encoding header
+	CompressionModule struct{}
+
+	compressionFilter struct {
+		encodings    []string
+		contentTypes []string
+		minSize      int
+	}
+
+	compressor interface {
+		io.WriteCloser
+		Reset(w io.Writer)
+	}
+
+	compressionResponse struct {
+		result   web.Result
+		filter   *compressionFilter
+		encoding string
+	}
+
+	compressionResponseWriter struct {
+		rw       http.ResponseWriter
+		filter   *compressionFilter
+		encoding string
+
+		status      int
+		wroteHeader bool
+		decided     bool
+		compress    bool
+		buffer      []byte
+		compressor  compressor
+	}
+)
+
+var (
+	_ web.Filter = new(compressionFilter)
+	_ web.Result = new(compressionResponse)
+
+	compressors = map[string]*sync.Pool{
+		"gzip": {New: func() interface{} { return gzip.NewWriter(nil) }},
+		"br":   {New: func() interface{} { return brotli.NewWriter(nil) }},
+		"zstd": {New: func() interface{} {
+			encoder, _ := zstd.NewWriter(nil, zstd.WithEncoderConcurrency(1))
+			return encoder
+		}},
+	}
+)
+
+// Configure the Module
+func (m *CompressionModule) Configure(injector *dingo.Injector) {
+	injector.BindMulti((*web.Filter)(nil)).To(compressionFilter{})
+}
+
+// CueConfig defines the compression configuration
+func (m *CompressionModule) CueConfig() string {
+	return `
+flamingo: web: filter: compression: {
+	// encodings in order of preference
+	encodings: [...("br" | "zstd" | "gzip")] | *["br", "zstd", "gzip"]
+	// responses smaller than minSize bytes are sent uncompressed
+	minSize: int | *1024
+	// compressed content types, "type/*" matches all subtypes
+	contentTypes: [...string] | *[
+		"text/*",
+		"application/javascript",
+		"application/json",
+		"application/problem+json",
+		"application/xml",
+		"application/manifest+json",
+		"image/svg+xml",
+	]
+}
+`
+}
+
+// Inject dependencies
+func (f *compressionFilter) Inject(
+	cfg *struct {
+		Encodings    config.Slice `inject:"config:flamingo.web.filter.compression.encodings,optional"`
+		MinSize      float64      `inject:"config:flamingo.web.filter.compression.minSize,optional"`
+		ContentTypes config.Slice `inject:"config:flamingo.web.filter.compression.contentTypes,optional"`
+	},
+) *compressionFilter {
+	if cfg != nil {
+		_ = cfg.Encodings.MapInto(&f.encodings)
+		_ = cfg.ContentTypes.MapInto(&f.contentTypes)
+		f.minSize = int(cfg.MinSize)
+	}
+	return f
+}
+
+// Filter wraps the result to compress its body, streams and websockets are never compressed
+func (f *compressionFilter) Filter(ctx context.Context, r *web.Request, w http.ResponseWriter, chain *web.FilterChain) web.Result {
+	result := chain.Next(ctx, r, w)
+
+	switch result.(type) {
+	case nil, *web.StreamResponse, *web.SSEResponse, *web.WebSocketResponse:
+		return result
+	}
+
+	return &compressionResponse{
+		result:   result,
+		filter:   f,
+		encoding: f.negotiate(r.Request().Header.Get("Accept-Encoding")),
+	}
+}
+
+// negotiate the encoding, the client's quality wins over the configured order
+func (f *compressionFilter) negotiate(acceptEncoding string) string {
+	qualities := make(map[string]float64)
+	for _, part := range strings.Split(acceptEncoding, ",") {
+		coding, params, _ := strings.Cut(part, ";")
+		coding = strings.ToLower(strings.TrimSpace(coding))
+		if coding == "" {
+			continue
+		}
+
+		q := 1.0
+		if name, value, ok := strings.Cut(strings.TrimSpace(params), "="); ok && strings.TrimSpace(name) == "q" {
+			if parsed, err := strconv.ParseFloat(strings.TrimSpace(value), 64); err == nil {
+				q = parsed
+			}
+		}
+		qualities[coding] = q
+	}
+
+	best, bestQ := "", 0.0
+	for _, encoding := range f.encodings {
+		if _, ok := compressors[encoding]; !ok {
+			continue
+		}
+
+		q, ok := qualities[encoding]
+		if !ok {
+			q, ok = qualities["*"]
+		}
+		if ok && q > bestQ {
+			best, bestQ = encoding, q
+		}
+	}
+
+	return best
+}
+
+func (f *compressionFilter) compressible(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+
+	for _, candidate := range f.contentTypes {
+		candidate = strings.ToLower(candidate)
+		if candidate == mediaType || (strings.HasSuffix(candidate, "/*") && strings.HasPrefix(mediaType, strings.TrimSuffix(candidate, "*"))) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Apply the result through a compressing response writer
+func (r *compressionResponse) Apply(ctx context.Context, w http.ResponseWriter) error {
+	cw := &compressionResponseWriter{rw: w, filter: r.filter, encoding: r.encoding}
+	err := r.result.Apply(ctx, cw)
+	if closeErr := cw.Close(); err == nil {
+		err = closeErr
+	}
+
+	return err
+}
+
+// Header of the underlying response writer
+func (w *compressionResponseWriter) Header() http.Header {
+	return w.rw.Header()
+}
+
+// WriteHeader records the status, the header is sent once it is decided whether the body is compressed
+func (w *compressionResponseWriter) WriteHeader(status int) {
+	if w.wroteHeader || w.status != 0 {
+		return
+	}
+	w.status = status
+}
+
+// Write buffers the body until minSize is reached, then compresses it
+func (w *compressionResponseWriter) Write(p []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+
+	if !w.decided {
+		w.decide(p)
+	}
+
+	if !w.compress {
+		w.writeHeader()
+		return w.rw.Write(p)
+	}
+
+	if w.compressor == nil {
+		w.buffer = append(w.buffer, p...)
+		if len(w.buffer) < w.filter.minSize {
+			return len(p), nil
+		}
+		if err := w.startCompression(); err != nil {
+			return 0, err
+		}
+		return len(p), nil
+	}
+
+	return w.compressor.Write(p)
+}
+
+// Flush the compressor and the underlying response writer
+func (w *compressionResponseWriter) Flush() {
+	if w.compress && w.compressor == nil && w.status != 0 {
+		_ = w.startCompression()
+	}
+	if flusher, ok := w.compressor.(interface{ Flush() error }); ok {
+		_ = flusher.Flush()
+	}
+	_ = http.NewResponseController(w.rw).Flush()
+}
+
+// Unwrap returns the underlying response writer
+func (w *compressionResponseWriter) Unwrap() http.ResponseWriter {
+	return w.rw
+}
+
+// Close finishes the compressed body, or writes the buffered body uncompressed if it stayed below minSize
+func (w *compressionResponseWriter) Close() error {
+	if w.compressor != nil {
+		err := w.compressor.Close()
+		w.compressor.Reset(nil)
+		compressors[w.encoding].Put(w.compressor)
+		w.compressor = nil
+		return err
+	}
+
+	if w.status == 0 {
+		return nil
+	}
+
+	w.writeHeader()
+	if len(w.buffer) == 0 {
+		return nil
+	}
+
+	_, err := w.rw.Write(w.buffer)
+	w.buffer = nil
+
+	return err
+}
+
+// decide if the response is compressed, based on the header and the first chunk of the body
+func (w *compressionResponseWriter) decide(p []byte) {
+	w.decided = true
+
+	header := w.rw.Header()
+	switch {
+	case w.status < http.StatusOK, w.status == http.StatusNoContent, w.status == http.StatusNotModified, w.status == http.StatusPartialContent:
+		return
+	case header.Get("Content-Encoding") != "":
+		return
+	case strings.Contains(strings.ToLower(strings.Join(header.Values("Cache-Control"), ",")), "no-transform"):
+		return
+	}
+
+	contentType := header.Get("Content-Type")
+	if contentType == "" {
+		contentType = http.DetectContentType(p)
+		header.Set("Content-Type", contentType)
+	}
+	if !w.filter.compressible(contentType) {
+		return
+	}
+
+	header.Add("Vary", "Accept-Encoding")
+	if w.encoding == "" {
+		return
+	}
+
+	if contentLength, err := strconv.Atoi(header.Get("Content-Length")); err == nil && contentLength < w.filter.minSize {
+		return
+	}
+
+	w.compress = true
+}
+
+func (w *compressionResponseWriter) writeHeader() {
+	if w.wroteHeader {
+		return
+	}
+	w.wroteHeader = true
+	w.rw.WriteHeader(w.status)
+}
+
+func (w *compressionResponseWriter) startCompression() error {
+	header := w.rw.Header()
+	header.Set("Content-Encoding", w.encoding)
+	header.Del("Content-Length")
+	if etag := header.Get("ETag"); etag != "" && !strings.HasPrefix(etag, "W/") {
+		// the compressed body is a different representation
+		header.Set("ETag", "W/"+etag)
+	}
+	w.writeHeader()
+
+	w.compressor = compressors[w.encoding].Get().(compressor)
+	w.compressor.Reset(w.rw)
+
+	buffer := w.buffer
+	w.buffer = nil
+	_, err := w.compressor.Write(buffer)
+
+	return err
+}
@@ -0,0 +1,175 @@
+package filter
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/andybalholm/brotli"
+	"github.com/klauspost/compress/zstd"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"flamingo.me/flamingo/v3/framework/config"
+	"flamingo.me/flamingo/v3/framework/web"
+)
+
+func newCompressionFilter() *compressionFilter {
+	return new(compressionFilter).Inject(&struct {
+		Encodings    config.Slice `inject:"config:flamingo.web.filter.compression.encodings,optional"`
+		MinSize      float64      `inject:"config:flamingo.web.filter.compression.minSize,optional"`
+		ContentTypes config.Slice `inject:"config:flamingo.web.filter.compression.contentTypes,optional"`
+	}{
+		Encodings:    config.Slice{"br", "zstd", "gzip"},
+		MinSize:      64,
+		ContentTypes: config.Slice{"text/*", "application/json"},
+	})
+}
+
+func TestCompressionFilterNegotiate(t *testing.T) {
+	filter := newCompressionFilter()
+
+	tests := []struct {
+		acceptEncoding string
+		want           string
+	}{
+		{acceptEncoding: "", want: ""},
+		{acceptEncoding: "identity", want: ""},
+		{acceptEncoding: "gzip", want: "gzip"},
+		{acceptEncoding: "gzip, deflate, br, zstd", want: "br"},
+		{acceptEncoding: "gzip;q=1.0, br;q=0.5", want: "gzip"},
+		{acceptEncoding: "br;q=0, *", want: "zstd"},
+		{acceptEncoding: "GZIP", want: "gzip"},
+		{acceptEncoding: "deflate", want: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.acceptEncoding, func(t *testing.T) {
+			assert.Equal(t, tt.want, filter.negotiate(tt.acceptEncoding))
+		})
+	}
+}
+
+func TestCompressionFilter(t *testing.T) {
+	body := strings.Repeat("flamingo compresses text responses. ", 20)
+
+	handler := newTestHandler([]web.Filter{newCompressionFilter()}, func(registry *web.RouterRegistry) {
+		registry.MustRoute("/data", "data")
+		registry.HandleAny("data", func(ctx context.Context, r *web.Request) web.Result {
+			return new(web.Responder).Data(map[string]string{"text": body})
+		})
+
+		registry.MustRoute("/small", "small")
+		registry.HandleAny("small", func(ctx context.Context, r *web.Request) web.Result {
+			return &web.Response{Status: http.StatusOK, Header: http.Header{"Content-Type": {"text/plain"}}, Body: strings.NewReader("small")}
+		})
+
+		registry.MustRoute("/notransform", "notransform")
+		registry.HandleAny("notransform", func(ctx context.Context, r *web.Request) web.Result {
+			return &web.Response{
+				Status:         http.StatusOK,
+				Header:         http.Header{"Content-Type": {"text/plain"}},
+				Body:           strings.NewReader(body),
+				CacheDirective: &web.CacheDirective{NoTransform: true},
+			}
+		})
+
+		registry.MustRoute("/encoded", "encoded")
+		registry.HandleAny("encoded", func(ctx context.Context, r *web.Request) web.Result {
+			return &web.Response{Status: http.StatusOK, Header: http.Header{"Content-Type": {"text/plain"}, "Content-Encoding": {"gzip"}}, Body: strings.NewReader(body)}
+		})
+
+		registry.MustRoute("/image", "image")
+		registry.HandleAny("image", func(ctx context.Context, r *web.Request) web.Result {
+			return &web.Response{Status: http.StatusOK, Header: http.Header{"Content-Type": {"image/png"}}, Body: strings.NewReader(body)}
+		})
+
+		registry.MustRoute("/stream", "stream")
+		registry.HandleAny("stream", func(ctx context.Context, r *web.Request) web.Result {
+			return new(web.Responder).Stream(func(ctx context.Context, w web.StreamWriter) error {
+				_, err := io.WriteString(w, body)
+				return err
+			})
+		})
+	})
+
+	do := func(path, acceptEncoding string) *httptest.ResponseRecorder {
+		request := httptest.NewRequest(http.MethodGet, path, nil)
+		request.Header.Set("Accept-Encoding", acceptEncoding)
+		recorder := httptest.NewRecorder()
+		handler.ServeHTTP(recorder, request)
+		return recorder
+	}
+
+	decoders := map[string]func(io.Reader) (io.Reader, error){
+		"gzip": func(r io.Reader) (io.Reader, error) { return gzip.NewReader(r) },
+		"br":   func(r io.Reader) (io.Reader, error) { return brotli.NewReader(r), nil },
+		"zstd": func(r io.Reader) (io.Reader, error) {
+			decoder, err := zstd.NewReader(r)
+			return decoder, err
+		},
+	}
+
+	uncompressed := do("/data", "").Body.String()
+
+	for encoding, decoder := range decoders {
+		t.Run(encoding, func(t *testing.T) {
+			response := do("/data", encoding)
+
+			assert.Equal(t, http.StatusOK, response.Code)
+			assert.Equal(t, encoding, response.Header().Get("Content-Encoding"))
+			assert.Equal(t, "Accept-Encoding", response.Header().Get("Vary"))
+			assert.Empty(t, response.Header().Get("Content-Length"))
+			assert.Less(t, response.Body.Len(), len(uncompressed))
+
+			reader, err := decoder(bytes.NewReader(response.Body.Bytes()))
+			require.NoError(t, err)
+			decoded, err := io.ReadAll(reader)
+			require.NoError(t, err)
+			assert.Equal(t, uncompressed, string(decoded))
+		})
+	}
+
+	t.Run("not accepted", func(t *testing.T) {
+		response := do("/data", "")
+		assert.Empty(t, response.Header().Get("Content-Encoding"))
+		assert.Equal(t, "Accept-Encoding", response.Header().Get("Vary"))
+	})
+
+	t.Run("below min size", func(t *testing.T) {
+		response := do("/small", "gzip")
+		assert.Empty(t, response.Header().Get("Content-Encoding"))
+		assert.Equal(t, "Accept-Encoding", response.Header().Get("Vary"))
+		assert.Equal(t, "small", response.Body.String())
+	})
+
+	t.Run("no-transform", func(t *testing.T) {
+		response := do("/notransform", "gzip")
+		assert.Empty(t, response.Header().Get("Content-Encoding"))
+		assert.Equal(t, body, response.Body.String())
+	})
+
+	t.Run("already encoded", func(t *testing.T) {
+		response := do("/encoded", "br")
+		assert.Equal(t, "gzip", response.Header().Get("Content-Encoding"))
+		assert.Equal(t, body, response.Body.String())
+	})
+
+	t.Run("content type", func(t *testing.T) {
+		response := do("/image", "gzip")
+		assert.Empty(t, response.Header().Get("Content-Encoding"))
+		assert.Empty(t, response.Header().Get("Vary"))
+		assert.Equal(t, body, response.Body.String())
+	})
+
+	t.Run("stream", func(t *testing.T) {
+		response := do("/stream", "gzip")
+		assert.Empty(t, response.Header().Get("Content-Encoding"))
+		assert.Equal(t, body, response.Body.String())
+	})
+}
@@ -0,0 +1,266 @@
+package filter
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"net/http"
+	"strings"
+	"time"
+
+	"flamingo.me/dingo"
+
+	"flamingo.me/flamingo/v3/framework/web"
+)
+
+type (
+	// ConditionalRequestsModule adds a filter which answers conditional requests with 304 Not Modified and
+	// checks If-Match preconditions of PUT and DELETE requests
+	ConditionalRequestsModule struct{}
+
+	// ETagProvider returns the ETag of the current representation of a resource, which is checked against If-Match.
+	// Providers are registered with a map binding for the handler name of the route:
+	// injector.BindMap(new(filter.ETagProvider), "order.update").To(orderETagProvider{})
+	ETagProvider interface {
+		// ETag returns false if there is no current representation
+		ETag(ctx context.Context, r *web.Request) (etag string, ok bool)
+	}
+
+	conditionalRequestsFilter struct {
+		generateETags bool
+		weakETags     bool
+		etagProviders map[string]ETagProvider
+	}
+
+	conditionalResponse struct {
+		result web.Result
+		filter *conditionalRequestsFilter
+		req    *web.Request
+	}
+
+	bufferedResponseWriter struct {
+		header http.Header
+		status int
+		body   bytes.Buffer
+	}
+)
+
+var (
+	_ web.Filter = new(conditionalRequestsFilter)
+	_ web.Result = new(conditionalResponse)
+)
+
+// Configure the Module
+func (m *ConditionalRequestsModule) Configure(injector *dingo.Injector) {
+	injector.BindMulti((*web.Filter)(nil)).To(conditionalRequestsFilter{})
+}
+
+// CueConfig defines the conditional requests configuration
+func (m *ConditionalRequestsModule) CueConfig() string {
+	return `
+flamingo: web: filter: conditional: {
+	generateETags: bool | *true
+	weakETags: bool | *false
+}
+`
+}
+
+// Inject dependencies
+func (f *conditionalRequestsFilter) Inject(
+	cfg *struct {
+		GenerateETags bool                    `inject:"config:flamingo.web.filter.conditional.generateETags,optional"`
+		WeakETags     bool                    `inject:"config:flamingo.web.filter.conditional.weakETags,optional"`
+		ETagProviders map[string]ETagProvider `inject:",optional"`
+	},
+) *conditionalRequestsFilter {
+	if cfg != nil {
+		f.generateETags = cfg.GenerateETags
+		f.weakETags = cfg.WeakETags
+		f.etagProviders = cfg.ETagProviders
+	}
+	return f
+}
+
+// Filter checks preconditions of modifying requests and wraps responses of safe requests to answer 304 Not Modified
+func (f *conditionalRequestsFilter) Filter(ctx context.Context, r *web.Request, w http.ResponseWriter, chain *web.FilterChain) web.Result {
+	switch r.Request().Method {
+	case http.MethodPut, http.MethodDelete:
+		if ifMatch := r.Request().Header.Get("If-Match"); ifMatch != "" && !f.preconditionHolds(ctx, r, ifMatch) {
+			return &web.Response{Status: http.StatusPreconditionFailed, Header: make(http.Header)}
+		}
+		return chain.Next(ctx, r, w)
+	case http.MethodGet, http.MethodHead:
+		result := chain.Next(ctx, r, w)
+		switch result.(type) {
+		case *web.RenderResponse, *web.DataResponse:
+			return &conditionalResponse{result: result, filter: f, req: r}
+		}
+		return result
+	}
+
+	return chain.Next(ctx, r, w)
+}
+
+// preconditionHolds compares If-Match with the ETag returned by the ETagProvider of the route.
+// Routes without provider can not be checked and are let through.
+func (f *conditionalRequestsFilter) preconditionHolds(ctx context.Context, r *web.Request, ifMatch string) bool {
+	if r.Handler == nil {
+		return true
+	}
+
+	provider, ok := f.etagProviders[r.Handler.GetHandlerName()]
+	if !ok {
+		return true
+	}
+
+	etag, ok := provider.ETag(ctx, r)
+	if !ok {
+		// there is no current representation
+		return false
+	}
+
+	if strings.TrimSpace(ifMatch) == "*" {
+		return true
+	}
+
+	return etag != "" && matchETag(ifMatch, quoteETag(etag), false)
+}
+
+func (f *conditionalRequestsFilter) etag(buffered *bufferedResponseWriter) string {
+	if etag := buffered.header.Get("ETag"); etag != "" {
+		return quoteETag(etag)
+	}
+
+	if !f.generateETags {
+		return ""
+	}
+
+	sum := sha256.Sum256(buffered.body.Bytes())
+	etag := `"` + base64.RawURLEncoding.EncodeToString(sum[:16]) + `"`
+	if f.weakETags {
+		etag = "W/" + etag
+	}
+
+	return etag
+}
+
+// Apply the response, or answer 304 if the client's validators match
+func (r *conditionalResponse) Apply(ctx context.Context, w http.ResponseWriter) error {
+	buffered := newBufferedResponseWriter()
+	if err := r.result.Apply(ctx, buffered); err != nil {
+		return err
+	}
+
+	if buffered.status == http.StatusOK {
+		if etag := r.filter.etag(buffered); etag != "" {
+			buffered.header.Set("ETag", etag)
+		}
+
+		if notModified(r.req.Request(), buffered.header) {
+			copyHeader(w.Header(), buffered.header, "Cache-Control", "Content-Location", "Date", "ETag", "Expires", "Last-Modified", "Vary")
+			w.WriteHeader(http.StatusNotModified)
+			return nil
+		}
+	}
+
+	copyHeader(w.Header(), buffered.header)
+	w.WriteHeader(buffered.status)
+	_, err := w.Write(buffered.body.Bytes())
+
+	return err
+}
+
+// notModified evaluates If-None-Match, or If-Modified-Since if no If-None-Match is sent
+func notModified(req *http.Request, header http.Header) bool {
+	if ifNoneMatch := req.Header.Get("If-None-Match"); ifNoneMatch != "" {
+		etag := header.Get("ETag")
+		return etag != "" && matchETag(ifNoneMatch, etag, true)
+	}
+
+	ifModifiedSince, err := http.ParseTime(req.Header.Get("If-Modified-Since"))
+	if err != nil {
+		return false
+	}
+
+	lastModified, err := parseLastModified(header.Get("Last-Modified"))
+	if err != nil {
+		return false
+	}
+
+	return !lastModified.Truncate(time.Second).After(ifModifiedSince)
+}
+
+// parseLastModified also accepts the RFC1123 format with UTC zone, as written by web.CacheDirective
+func parseLastModified(value string) (time.Time, error) {
+	if t, err := http.ParseTime(value); err == nil {
+		return t, nil
+	}
+	return time.Parse(time.RFC1123, value)
+}
+
+// matchETag checks if the etag is part of the list, weak comparison ignores the W/ prefix
+func matchETag(list, etag string, weak bool) bool {
+	if strings.TrimSpace(list) == "*" {
+		return true
+	}
+
+	if !weak && strings.HasPrefix(etag, "W/") {
+		return false
+	}
+
+	etag = strings.TrimPrefix(etag, "W/")
+	for _, candidate := range strings.Split(list, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if !weak && strings.HasPrefix(candidate, "W/") {
+			continue
+		}
+		if strings.TrimPrefix(candidate, "W/") == etag {
+			return true
+		}
+	}
+
+	return false
+}
+
+// quoteETag makes sure ETags set without quotes, e.g. by CacheDirective, are valid entity tags
+func quoteETag(etag string) string {
+	if strings.HasSuffix(etag, `"`) {
+		return etag
+	}
+	return `"` + etag + `"`
+}
+
+func copyHeader(dst, src http.Header, keys ...string) {
+	if len(keys) == 0 {
+		for key, values := range src {
+			dst[key] = append(dst[key], values...)
+		}
+		return
+	}
+
+	for _, key := range keys {
+		if values := src.Values(key); len(values) > 0 {
+			dst[http.CanonicalHeaderKey(key)] = append(dst[http.CanonicalHeaderKey(key)], values...)
+		}
+	}
+}
+
+func newBufferedResponseWriter() *bufferedResponseWriter {
+	return &bufferedResponseWriter{header: make(http.Header), status: http.StatusOK}
+}
+
+// Header of the buffered response
+func (b *bufferedResponseWriter) Header() http.Header {
+	return b.header
+}
+
+// Write to the buffer
+func (b *bufferedResponseWriter) Write(p []byte) (int, error) {
+	return b.body.Write(p)
+}
+
+// WriteHeader records the status
+func (b *bufferedResponseWriter) WriteHeader(status int) {
+	b.status = status
+}
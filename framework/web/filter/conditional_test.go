@@ -0,0 +1,193 @@
+package filter
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"flamingo.me/flamingo/v3/framework/config"
+	"flamingo.me/flamingo/v3/framework/flamingo"
+	"flamingo.me/flamingo/v3/framework/web"
+)
+
+type (
+	routesFunc func(registry *web.RouterRegistry)
+
+	etagProviderFunc func(ctx context.Context, r *web.Request) (string, bool)
+)
+
+func (f routesFunc) Routes(registry *web.RouterRegistry) {
+	f(registry)
+}
+
+func (f etagProviderFunc) ETag(ctx context.Context, r *web.Request) (string, bool) {
+	return f(ctx, r)
+}
+
+func newTestHandler(filters []web.Filter, routes routesFunc) http.Handler {
+	router := new(web.Router)
+	router.Inject(
+		&struct {
+			Scheme           string       `inject:"config:flamingo.router.scheme,optional"`
+			Host             string       `inject:"config:flamingo.router.host,optional"`
+			Path             string       `inject:"config:flamingo.router.path,optional"`
+			External         string       `inject:"config:flamingo.router.external,optional"`
+			SessionName      string       `inject:"config:flamingo.session.name,optional"`
+			MethodNotAllowed bool         `inject:"config:flamingo.router.methodNotAllowed,optional"`
+			AutoOptions      bool         `inject:"config:flamingo.router.autoOptions,optional"`
+			AutoHead         bool         `inject:"config:flamingo.router.autoHead,optional"`
+			TrustedProxies   config.Slice `inject:"config:flamingo.router.trustedProxies,optional"`
+			ForwardedHeaders string       `inject:"config:flamingo.router.forwardedHeaders,optional"`
+		}{AutoHead: true},
+		nil,
+		new(flamingo.DefaultEventRouter),
+		func() []web.Filter { return filters },
+		func() []web.RoutesModule { return []web.RoutesModule{routes} },
+		flamingo.NullLogger{},
+		new(config.Area),
+		func() *web.Responder { return new(web.Responder) },
+	)
+
+	return router.Handler()
+}
+
+func TestConditionalRequestsFilter(t *testing.T) {
+	version := "1"
+	deleted := false
+	lastModified := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	routes := routesFunc(func(registry *web.RouterRegistry) {
+		registry.MustRoute("/order", "order")
+		registry.HandleGet("order", func(ctx context.Context, r *web.Request) web.Result {
+			response := new(web.Responder).Data(map[string]string{"version": version})
+			response.CacheDirective = &web.CacheDirective{ETag: "order-" + version}
+			return response
+		})
+		registry.HandlePut("order", func(ctx context.Context, r *web.Request) web.Result {
+			version = "2"
+			return &web.Response{Status: http.StatusNoContent}
+		})
+		registry.HandleDelete("order", func(ctx context.Context, r *web.Request) web.Result {
+			deleted = true
+			return &web.Response{Status: http.StatusNoContent}
+		})
+
+		registry.MustRoute("/dated", "dated")
+		registry.HandleGet("dated", func(ctx context.Context, r *web.Request) web.Result {
+			response := new(web.Responder).Data("dated")
+			response.CacheDirective = &web.CacheDirective{LastModifiedSince: &lastModified, ETag: "v1"}
+			return response
+		})
+	})
+
+	filter := new(conditionalRequestsFilter).Inject(&struct {
+		GenerateETags bool                    `inject:"config:flamingo.web.filter.conditional.generateETags,optional"`
+		WeakETags     bool                    `inject:"config:flamingo.web.filter.conditional.weakETags,optional"`
+		ETagProviders map[string]ETagProvider `inject:",optional"`
+	}{
+		GenerateETags: true,
+		ETagProviders: map[string]ETagProvider{
+			"order": etagProviderFunc(func(ctx context.Context, r *web.Request) (string, bool) {
+				return "order-" + version, !deleted
+			}),
+		},
+	})
+	handler := newTestHandler([]web.Filter{filter}, routes)
+
+	do := func(method, path string, header http.Header) *httptest.ResponseRecorder {
+		request := httptest.NewRequest(method, path, nil)
+		for key, values := range header {
+			request.Header[key] = values
+		}
+		recorder := httptest.NewRecorder()
+		handler.ServeHTTP(recorder, request)
+		return recorder
+	}
+
+	response := do(http.MethodGet, "/order", nil)
+	require.Equal(t, http.StatusOK, response.Code)
+	etag := response.Header().Get("ETag")
+	require.NotEmpty(t, etag)
+	assert.Equal(t, `"order-1"`, etag)
+
+	t.Run("if-none-match", func(t *testing.T) {
+		response := do(http.MethodGet, "/order", http.Header{"If-None-Match": {`"other", ` + etag}})
+		assert.Equal(t, http.StatusNotModified, response.Code)
+		assert.Empty(t, response.Body.String())
+		assert.Equal(t, etag, response.Header().Get("ETag"))
+
+		response = do(http.MethodGet, "/order", http.Header{"If-None-Match": {`"other"`}})
+		assert.Equal(t, http.StatusOK, response.Code)
+		assert.NotEmpty(t, response.Body.String())
+
+		response = do(http.MethodHead, "/order", http.Header{"If-None-Match": {"W/" + etag}})
+		assert.Equal(t, http.StatusNotModified, response.Code, "If-None-Match uses the weak comparison")
+	})
+
+	t.Run("if-modified-since", func(t *testing.T) {
+		response := do(http.MethodGet, "/dated", http.Header{"If-Modified-Since": {lastModified.Format(http.TimeFormat)}})
+		assert.Equal(t, http.StatusNotModified, response.Code)
+		assert.Equal(t, `"v1"`, response.Header().Get("ETag"), "etags from the cache directive are kept")
+
+		response = do(http.MethodGet, "/dated", http.Header{"If-Modified-Since": {lastModified.Add(-time.Hour).Format(http.TimeFormat)}})
+		assert.Equal(t, http.StatusOK, response.Code)
+
+		response = do(http.MethodGet, "/dated", http.Header{"If-None-Match": {`"v0"`}, "If-Modified-Since": {lastModified.Format(http.TimeFormat)}})
+		assert.Equal(t, http.StatusOK, response.Code, "If-None-Match takes precedence")
+	})
+
+	t.Run("if-match", func(t *testing.T) {
+		response := do(http.MethodDelete, "/order", http.Header{"If-Match": {`"outdated"`}})
+		assert.Equal(t, http.StatusPreconditionFailed, response.Code)
+
+		response = do(http.MethodPut, "/order", http.Header{"If-Match": {etag}})
+		assert.Equal(t, http.StatusNoContent, response.Code)
+
+		response = do(http.MethodPut, "/order", http.Header{"If-Match": {etag}})
+		assert.Equal(t, http.StatusPreconditionFailed, response.Code, "the order changed with the first update")
+
+		response = do(http.MethodDelete, "/order", http.Header{"If-Match": {"*"}})
+		assert.Equal(t, http.StatusNoContent, response.Code)
+
+		response = do(http.MethodDelete, "/order", http.Header{"If-Match": {"*"}})
+		assert.Equal(t, http.StatusPreconditionFailed, response.Code, "the order does not exist anymore")
+	})
+}
+
+func TestConditionalRequestsFilterWeakETags(t *testing.T) {
+	filter := new(conditionalRequestsFilter).Inject(&struct {
+		GenerateETags bool                    `inject:"config:flamingo.web.filter.conditional.generateETags,optional"`
+		WeakETags     bool                    `inject:"config:flamingo.web.filter.conditional.weakETags,optional"`
+		ETagProviders map[string]ETagProvider `inject:",optional"`
+	}{GenerateETags: true, WeakETags: true})
+
+	handler := newTestHandler([]web.Filter{filter}, func(registry *web.RouterRegistry) {
+		registry.MustRoute("/page", "page")
+		registry.HandleAny("page", func(ctx context.Context, r *web.Request) web.Result {
+			return new(web.Responder).Render("page", "content")
+		})
+	})
+
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/page", nil))
+	etag := recorder.Header().Get("ETag")
+	assert.True(t, strings.HasPrefix(etag, `W/"`))
+
+	request := httptest.NewRequest(http.MethodPut, "/page", nil)
+	request.Header.Set("If-Match", etag)
+	recorder = httptest.NewRecorder()
+	handler.ServeHTTP(recorder, request)
+	assert.Equal(t, http.StatusOK, recorder.Code, "routes without etag provider are not checked")
+
+	request = httptest.NewRequest(http.MethodGet, "/page", nil)
+	request.Header.Set("If-None-Match", etag)
+	recorder = httptest.NewRecorder()
+	handler.ServeHTTP(recorder, request)
+	assert.Equal(t, http.StatusNotModified, recorder.Code)
+}
@@ -0,0 +1,287 @@
+package filter
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"path"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"flamingo.me/dingo"
+
+	"flamingo.me/flamingo/v3/framework/config"
+	"flamingo.me/flamingo/v3/framework/flamingo"
+	"flamingo.me/flamingo/v3/framework/web"
+)
+
+type (
+	// CORSModule adds a filter which handles cross-origin requests and answers preflight requests
+	CORSModule struct{}
+
+	// corsPolicy is the configuration of allowed cross-origin requests
+	corsPolicy struct {
+		AllowedOrigins      []string `json:"allowedOrigins"`
+		AllowedOriginsRegex []string `json:"allowedOriginsRegex"`
+		AllowedMethods      []string `json:"allowedMethods"`
+		AllowedHeaders      []string `json:"allowedHeaders"`
+		ExposedHeaders      []string `json:"exposedHeaders"`
+		AllowCredentials    bool     `json:"allowCredentials"`
+		MaxAge              int      `json:"maxAge"`
+
+		originsRegex []*regexp.Regexp
+	}
+
+	corsFilter struct {
+		defaults *corsPolicy
+		routes   map[string]*corsPolicy
+		logger   flamingo.Logger
+	}
+)
+
+var (
+	_ web.Filter = new(corsFilter)
+
+	errCORSAnyOriginWithCredentials = errors.New(`allowed origin "*" can not be combined with allowCredentials`)
+)
+
+// Configure the Module
+func (m *CORSModule) Configure(injector *dingo.Injector) {
+	injector.BindMulti((*web.Filter)(nil)).To(corsFilter{})
+}
+
+// CueConfig defines the cors configuration
+func (m *CORSModule) CueConfig() string {
+	return `
+flamingo: web: cors: {
+	// exact origins, "*" allows all origins (not with allowCredentials), "*" within an origin matches within the host, e.g. "https://*.example.com"
+	allowedOrigins: [...string] | *[]
+	// regular expressions matching the whole origin
+	allowedOriginsRegex: [...string] | *[]
+	allowedMethods: [...string] | *["GET", "HEAD", "POST"]
+	// "*" allows all requested headers
+	allowedHeaders: [...string] | *["Accept", "Accept-Language", "Content-Language", "Content-Type"]
+	exposedHeaders: [...string] | *[]
+	allowCredentials: bool | *false
+	// seconds preflight responses may be cached, 0 omits the header
+	maxAge: int | *0
+
+	// policies per handler name, unset fields are taken from the default policy
+	routes: [string]: {
+		allowedOrigins?: [...string]
+		allowedOriginsRegex?: [...string]
+		allowedMethods?: [...string]
+		allowedHeaders?: [...string]
+		exposedHeaders?: [...string]
+		allowCredentials?: bool
+		maxAge?: int
+	}
+}
+`
+}
+
+// Inject dependencies
+func (f *corsFilter) Inject(
+	logger flamingo.Logger,
+	cfg *struct {
+		Config config.Map `inject:"config:flamingo.web.cors,optional"`
+	},
+) *corsFilter {
+	f.logger = logger.WithField(flamingo.LogKeyModule, "web").WithField(flamingo.LogKeyCategory, "cors")
+	f.defaults = new(corsPolicy)
+	f.routes = make(map[string]*corsPolicy)
+
+	if cfg == nil {
+		return f
+	}
+
+	routes, _ := cfg.Config["routes"].(config.Map)
+	defaults := make(config.Map, len(cfg.Config))
+	for key, value := range cfg.Config {
+		if key != "routes" {
+			defaults[key] = value
+		}
+	}
+
+	if err := f.defaults.load(defaults); err != nil {
+		f.logger.Error("invalid cors policy: ", err)
+		f.defaults = new(corsPolicy)
+	}
+
+	for handler, route := range routes {
+		routeConfig, ok := route.(config.Map)
+		if !ok {
+			continue
+		}
+
+		merged := make(config.Map, len(defaults)+len(routeConfig))
+		for key, value := range defaults {
+			merged[key] = value
+		}
+		for key, value := range routeConfig {
+			merged[key] = value
+		}
+
+		policy := new(corsPolicy)
+		if err := policy.load(merged); err != nil {
+			f.logger.Error("invalid cors policy for ", handler, ": ", err)
+			continue
+		}
+		f.routes[handler] = policy
+	}
+
+	return f
+}
+
+// load the policy and compile the origin expressions
+func (p *corsPolicy) load(cfg config.Map) error {
+	if err := cfg.MapInto(p); err != nil {
+		return err
+	}
+
+	if p.AllowCredentials && slices.Contains(p.AllowedOrigins, "*") {
+		return errCORSAnyOriginWithCredentials
+	}
+
+	p.originsRegex = make([]*regexp.Regexp, 0, len(p.AllowedOriginsRegex))
+	for _, expression := range p.AllowedOriginsRegex {
+		// the whole origin must match, so an expression for "https://shop.example" does not allow "https://shop.example.evil"
+		re, err := regexp.Compile("^(?:" + expression + ")$")
+		if err != nil {
+			return err
+		}
+		p.originsRegex = append(p.originsRegex, re)
+	}
+
+	return nil
+}
+
+// Filter adds the CORS headers to cross-origin requests and answers preflight requests without calling the action
+func (f *corsFilter) Filter(ctx context.Context, r *web.Request, w http.ResponseWriter, chain *web.FilterChain) web.Result {
+	policy := f.defaults
+	if r.Handler != nil {
+		if routePolicy, ok := f.routes[r.Handler.GetHandlerName()]; ok {
+			policy = routePolicy
+		}
+	}
+
+	origin := r.Request().Header.Get("Origin")
+	requestMethod := r.Request().Header.Get("Access-Control-Request-Method")
+	if origin != "" && r.Request().Method == http.MethodOptions && requestMethod != "" {
+		return policy.preflight(r.Request(), origin, requestMethod)
+	}
+
+	// responses depend on the origin unless every origin gets "*", also without Origin header so caches don't mix them up
+	header := w.Header()
+	if !policy.anyOrigin() {
+		header.Add("Vary", "Origin")
+	}
+
+	if origin == "" || !policy.allowsOrigin(origin) {
+		return chain.Next(ctx, r, w)
+	}
+
+	header.Set("Access-Control-Allow-Origin", policy.allowOrigin(origin))
+	if policy.AllowCredentials {
+		header.Set("Access-Control-Allow-Credentials", "true")
+	}
+	if len(policy.ExposedHeaders) > 0 {
+		header.Set("Access-Control-Expose-Headers", strings.Join(policy.ExposedHeaders, ", "))
+	}
+
+	return chain.Next(ctx, r, w)
+}
+
+// preflight answers a preflight request, disallowed requests are answered without CORS headers so the browser rejects them
+func (p *corsPolicy) preflight(req *http.Request, origin, requestMethod string) web.Result {
+	response := &web.Response{
+		Status: http.StatusNoContent,
+		Header: http.Header{"Vary": {"Origin", "Access-Control-Request-Method", "Access-Control-Request-Headers"}},
+	}
+
+	requestHeaders := req.Header.Get("Access-Control-Request-Headers")
+	if !p.allowsOrigin(origin) || !p.allowsMethod(requestMethod) || !p.allowsHeaders(requestHeaders) {
+		return response
+	}
+
+	response.Header.Set("Access-Control-Allow-Origin", p.allowOrigin(origin))
+	response.Header.Set("Access-Control-Allow-Methods", strings.Join(p.AllowedMethods, ", "))
+	if requestHeaders != "" {
+		response.Header.Set("Access-Control-Allow-Headers", requestHeaders)
+	}
+	if p.AllowCredentials {
+		response.Header.Set("Access-Control-Allow-Credentials", "true")
+	}
+	if p.MaxAge > 0 {
+		response.Header.Set("Access-Control-Max-Age", strconv.Itoa(p.MaxAge))
+	}
+
+	return response
+}
+
+// allowOrigin is the value of Access-Control-Allow-Origin, credentials require the explicit origin
+func (p *corsPolicy) allowOrigin(origin string) string {
+	if p.anyOrigin() {
+		return "*"
+	}
+	return origin
+}
+
+// anyOrigin reports whether all origins are answered with "*"
+func (p *corsPolicy) anyOrigin() bool {
+	return !p.AllowCredentials && len(p.AllowedOrigins) == 1 && p.AllowedOrigins[0] == "*"
+}
+
+func (p *corsPolicy) allowsOrigin(origin string) bool {
+	origin = strings.ToLower(origin)
+	for _, allowed := range p.AllowedOrigins {
+		if allowed == "*" {
+			return true
+		}
+		if matched, _ := path.Match(strings.ToLower(allowed), origin); matched {
+			return true
+		}
+	}
+
+	for _, re := range p.originsRegex {
+		if re.MatchString(origin) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (p *corsPolicy) allowsMethod(method string) bool {
+	for _, allowed := range p.AllowedMethods {
+		if strings.EqualFold(allowed, method) {
+			return true
+		}
+	}
+	return false
+}
+
+// allowsHeaders checks the comma separated list of requested headers
+func (p *corsPolicy) allowsHeaders(requestHeaders string) bool {
+	for _, requested := range strings.Split(requestHeaders, ",") {
+		requested = strings.TrimSpace(requested)
+		if requested == "" {
+			continue
+		}
+
+		allowed := false
+		for _, header := range p.AllowedHeaders {
+			if header == "*" || strings.EqualFold(header, requested) {
+				allowed = true
+				break
+			}
+		}
+		if !allowed {
+			return false
+		}
+	}
+
+	return true
+}
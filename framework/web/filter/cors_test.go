@@ -0,0 +1,173 @@
+package filter
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"flamingo.me/flamingo/v3/framework/config"
+	"flamingo.me/flamingo/v3/framework/flamingo"
+	"flamingo.me/flamingo/v3/framework/web"
+)
+
+func newCORSFilter(cfg config.Map) *corsFilter {
+	return new(corsFilter).Inject(flamingo.NullLogger{}, &struct {
+		Config config.Map `inject:"config:flamingo.web.cors,optional"`
+	}{Config: cfg})
+}
+
+func TestCORSModule(t *testing.T) {
+	if err := config.TryModules(nil, new(CORSModule)); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestCORSFilter(t *testing.T) {
+	filter := newCORSFilter(config.Map{
+		"allowedOrigins":      config.Slice{"https://shop.example.com", "https://*.partner.example"},
+		"allowedOriginsRegex": config.Slice{`https://[a-z]+\.preview\.example`},
+		"allowedMethods":      config.Slice{"GET", "POST"},
+		"allowedHeaders":      config.Slice{"Content-Type", "X-Requested-With"},
+		"exposedHeaders":      config.Slice{"X-Total"},
+		"allowCredentials":    false,
+		"maxAge":              600.0,
+		"routes": config.Map{
+			"public.api": config.Map{
+				"allowedOrigins":   config.Slice{"*"},
+				"allowedHeaders":   config.Slice{"*"},
+				"allowedMethods":   config.Slice{"GET", "PUT"},
+				"allowCredentials": false,
+			},
+			"account.api": config.Map{
+				"allowCredentials": true,
+			},
+			"insecure.api": config.Map{
+				"allowedOrigins":   config.Slice{"*"},
+				"allowCredentials": true,
+			},
+		},
+	})
+
+	run := func(method, origin string, handler string, header http.Header) (web.Result, http.Header, bool) {
+		httpRequest := httptest.NewRequest(method, "/api", nil)
+		for name, values := range header {
+			httpRequest.Header[name] = values
+		}
+		if origin != "" {
+			httpRequest.Header.Set("Origin", origin)
+		}
+
+		req := web.CreateRequest(httpRequest, nil)
+		if handler != "" {
+			req.Handler = web.NewRegistry().MustRoute("/api", handler)
+		}
+
+		called := false
+		recorder := httptest.NewRecorder()
+		result := filter.Filter(context.Background(), req, recorder, web.NewFilterChain(func(context.Context, *web.Request, http.ResponseWriter) web.Result {
+			called = true
+			return &web.Response{Status: http.StatusOK}
+		}))
+
+		if response, ok := result.(*web.Response); ok && !called {
+			return result, response.Header, called
+		}
+		return result, recorder.Header(), called
+	}
+
+	t.Run("same origin requests only vary by origin", func(t *testing.T) {
+		_, header, called := run(http.MethodGet, "", "", nil)
+		assert.True(t, called)
+		assert.Equal(t, http.Header{"Vary": {"Origin"}}, header)
+
+		_, header, called = run(http.MethodGet, "", "public.api", nil)
+		assert.True(t, called)
+		assert.Empty(t, header, "responses with * do not vary by origin")
+	})
+
+	for _, origin := range []string{"https://shop.example.com", "https://b2b.partner.example", "https://feature.preview.example"} {
+		t.Run("allowed origin "+origin, func(t *testing.T) {
+			_, header, called := run(http.MethodGet, origin, "", nil)
+			assert.True(t, called)
+			assert.Equal(t, origin, header.Get("Access-Control-Allow-Origin"))
+			assert.Equal(t, "X-Total", header.Get("Access-Control-Expose-Headers"))
+			assert.Empty(t, header.Get("Access-Control-Allow-Credentials"))
+			assert.Equal(t, []string{"Origin"}, header.Values("Vary"))
+		})
+	}
+
+	for _, origin := range []string{"https://evil.example", "https://feature.preview.example.evil", "https://evil.example/https://feature.preview.example"} {
+		t.Run("foreign origin "+origin, func(t *testing.T) {
+			_, header, called := run(http.MethodGet, origin, "", nil)
+			assert.True(t, called)
+			assert.Empty(t, header.Get("Access-Control-Allow-Origin"), "expressions match the whole origin")
+		})
+	}
+
+	t.Run("preflight", func(t *testing.T) {
+		result, header, called := run(http.MethodOptions, "https://shop.example.com", "", http.Header{
+			"Access-Control-Request-Method":  {"POST"},
+			"Access-Control-Request-Headers": {"content-type"},
+		})
+		assert.False(t, called)
+		assert.Equal(t, uint(http.StatusNoContent), result.(*web.Response).Status)
+		assert.Equal(t, "https://shop.example.com", header.Get("Access-Control-Allow-Origin"))
+		assert.Equal(t, "GET, POST", header.Get("Access-Control-Allow-Methods"))
+		assert.Equal(t, "content-type", header.Get("Access-Control-Allow-Headers"))
+		assert.Equal(t, "600", header.Get("Access-Control-Max-Age"))
+	})
+
+	t.Run("preflight with disallowed method or header", func(t *testing.T) {
+		for _, requestHeader := range []http.Header{
+			{"Access-Control-Request-Method": {"DELETE"}},
+			{"Access-Control-Request-Method": {"POST"}, "Access-Control-Request-Headers": {"X-Custom"}},
+		} {
+			result, header, called := run(http.MethodOptions, "https://shop.example.com", "", requestHeader)
+			assert.False(t, called)
+			assert.Equal(t, uint(http.StatusNoContent), result.(*web.Response).Status)
+			assert.Empty(t, header.Get("Access-Control-Allow-Origin"))
+		}
+	})
+
+	t.Run("route policy", func(t *testing.T) {
+		_, header, _ := run(http.MethodOptions, "https://anyone.example", "public.api", http.Header{
+			"Access-Control-Request-Method":  {"PUT"},
+			"Access-Control-Request-Headers": {"X-Custom"},
+		})
+		assert.Equal(t, "*", header.Get("Access-Control-Allow-Origin"))
+		assert.Equal(t, "GET, PUT", header.Get("Access-Control-Allow-Methods"))
+		assert.Equal(t, "X-Custom", header.Get("Access-Control-Allow-Headers"))
+		assert.Equal(t, "600", header.Get("Access-Control-Max-Age"), "unset fields are taken from the defaults")
+
+		_, header, called := run(http.MethodGet, "https://anyone.example", "public.api", nil)
+		assert.True(t, called)
+		assert.Equal(t, "*", header.Get("Access-Control-Allow-Origin"))
+		assert.Empty(t, header.Values("Vary"))
+	})
+
+	t.Run("credentials", func(t *testing.T) {
+		_, header, called := run(http.MethodPost, "https://shop.example.com", "account.api", nil)
+		require.True(t, called)
+		assert.Equal(t, "https://shop.example.com", header.Get("Access-Control-Allow-Origin"))
+		assert.Equal(t, "true", header.Get("Access-Control-Allow-Credentials"))
+	})
+
+	t.Run("any origin with credentials is rejected", func(t *testing.T) {
+		_, header, called := run(http.MethodGet, "https://anyone.example", "insecure.api", nil)
+		assert.True(t, called)
+		assert.Empty(t, header.Get("Access-Control-Allow-Origin"), "the invalid route policy is not used")
+
+		filter := newCORSFilter(config.Map{"allowedOrigins": config.Slice{"*"}, "allowCredentials": true})
+		httpRequest := httptest.NewRequest(http.MethodGet, "/api", nil)
+		httpRequest.Header.Set("Origin", "https://anyone.example")
+		recorder := httptest.NewRecorder()
+		filter.Filter(context.Background(), web.CreateRequest(httpRequest, nil), recorder, web.NewFilterChain(func(context.Context, *web.Request, http.ResponseWriter) web.Result {
+			return &web.Response{Status: http.StatusOK}
+		}))
+		assert.Empty(t, recorder.Header().Get("Access-Control-Allow-Origin"), "an invalid default policy allows no origin")
+	})
+}
@@ -1,7 +1,9 @@
 package filter
 
 import (
+	"bufio"
 	"context"
+	"net"
 	"net/http"
 	"strconv"
 
@@ -70,6 +72,15 @@ func (r *responseWriterMetrics) Flush() {
 	}
 }
 
+// Hijack the inner connection, used for protocol upgrades
+func (r *responseWriterMetrics) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	conn, rw, err := http.NewResponseController(r.rw).Hijack()
+	if err == nil {
+		r.status = http.StatusSwitchingProtocols
+	}
+	return conn, rw, err
+}
+
 // Apply metricsFilter to request
 func (r responseMetrics) Apply(ctx context.Context, rw http.ResponseWriter) error {
 	var err error
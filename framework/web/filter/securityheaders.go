@@ -0,0 +1,335 @@
+package filter
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+
+	"flamingo.me/dingo"
+
+	"flamingo.me/flamingo/v3/framework/config"
+	"flamingo.me/flamingo/v3/framework/flamingo"
+	"flamingo.me/flamingo/v3/framework/web"
+)
+
+type (
+	// SecurityHeadersModule adds a filter which sets security related response headers,
+	// a cspNonce template function and an endpoint collecting CSP violation reports
+	SecurityHeadersModule struct {
+		reportPath string
+	}
+
+	// securityHeaders holds the header values, empty values are not sent
+	securityHeaders struct {
+		ContentSecurityPolicy           *string `json:"contentSecurityPolicy"`
+		ContentSecurityPolicyReportOnly *bool   `json:"contentSecurityPolicyReportOnly"`
+		StrictTransportSecurity         *string `json:"strictTransportSecurity"`
+		ContentTypeOptions              *string `json:"contentTypeOptions"`
+		FrameOptions                    *string `json:"frameOptions"`
+		ReferrerPolicy                  *string `json:"referrerPolicy"`
+		PermissionsPolicy               *string `json:"permissionsPolicy"`
+	}
+
+	securityHeadersFilter struct {
+		defaults   securityHeaders
+		routes     map[string]securityHeaders
+		reportPath string
+	}
+
+	cspNonceFunc struct{}
+
+	cspReportController struct {
+		responder *web.Responder
+		logger    flamingo.Logger
+	}
+
+	cspReportRoutes struct {
+		controller *cspReportController
+		reportPath string
+	}
+)
+
+const (
+	// cspNoncePlaceholder is replaced by the nonce source of the request in the Content-Security-Policy
+	cspNoncePlaceholder = "{nonce}"
+	cspNonceKey         = "flamingo.web.filter.cspNonce"
+	cspReportHandler    = "flamingo.web.filter.cspReport"
+	cspReportMaxSize    = 64 << 10
+)
+
+var (
+	_ web.Filter = new(securityHeadersFilter)
+
+	// cspReportFields are logged, in the report-uri format and the Reporting API format, all other fields are ignored
+	cspReportFields = map[string]bool{
+		"document-uri":        true,
+		"documentURL":         true,
+		"referrer":            true,
+		"blocked-uri":         true,
+		"blockedURL":          true,
+		"violated-directive":  true,
+		"effective-directive": true,
+		"effectiveDirective":  true,
+		"original-policy":     true,
+		"originalPolicy":      true,
+		"disposition":         true,
+		"source-file":         true,
+		"sourceFile":          true,
+		"line-number":         true,
+		"lineNumber":          true,
+		"column-number":       true,
+		"columnNumber":        true,
+		"status-code":         true,
+		"statusCode":          true,
+		"script-sample":       true,
+		"sample":              true,
+	}
+)
+
+// Inject dependencies
+func (m *SecurityHeadersModule) Inject(
+	cfg *struct {
+		ReportPath string `inject:"config:flamingo.web.filter.securityHeaders.reportPath,optional"`
+	},
+) *SecurityHeadersModule {
+	if cfg != nil {
+		m.reportPath = cfg.ReportPath
+	}
+	return m
+}
+
+// Configure the Module
+func (m *SecurityHeadersModule) Configure(injector *dingo.Injector) {
+	injector.BindMulti((*web.Filter)(nil)).To(securityHeadersFilter{})
+	flamingo.BindTemplateFunc(injector, "cspNonce", new(cspNonceFunc))
+
+	if m.reportPath != "" {
+		web.BindRoutes(injector, new(cspReportRoutes))
+	}
+}
+
+// CueConfig defines the security headers configuration
+func (m *SecurityHeadersModule) CueConfig() string {
+	return `
+flamingo: web: filter: securityHeaders: {
+	// {nonce} is replaced by the nonce of the request, which is available in templates via cspNonce
+	contentSecurityPolicy: string | *"default-src 'self'; script-src 'self' {nonce}; style-src 'self' {nonce}; object-src 'none'; base-uri 'self'; frame-ancestors 'self'"
+	// send the policy as Content-Security-Policy-Report-Only, violations are only reported
+	contentSecurityPolicyReportOnly: bool | *false
+	// path of the endpoint which logs violation reports, e.g. "/csp-report", added as report-uri to the policy, an empty path disables it
+	reportPath: string | *""
+	strictTransportSecurity: string | *"max-age=31536000; includeSubDomains"
+	contentTypeOptions: string | *"nosniff"
+	frameOptions: string | *"SAMEORIGIN"
+	referrerPolicy: string | *"strict-origin-when-cross-origin"
+	permissionsPolicy: string | *"camera=(), microphone=(), geolocation=()"
+	// overrides per handler name, an empty string removes the header
+	routes: [string]: {
+		contentSecurityPolicy?: string
+		contentSecurityPolicyReportOnly?: bool
+		strictTransportSecurity?: string
+		contentTypeOptions?: string
+		frameOptions?: string
+		referrerPolicy?: string
+		permissionsPolicy?: string
+	}
+}
+`
+}
+
+// Inject dependencies
+func (f *securityHeadersFilter) Inject(
+	cfg *struct {
+		ContentSecurityPolicy           string     `inject:"config:flamingo.web.filter.securityHeaders.contentSecurityPolicy,optional"`
+		ContentSecurityPolicyReportOnly bool       `inject:"config:flamingo.web.filter.securityHeaders.contentSecurityPolicyReportOnly,optional"`
+		ReportPath                      string     `inject:"config:flamingo.web.filter.securityHeaders.reportPath,optional"`
+		StrictTransportSecurity         string     `inject:"config:flamingo.web.filter.securityHeaders.strictTransportSecurity,optional"`
+		ContentTypeOptions              string     `inject:"config:flamingo.web.filter.securityHeaders.contentTypeOptions,optional"`
+		FrameOptions                    string     `inject:"config:flamingo.web.filter.securityHeaders.frameOptions,optional"`
+		ReferrerPolicy                  string     `inject:"config:flamingo.web.filter.securityHeaders.referrerPolicy,optional"`
+		PermissionsPolicy               string     `inject:"config:flamingo.web.filter.securityHeaders.permissionsPolicy,optional"`
+		Routes                          config.Map `inject:"config:flamingo.web.filter.securityHeaders.routes,optional"`
+	},
+) *securityHeadersFilter {
+	if cfg != nil {
+		f.defaults = securityHeaders{
+			ContentSecurityPolicy:           &cfg.ContentSecurityPolicy,
+			ContentSecurityPolicyReportOnly: &cfg.ContentSecurityPolicyReportOnly,
+			StrictTransportSecurity:         &cfg.StrictTransportSecurity,
+			ContentTypeOptions:              &cfg.ContentTypeOptions,
+			FrameOptions:                    &cfg.FrameOptions,
+			ReferrerPolicy:                  &cfg.ReferrerPolicy,
+			PermissionsPolicy:               &cfg.PermissionsPolicy,
+		}
+		f.reportPath = cfg.ReportPath
+		_ = cfg.Routes.MapInto(&f.routes)
+	}
+	return f
+}
+
+// Filter sets the headers before the action is called, so actions can still change them
+func (f *securityHeadersFilter) Filter(ctx context.Context, r *web.Request, w http.ResponseWriter, chain *web.FilterChain) web.Result {
+	headers := f.defaults
+	if r.Handler != nil {
+		if override, ok := f.routes[r.Handler.GetHandlerName()]; ok {
+			headers = headers.override(override)
+		}
+	}
+
+	header := w.Header()
+	set := func(name string, value *string) {
+		if value != nil && *value != "" {
+			header.Set(name, *value)
+		}
+	}
+
+	if policy := deref(headers.ContentSecurityPolicy); policy != "" {
+		if strings.Contains(policy, cspNoncePlaceholder) {
+			nonce := newCSPNonce()
+			r.Values.Store(cspNonceKey, nonce)
+			policy = strings.ReplaceAll(policy, cspNoncePlaceholder, "'nonce-"+nonce+"'")
+		}
+
+		if f.reportPath != "" && !strings.Contains(policy, "report-uri") {
+			policy += "; report-uri " + f.reportPath
+		}
+
+		if headers.ContentSecurityPolicyReportOnly != nil && *headers.ContentSecurityPolicyReportOnly {
+			header.Set("Content-Security-Policy-Report-Only", policy)
+		} else {
+			header.Set("Content-Security-Policy", policy)
+		}
+	}
+
+	set("Strict-Transport-Security", headers.StrictTransportSecurity)
+	set("X-Content-Type-Options", headers.ContentTypeOptions)
+	set("X-Frame-Options", headers.FrameOptions)
+	set("Referrer-Policy", headers.ReferrerPolicy)
+	set("Permissions-Policy", headers.PermissionsPolicy)
+
+	return chain.Next(ctx, r, w)
+}
+
+// override returns the headers with all values set in the override
+func (h securityHeaders) override(override securityHeaders) securityHeaders {
+	if override.ContentSecurityPolicy != nil {
+		h.ContentSecurityPolicy = override.ContentSecurityPolicy
+	}
+	if override.ContentSecurityPolicyReportOnly != nil {
+		h.ContentSecurityPolicyReportOnly = override.ContentSecurityPolicyReportOnly
+	}
+	if override.StrictTransportSecurity != nil {
+		h.StrictTransportSecurity = override.StrictTransportSecurity
+	}
+	if override.ContentTypeOptions != nil {
+		h.ContentTypeOptions = override.ContentTypeOptions
+	}
+	if override.FrameOptions != nil {
+		h.FrameOptions = override.FrameOptions
+	}
+	if override.ReferrerPolicy != nil {
+		h.ReferrerPolicy = override.ReferrerPolicy
+	}
+	if override.PermissionsPolicy != nil {
+		h.PermissionsPolicy = override.PermissionsPolicy
+	}
+	return h
+}
+
+func deref(value *string) string {
+	if value == nil {
+		return ""
+	}
+	return *value
+}
+
+func newCSPNonce() string {
+	nonce := make([]byte, 16)
+	_, _ = rand.Read(nonce)
+	return base64.StdEncoding.EncodeToString(nonce)
+}
+
+// CSPNonce returns the Content-Security-Policy nonce of the request, empty if the policy has no nonce
+func CSPNonce(r *web.Request) string {
+	if r == nil {
+		return ""
+	}
+
+	nonce, _ := r.Values.Load(cspNonceKey)
+	s, _ := nonce.(string)
+	return s
+}
+
+// Func returns the nonce of the current request
+func (*cspNonceFunc) Func(ctx context.Context) interface{} {
+	return func() string {
+		return CSPNonce(web.RequestFromContext(ctx))
+	}
+}
+
+// Inject dependencies
+func (c *cspReportController) Inject(responder *web.Responder, logger flamingo.Logger) *cspReportController {
+	c.responder = responder
+	c.logger = logger.WithField(flamingo.LogKeyModule, "web").WithField(flamingo.LogKeyCategory, "csp-report")
+	return c
+}
+
+// Report logs the violation reports, both the report-uri format and the Reporting API format are accepted
+func (c *cspReportController) Report(ctx context.Context, r *web.Request) web.Result {
+	body, err := io.ReadAll(io.LimitReader(r.Request().Body, cspReportMaxSize))
+	if err != nil {
+		return c.responder.HTTP(http.StatusBadRequest, nil)
+	}
+
+	var reports []map[string]interface{}
+	var report map[string]interface{}
+	switch {
+	case json.Unmarshal(body, &report) == nil:
+		if inner, ok := report["csp-report"].(map[string]interface{}); ok {
+			report = inner
+		}
+		reports = append(reports, report)
+	case json.Unmarshal(body, &reports) == nil:
+		for i, report := range reports {
+			if inner, ok := report["body"].(map[string]interface{}); ok {
+				reports[i] = inner
+			}
+		}
+	default:
+		return c.responder.HTTP(http.StatusBadRequest, nil)
+	}
+
+	for _, report := range reports {
+		logger := c.logger.WithContext(ctx)
+		for key, value := range report {
+			if cspReportFields[key] {
+				logger = logger.WithField(flamingo.LogKey("csp_"+key), value)
+			}
+		}
+		logger.Warn("content security policy violation")
+	}
+
+	return c.responder.HTTP(http.StatusNoContent, nil)
+}
+
+// Inject dependencies
+func (r *cspReportRoutes) Inject(
+	controller *cspReportController,
+	cfg *struct {
+		ReportPath string `inject:"config:flamingo.web.filter.securityHeaders.reportPath"`
+	},
+) *cspReportRoutes {
+	r.controller = controller
+	r.reportPath = cfg.ReportPath
+	return r
+}
+
+// Routes registers the report endpoint
+func (r *cspReportRoutes) Routes(registry *web.RouterRegistry) {
+	registry.HandlePost(cspReportHandler, r.controller.Report)
+	registry.MustRoute(r.reportPath, cspReportHandler)
+}
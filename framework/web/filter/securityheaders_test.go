@@ -0,0 +1,145 @@
+package filter
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"flamingo.me/flamingo/v3/framework/config"
+	"flamingo.me/flamingo/v3/framework/flamingo"
+	"flamingo.me/flamingo/v3/framework/web"
+)
+
+func newSecurityHeadersFilter(reportOnly bool, routes config.Map) *securityHeadersFilter {
+	return new(securityHeadersFilter).Inject(&struct {
+		ContentSecurityPolicy           string     `inject:"config:flamingo.web.filter.securityHeaders.contentSecurityPolicy,optional"`
+		ContentSecurityPolicyReportOnly bool       `inject:"config:flamingo.web.filter.securityHeaders.contentSecurityPolicyReportOnly,optional"`
+		ReportPath                      string     `inject:"config:flamingo.web.filter.securityHeaders.reportPath,optional"`
+		StrictTransportSecurity         string     `inject:"config:flamingo.web.filter.securityHeaders.strictTransportSecurity,optional"`
+		ContentTypeOptions              string     `inject:"config:flamingo.web.filter.securityHeaders.contentTypeOptions,optional"`
+		FrameOptions                    string     `inject:"config:flamingo.web.filter.securityHeaders.frameOptions,optional"`
+		ReferrerPolicy                  string     `inject:"config:flamingo.web.filter.securityHeaders.referrerPolicy,optional"`
+		PermissionsPolicy               string     `inject:"config:flamingo.web.filter.securityHeaders.permissionsPolicy,optional"`
+		Routes                          config.Map `inject:"config:flamingo.web.filter.securityHeaders.routes,optional"`
+	}{
+		ContentSecurityPolicy:           "default-src 'self'; script-src 'self' {nonce}",
+		ContentSecurityPolicyReportOnly: reportOnly,
+		ReportPath:                      "/csp-report",
+		StrictTransportSecurity:         "max-age=31536000",
+		ContentTypeOptions:              "nosniff",
+		FrameOptions:                    "SAMEORIGIN",
+		ReferrerPolicy:                  "strict-origin-when-cross-origin",
+		PermissionsPolicy:               "camera=()",
+		Routes:                          routes,
+	})
+}
+
+func TestSecurityHeadersModule(t *testing.T) {
+	if err := config.TryModules(nil, new(SecurityHeadersModule)); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestSecurityHeadersFilter(t *testing.T) {
+	filter := newSecurityHeadersFilter(false, config.Map{
+		"embed": config.Map{"frameOptions": "", "contentSecurityPolicyReportOnly": true},
+	})
+
+	run := func(req *web.Request) (http.Header, string) {
+		var nonce string
+		recorder := httptest.NewRecorder()
+		filter.Filter(context.Background(), req, recorder, web.NewFilterChain(func(ctx context.Context, req *web.Request, w http.ResponseWriter) web.Result {
+			nonce = new(cspNonceFunc).Func(web.ContextWithRequest(ctx, req)).(func() string)()
+			return nil
+		}))
+		return recorder.Header(), nonce
+	}
+
+	t.Run("defaults", func(t *testing.T) {
+		header, nonce := run(web.CreateRequest(nil, nil))
+
+		require.NotEmpty(t, nonce)
+		assert.Equal(t, "default-src 'self'; script-src 'self' 'nonce-"+nonce+"'; report-uri /csp-report", header.Get("Content-Security-Policy"))
+		assert.Empty(t, header.Get("Content-Security-Policy-Report-Only"))
+		assert.Equal(t, "max-age=31536000", header.Get("Strict-Transport-Security"))
+		assert.Equal(t, "nosniff", header.Get("X-Content-Type-Options"))
+		assert.Equal(t, "SAMEORIGIN", header.Get("X-Frame-Options"))
+		assert.Equal(t, "strict-origin-when-cross-origin", header.Get("Referrer-Policy"))
+		assert.Equal(t, "camera=()", header.Get("Permissions-Policy"))
+
+		_, other := run(web.CreateRequest(nil, nil))
+		assert.NotEqual(t, nonce, other, "every request gets a new nonce")
+	})
+
+	t.Run("route override", func(t *testing.T) {
+		req := web.CreateRequest(nil, nil)
+		req.Handler = web.NewRegistry().MustRoute("/embed", "embed")
+		header, _ := run(req)
+
+		assert.Empty(t, header.Get("X-Frame-Options"))
+		assert.Empty(t, header.Get("Content-Security-Policy"))
+		assert.Regexp(t, regexp.MustCompile(`^default-src 'self'; script-src 'self' 'nonce-[^']+'; report-uri /csp-report$`), header.Get("Content-Security-Policy-Report-Only"))
+		assert.Equal(t, "nosniff", header.Get("X-Content-Type-Options"))
+	})
+
+	t.Run("no nonce without request", func(t *testing.T) {
+		assert.Empty(t, new(cspNonceFunc).Func(context.Background()).(func() string)())
+	})
+}
+
+type cspReportTestLogger struct {
+	flamingo.NullLogger
+	fields   map[flamingo.LogKey]interface{}
+	messages []string
+}
+
+func (l *cspReportTestLogger) WithField(key flamingo.LogKey, value interface{}) flamingo.Logger {
+	l.fields[key] = value
+	return l
+}
+
+func (l *cspReportTestLogger) WithContext(context.Context) flamingo.Logger {
+	return l
+}
+
+func (l *cspReportTestLogger) Warn(args ...interface{}) {
+	l.messages = append(l.messages, args[0].(string))
+}
+
+func TestCSPReportController(t *testing.T) {
+	for name, tt := range map[string]struct {
+		body   string
+		fields map[flamingo.LogKey]interface{}
+	}{
+		"report-uri": {
+			body:   `{"csp-report": {"document-uri": "https://shop.test/", "violated-directive": "script-src", "unknown": "ignored"}}`,
+			fields: map[flamingo.LogKey]interface{}{"module": "web", "category": "csp-report", "csp_document-uri": "https://shop.test/", "csp_violated-directive": "script-src"},
+		},
+		"reporting api": {
+			body:   `[{"type": "csp-violation", "body": {"documentURL": "https://shop.test/", "effectiveDirective": "script-src", "unknown": "ignored"}}]`,
+			fields: map[flamingo.LogKey]interface{}{"module": "web", "category": "csp-report", "csp_documentURL": "https://shop.test/", "csp_effectiveDirective": "script-src"},
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			logger := &cspReportTestLogger{fields: make(map[flamingo.LogKey]interface{})}
+			controller := new(cspReportController).Inject(new(web.Responder), logger)
+
+			result := controller.Report(context.Background(), web.CreateRequest(httptest.NewRequest(http.MethodPost, "/csp-report", strings.NewReader(tt.body)), nil))
+			assert.Equal(t, uint(http.StatusNoContent), result.(*web.Response).Status)
+			assert.Equal(t, []string{"content security policy violation"}, logger.messages)
+			assert.Equal(t, tt.fields, logger.fields, "only known report fields are logged")
+		})
+	}
+
+	t.Run("invalid report", func(t *testing.T) {
+		controller := new(cspReportController).Inject(new(web.Responder), flamingo.NullLogger{})
+		result := controller.Report(context.Background(), web.CreateRequest(httptest.NewRequest(http.MethodPost, "/csp-report", strings.NewReader("invalid")), nil))
+		assert.Equal(t, uint(http.StatusBadRequest), result.(*web.Response).Status)
+	})
+}
@@ -7,6 +7,7 @@ import (
 	"io"
 	"net/http"
 	"runtime/debug"
+	"sort"
 	"strings"
 	"time"
 
@@ -32,6 +33,18 @@ type (
 		sessionName  string
 		prefix       string
 		responder    *Responder
+
+		trustedProxies   TrustedProxies
+		forwardedHeaders string
+
+		methodNotAllowed bool
+		autoOptions      bool
+		autoHead         bool
+	}
+
+	// headResponseWriter drops the body of GET actions serving HEAD requests
+	headResponseWriter struct {
+		http.ResponseWriter
 	}
 
 	panicError struct {
@@ -103,6 +116,65 @@ func panicToError(p interface{}) error {
 	return err
 }
 
+// Write discards the body
+func (w *headResponseWriter) Write(b []byte) (int, error) {
+	return len(b), nil
+}
+
+func (w *headResponseWriter) Flush() {
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+func withMethod(req *http.Request, method string) *http.Request {
+	clone := *req
+	clone.Method = method
+	return &clone
+}
+
+// allowHeader returns the methods for the Allow header, including the automatically handled ones
+func (h *handler) allowHeader(methods []string) []string {
+	allow := make([]string, 0, len(methods)+2)
+	known := make(map[string]struct{}, len(methods)+2)
+	add := func(method string) {
+		if _, ok := known[method]; !ok {
+			known[method] = struct{}{}
+			allow = append(allow, method)
+		}
+	}
+
+	for _, method := range methods {
+		add(method)
+		if method == http.MethodGet && h.autoHead {
+			add(http.MethodHead)
+		}
+	}
+	if h.autoOptions {
+		add(http.MethodOptions)
+	}
+
+	sort.Strings(allow)
+	return allow
+}
+
+// Redispatch copies the request with the given context, the returned function serves the copy through the router which served the request.
+// It is meant to render a page again in the background, so the context should be detached from the request. ok is false for requests not served by a router.
+func Redispatch(ctx context.Context, req *Request) (serve func(rw http.ResponseWriter), ok bool) {
+	h := req.router
+	if h == nil {
+		return nil, false
+	}
+
+	httpRequest := req.Request().Clone(ctx)
+	// the router strips its base path again
+	httpRequest.URL.Path = h.prefix + httpRequest.URL.Path
+
+	return func(rw http.ResponseWriter) {
+		h.ServeHTTP(rw, httpRequest)
+	}, true
+}
+
 func (h *handler) ServeHTTP(rw http.ResponseWriter, httpRequest *http.Request) {
 	httpRequest.URL.Path = strings.TrimPrefix(httpRequest.URL.Path, h.prefix)
 
@@ -121,6 +193,29 @@ func (h *handler) ServeHTTP(rw http.ResponseWriter, httpRequest *http.Request) {
 	_, span = trace.StartSpan(ctx, "router/matchRequest")
 	controller, params, handler := h.routerRegistry.matchRequest(httpRequest)
 
+	var allowedMethods []string
+	if handler == nil && httpRequest.Method == http.MethodHead && h.autoHead {
+		controller, params, handler = h.routerRegistry.matchRequest(withMethod(httpRequest, http.MethodGet))
+		if handler != nil {
+			rw = &headResponseWriter{ResponseWriter: rw}
+		}
+	}
+	// CORS preflight requests are matched with the requested method, so filters know the handler of the actual request
+	preflight := false
+	if handler == nil && httpRequest.Method == http.MethodOptions {
+		if requestMethod := httpRequest.Header.Get("Access-Control-Request-Method"); requestMethod != "" {
+			controller, params, handler = h.routerRegistry.matchRequest(withMethod(httpRequest, requestMethod))
+			preflight = handler != nil
+		}
+	}
+	if h.methodNotAllowed || h.autoOptions {
+		if handler == nil {
+			allowedMethods, params, handler = h.routerRegistry.matchMethods(httpRequest)
+		} else if preflight {
+			allowedMethods, _, _ = h.routerRegistry.matchMethods(httpRequest)
+		}
+	}
+
 	if handler != nil {
 		ctx, _ = tag.New(ctx, tag.Upsert(ControllerKey, handler.GetHandlerName()), tag.Insert(opencensus.KeyArea, "-"), tag.Upsert(MethodKey, httpRequest.Method))
 		httpRequest = httpRequest.WithContext(ctx)
@@ -135,6 +230,10 @@ func (h *handler) ServeHTTP(rw http.ResponseWriter, httpRequest *http.Request) {
 		session: Session{s: session.s, sessionSaveMode: session.sessionSaveMode},
 		Handler: handler,
 		Params:  params,
+
+		trustedProxies:   h.trustedProxies,
+		forwardedHeaders: h.forwardedHeaders,
+		router:           h,
 	}
 	ctx = ContextWithRequest(ContextWithSession(ctx, req.Session()), req)
 
@@ -151,8 +250,13 @@ func (h *handler) ServeHTTP(rw http.ResponseWriter, httpRequest *http.Request) {
 	ctx, span = trace.StartSpan(ctx, "router/request")
 	defer span.End()
 
+	filters := h.filter
+	if handler != nil && len(handler.filters) > 0 {
+		filters = append(append(make([]Filter, 0, len(h.filter)+len(handler.filters)), h.filter...), handler.filters...)
+	}
+
 	chain := &FilterChain{
-		filters: h.filter,
+		filters: filters,
 		final: func(ctx context.Context, r *Request, rw http.ResponseWriter) (response Result) {
 			ctx, span := trace.StartSpan(ctx, "router/controller")
 			defer span.End()
@@ -170,6 +274,17 @@ func (h *handler) ServeHTTP(rw http.ResponseWriter, httpRequest *http.Request) {
 				response = c(ctx, r)
 			} else if controller.any != nil {
 				response = controller.any(ctx, r)
+			} else if c, ok := controller.method[http.MethodGet]; ok && c != nil && req.Request().Method == http.MethodHead && h.autoHead {
+				response = c(ctx, r)
+			} else if len(allowedMethods) > 0 && req.Request().Method == http.MethodOptions && h.autoOptions {
+				response = &Response{
+					Status: http.StatusNoContent,
+					Header: http.Header{"Allow": []string{strings.Join(h.allowHeader(allowedMethods), ", ")}},
+				}
+			} else if len(allowedMethods) > 0 && h.methodNotAllowed {
+				err := fmt.Errorf("action for method %q not allowed", req.Request().Method)
+				response = h.responder.MethodNotAllowedWithContext(ctx, err, h.allowHeader(allowedMethods))
+				span.SetStatus(trace.Status{Code: trace.StatusCodeUnimplemented, Message: "method not allowed"})
 			} else {
 				err := fmt.Errorf("action for method %q not found and no \"any\" fallback", req.Request().Method)
 				response = h.routerRegistry.handler[FlamingoNotfound].any(context.WithValue(ctx, RouterError, err), r)
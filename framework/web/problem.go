@@ -0,0 +1,201 @@
+package web
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+)
+
+type (
+	// Problem details for HTTP APIs as defined in RFC 7807
+	Problem struct {
+		// Type is a URI reference identifying the problem type, "about:blank" if empty
+		Type string
+		// Title is a short summary of the problem type, defaults to the status text
+		Title string
+		// Status is always the status code of the response
+		Status int
+		// Detail explains this occurrence of the problem, defaults to the error message
+		Detail string
+		// Instance identifies this occurrence of the problem, defaults to the request path
+		Instance string
+		// Extensions are added as additional members
+		Extensions map[string]interface{}
+	}
+
+	// ProblemProvider is implemented by errors which describe themselves as Problem
+	ProblemProvider interface {
+		Problem() Problem
+	}
+
+	// ProblemError attaches a Problem to an error
+	ProblemError struct {
+		problem Problem
+		err     error
+	}
+)
+
+const (
+	// ProblemMediaType is the media type of problem details responses
+	ProblemMediaType = "application/problem+json"
+)
+
+var (
+	_ ProblemProvider = new(ProblemError)
+	_ ProblemProvider = new(BindError)
+)
+
+// NewProblemError creates an error mapped to the given problem type and title
+func NewProblemError(err error, problemType, title string) *ProblemError {
+	return &ProblemError{
+		problem: Problem{Type: problemType, Title: title},
+		err:     err,
+	}
+}
+
+// WithDetail sets the detail of the problem, instead of the error message
+func (e *ProblemError) WithDetail(detail string) *ProblemError {
+	e.problem.Detail = detail
+	return e
+}
+
+// WithExtension adds an extension member to the problem
+func (e *ProblemError) WithExtension(key string, value interface{}) *ProblemError {
+	if e.problem.Extensions == nil {
+		e.problem.Extensions = make(map[string]interface{})
+	}
+	e.problem.Extensions[key] = value
+	return e
+}
+
+// Error message of the wrapped error
+func (e *ProblemError) Error() string {
+	if e.err == nil {
+		return e.problem.Title
+	}
+	return e.err.Error()
+}
+
+// Unwrap returns the wrapped error
+func (e *ProblemError) Unwrap() error {
+	return e.err
+}
+
+// Problem details of the error
+func (e *ProblemError) Problem() Problem {
+	return e.problem
+}
+
+// Problem details of the bind error, the failed fields are added as "fields"
+func (e *BindError) Problem() Problem {
+	return Problem{
+		Detail:     e.Error(),
+		Extensions: map[string]interface{}{"fields": e.Fields},
+	}
+}
+
+// MarshalJSON encodes the problem with its extensions as a single object
+func (p Problem) MarshalJSON() ([]byte, error) {
+	members := make(map[string]interface{}, len(p.Extensions)+5)
+	for key, value := range p.Extensions {
+		members[key] = value
+	}
+
+	members["type"] = p.Type
+	if p.Type == "" {
+		members["type"] = "about:blank"
+	}
+	if p.Title != "" {
+		members["title"] = p.Title
+	}
+	if p.Status != 0 {
+		members["status"] = p.Status
+	}
+	if p.Detail != "" {
+		members["detail"] = p.Detail
+	}
+	if p.Instance != "" {
+		members["instance"] = p.Instance
+	}
+
+	return json.Marshal(members)
+}
+
+// WithProblemDetails answers errors of this route always with problem details, regardless of the Accept header
+func (handler *Handler) WithProblemDetails() *Handler {
+	handler.problemDetails = true
+	return handler
+}
+
+// Problem returns the problem details of the error response
+func (r *ServerErrorResponse) Problem(ctx context.Context) Problem {
+	var problem Problem
+	var provider ProblemProvider
+	if errors.As(r.Error, &provider) {
+		problem = provider.Problem()
+	}
+
+	status := int(r.RenderResponse.DataResponse.Response.Status) //nolint:gosec // status codes fit into int
+	if status == 0 {
+		status = http.StatusInternalServerError
+	}
+	problem.Status = status
+
+	if problem.Title == "" && (problem.Type == "" || problem.Type == "about:blank") {
+		problem.Title = http.StatusText(status)
+	}
+
+	if problem.Detail == "" && r.Error != nil {
+		problem.Detail = r.Error.Error()
+	}
+
+	if problem.Instance == "" {
+		if req := RequestFromContext(ctx); req != nil {
+			problem.Instance = req.Request().URL.Path
+		}
+	}
+
+	return problem
+}
+
+// wantsProblem checks if the route is configured for problem details or the client explicitly accepts them
+func wantsProblem(ctx context.Context) bool {
+	req := RequestFromContext(ctx)
+	if req == nil {
+		return false
+	}
+
+	if req.Handler != nil && req.Handler.problemDetails {
+		return true
+	}
+
+	for _, r := range parseAccept(req.Request().Header.Get("Accept")) {
+		if r.mediaType == ProblemMediaType && r.q > 0 {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (r *ServerErrorResponse) applyProblem(ctx context.Context, w http.ResponseWriter) error {
+	body, err := json.Marshal(r.Problem(ctx))
+	if err != nil {
+		return err
+	}
+
+	response := r.RenderResponse.DataResponse.Response
+	if response.Status == 0 {
+		response.Status = http.StatusInternalServerError
+	}
+	response.Header = response.Header.Clone()
+	if response.Header == nil {
+		response.Header = make(http.Header)
+	}
+	response.Header.Set("Content-Type", ProblemMediaType)
+	response.Body = bytes.NewReader(body)
+
+	return response.Apply(ctx, w)
+}
@@ -0,0 +1,101 @@
+package web
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProblemDetails(t *testing.T) {
+	responder := new(Responder)
+
+	apply := func(t *testing.T, result Result, accept string, handler *Handler) (*httptest.ResponseRecorder, map[string]interface{}) {
+		t.Helper()
+		httpRequest := httptest.NewRequest(http.MethodGet, "/api/orders/1", nil)
+		httpRequest.Header.Set("Accept", accept)
+		req := CreateRequest(httpRequest, nil)
+		req.Handler = handler
+
+		recorder := httptest.NewRecorder()
+		require.NoError(t, result.Apply(ContextWithRequest(context.Background(), req), recorder))
+
+		var body map[string]interface{}
+		if recorder.Header().Get("Content-Type") == ProblemMediaType {
+			require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &body))
+		}
+		return recorder, body
+	}
+
+	t.Run("negotiated", func(t *testing.T) {
+		recorder, body := apply(t, responder.NotFoundWithContext(context.Background(), errors.New("order 1 not found")), "application/problem+json, application/json;q=0.9", nil)
+
+		assert.Equal(t, http.StatusNotFound, recorder.Code)
+		assert.Equal(t, ProblemMediaType, recorder.Header().Get("Content-Type"))
+		assert.Equal(t, map[string]interface{}{
+			"type":     "about:blank",
+			"title":    "Not Found",
+			"status":   float64(http.StatusNotFound),
+			"detail":   "order 1 not found",
+			"instance": "/api/orders/1",
+		}, body)
+	})
+
+	t.Run("not requested", func(t *testing.T) {
+		recorder, _ := apply(t, responder.ForbiddenWithContext(context.Background(), errors.New("forbidden")), "application/json", nil)
+
+		assert.Equal(t, http.StatusForbidden, recorder.Code)
+		assert.Equal(t, "application/json; charset=utf-8", recorder.Header().Get("Content-Type"))
+	})
+
+	t.Run("route configuration", func(t *testing.T) {
+		registry := NewRegistry()
+		handler := registry.Group("/api", "api.").WithProblemDetails().MustRoute("/orders/:id", "orders")
+
+		recorder, body := apply(t, responder.ServerErrorWithContext(context.Background(), errors.New("boom")), "text/html", handler)
+
+		assert.Equal(t, http.StatusInternalServerError, recorder.Code)
+		assert.Equal(t, "Internal Server Error", body["title"])
+	})
+
+	t.Run("typed errors", func(t *testing.T) {
+		err := NewProblemError(errors.New("order already shipped"), "https://example.com/problems/order-shipped", "Order already shipped").
+			WithExtension("orderId", "1")
+
+		recorder, body := apply(t, responder.BadRequestWithContext(context.Background(), err), ProblemMediaType, nil)
+
+		assert.Equal(t, http.StatusBadRequest, recorder.Code)
+		assert.Equal(t, map[string]interface{}{
+			"type":     "https://example.com/problems/order-shipped",
+			"title":    "Order already shipped",
+			"status":   float64(http.StatusBadRequest),
+			"detail":   "order already shipped",
+			"instance": "/api/orders/1",
+			"orderId":  "1",
+		}, body)
+	})
+
+	t.Run("allow header is kept", func(t *testing.T) {
+		recorder, _ := apply(t, responder.MethodNotAllowedWithContext(context.Background(), errors.New("not allowed"), []string{http.MethodGet}), ProblemMediaType, nil)
+
+		assert.Equal(t, http.StatusMethodNotAllowed, recorder.Code)
+		assert.Equal(t, http.MethodGet, recorder.Header().Get("Allow"))
+	})
+
+	t.Run("bind errors", func(t *testing.T) {
+		_, err := Bind[struct {
+			ID int `query:"id" validate:"required"`
+		}](CreateRequest(httptest.NewRequest(http.MethodGet, "/", nil), nil))
+		require.Error(t, err)
+
+		_, body := apply(t, responder.BadRequestWithContext(context.Background(), err), ProblemMediaType, nil)
+
+		assert.Equal(t, "Bad Request", body["title"])
+		assert.Equal(t, []interface{}{map[string]interface{}{"field": "id", "source": "query", "rule": "required", "message": "is required"}}, body["fields"])
+	})
+}
@@ -0,0 +1,212 @@
+package web
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+)
+
+type (
+	// TrustedProxies are the networks of reverse proxies whose forwarding headers are evaluated
+	TrustedProxies []*net.IPNet
+
+	// forwardedHop is an element of the forwarding chain, ordered from the client to the proxy in front of flamingo
+	forwardedHop struct {
+		forAddress string
+		proto      string
+		host       string
+	}
+)
+
+const (
+	// forwardedHeadersForwarded evaluates the RFC 7239 Forwarded header of trusted proxies
+	forwardedHeadersForwarded = "forwarded"
+	// forwardedHeadersXForwarded evaluates X-Forwarded-For, X-Forwarded-Proto and X-Forwarded-Host or X-Real-IP of trusted proxies
+	forwardedHeadersXForwarded = "x-forwarded"
+)
+
+// ParseTrustedProxies parses a list of CIDRs or single IP addresses
+func ParseTrustedProxies(proxies []string) (TrustedProxies, error) {
+	trusted := make(TrustedProxies, 0, len(proxies))
+
+	for _, proxy := range proxies {
+		proxy = strings.TrimSpace(proxy)
+		if !strings.Contains(proxy, "/") {
+			ip := net.ParseIP(proxy)
+			if ip == nil {
+				return nil, fmt.Errorf("invalid trusted proxy %q", proxy)
+			}
+
+			bits := 8 * net.IPv6len
+			if ip4 := ip.To4(); ip4 != nil {
+				ip, bits = ip4, 8*net.IPv4len
+			}
+			trusted = append(trusted, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+
+			continue
+		}
+
+		_, network, err := net.ParseCIDR(proxy)
+		if err != nil {
+			return nil, fmt.Errorf("invalid trusted proxy %q: %w", proxy, err)
+		}
+		trusted = append(trusted, network)
+	}
+
+	return trusted, nil
+}
+
+// Contains reports whether the address belongs to a trusted proxy
+func (t TrustedProxies) Contains(address string) bool {
+	ip := net.ParseIP(address)
+	if ip == nil {
+		return false
+	}
+
+	for _, network := range t {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// ClientIP returns the IP address of the client.
+// Forwarding headers are only evaluated if the request was sent by a trusted proxy,
+// the client is the last address of the forwarding chain which is not a trusted proxy.
+func (r *Request) ClientIP() string {
+	hops, client := r.forwarded()
+	if client >= 0 && net.ParseIP(hops[client].forAddress) != nil {
+		return hops[client].forAddress
+	}
+
+	return stripPort(r.request.RemoteAddr)
+}
+
+// Scheme returns the scheme requested by the client, from the forwarding headers of trusted proxies or the connection
+func (r *Request) Scheme() string {
+	hops, client := r.forwarded()
+	for i := max(client, 0); i < len(hops); i++ {
+		if hops[i].proto != "" {
+			return hops[i].proto
+		}
+	}
+
+	if r.request.TLS != nil {
+		return "https"
+	}
+
+	return "http"
+}
+
+// Host returns the host requested by the client, from the forwarding headers of trusted proxies or the Host header
+func (r *Request) Host() string {
+	hops, client := r.forwarded()
+	for i := max(client, 0); i < len(hops); i++ {
+		if hops[i].host != "" {
+			return hops[i].host
+		}
+	}
+
+	return r.request.Host
+}
+
+// forwarded returns the forwarding chain and the index of the client, -1 if the request was not sent by a trusted proxy.
+// Only the configured header family is evaluated, the other one is passed through by the proxies and may be sent by the client.
+func (r *Request) forwarded() ([]forwardedHop, int) {
+	if !r.trustedProxies.Contains(stripPort(r.request.RemoteAddr)) {
+		return nil, -1
+	}
+
+	var hops []forwardedHop
+	if r.forwardedHeaders == forwardedHeadersForwarded {
+		hops = parseForwarded(r.request.Header.Values("Forwarded"))
+	} else {
+		hops = parseXForwarded(r.request.Header)
+	}
+
+	for i := len(hops) - 1; i >= 0; i-- {
+		if !r.trustedProxies.Contains(hops[i].forAddress) {
+			return hops, i
+		}
+	}
+
+	return hops, 0
+}
+
+// parseForwarded parses the elements of RFC 7239 Forwarded headers
+func parseForwarded(values []string) []forwardedHop {
+	var hops []forwardedHop
+
+	for _, value := range values {
+		for _, element := range strings.Split(value, ",") {
+			var hop forwardedHop
+			for _, pair := range strings.Split(element, ";") {
+				key, val, found := strings.Cut(strings.TrimSpace(pair), "=")
+				if !found {
+					continue
+				}
+
+				val = strings.Trim(strings.TrimSpace(val), `"`)
+				switch strings.ToLower(key) {
+				case "for":
+					hop.forAddress = stripPort(val)
+				case "proto":
+					hop.proto = strings.ToLower(val)
+				case "host":
+					hop.host = val
+				}
+			}
+			hops = append(hops, hop)
+		}
+	}
+
+	return hops
+}
+
+// parseXForwarded creates the chain from X-Forwarded-For or X-Real-IP.
+// X-Forwarded-Proto and X-Forwarded-Host are assigned to the last hop, proxies appending to them add their value at the end,
+// so the last value is the one of the nearest proxy and earlier values may be sent by the client.
+func parseXForwarded(header http.Header) []forwardedHop {
+	var hops []forwardedHop
+
+	for _, value := range header.Values("X-Forwarded-For") {
+		for _, address := range strings.Split(value, ",") {
+			if address = strings.TrimSpace(address); address != "" {
+				hops = append(hops, forwardedHop{forAddress: stripPort(address)})
+			}
+		}
+	}
+
+	if len(hops) == 0 {
+		hops = append(hops, forwardedHop{forAddress: stripPort(strings.TrimSpace(header.Get("X-Real-IP")))})
+	}
+
+	last := &hops[len(hops)-1]
+	last.proto = strings.ToLower(lastHeaderValue(header, "X-Forwarded-Proto"))
+	last.host = lastHeaderValue(header, "X-Forwarded-Host")
+
+	return hops
+}
+
+// lastHeaderValue returns the last element of a comma separated header, which may be sent multiple times
+func lastHeaderValue(header http.Header, name string) string {
+	values := header.Values(name)
+	if len(values) == 0 {
+		return ""
+	}
+
+	value := values[len(values)-1]
+	return strings.TrimSpace(value[strings.LastIndex(value, ",")+1:])
+}
+
+// stripPort removes the port and the brackets of IPv6 addresses
+func stripPort(address string) string {
+	if host, _, err := net.SplitHostPort(address); err == nil {
+		return host
+	}
+
+	return strings.TrimSuffix(strings.TrimPrefix(address, "["), "]")
+}
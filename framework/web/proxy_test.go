@@ -0,0 +1,173 @@
+package web
+
+import (
+	"crypto/tls"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseTrustedProxies(t *testing.T) {
+	proxies, err := ParseTrustedProxies([]string{"10.0.0.0/8", "192.0.2.10", "2001:db8::/32", " ::1 "})
+	require.NoError(t, err)
+
+	assert.True(t, proxies.Contains("10.1.2.3"))
+	assert.True(t, proxies.Contains("192.0.2.10"))
+	assert.False(t, proxies.Contains("192.0.2.11"))
+	assert.True(t, proxies.Contains("2001:db8::1"))
+	assert.True(t, proxies.Contains("::1"))
+	assert.False(t, proxies.Contains("unknown"))
+
+	_, err = ParseTrustedProxies([]string{"proxy.local"})
+	assert.Error(t, err)
+
+	_, err = ParseTrustedProxies([]string{"10.0.0.0/33"})
+	assert.Error(t, err)
+}
+
+func TestRequestForwarding(t *testing.T) {
+	proxies, err := ParseTrustedProxies([]string{"10.0.0.0/8"})
+	require.NoError(t, err)
+
+	tests := []struct {
+		name                   string
+		remoteAddr             string
+		forwardedHeaders       string
+		tls                    bool
+		header                 http.Header
+		clientIP, scheme, host string
+	}{
+		{
+			name:       "direct request",
+			remoteAddr: "198.51.100.7:1234",
+			tls:        true,
+			clientIP:   "198.51.100.7",
+			scheme:     "https",
+			host:       "internal.host",
+		},
+		{
+			name:       "headers of untrusted hops are ignored",
+			remoteAddr: "198.51.100.7:1234",
+			header: http.Header{
+				"X-Forwarded-For":   {"203.0.113.1"},
+				"X-Forwarded-Proto": {"https"},
+				"X-Forwarded-Host":  {"flamingo.me"},
+				"X-Real-Ip":         {"203.0.113.2"},
+				"Forwarded":         {"for=203.0.113.3;proto=https"},
+			},
+			clientIP: "198.51.100.7",
+			scheme:   "http",
+			host:     "internal.host",
+		},
+		{
+			name:       "x-forwarded headers",
+			remoteAddr: "10.0.0.1:1234",
+			header: http.Header{
+				"X-Forwarded-For":   {"203.0.113.1, 198.51.100.7", "10.0.0.2"},
+				"X-Forwarded-Proto": {"https"},
+				"X-Forwarded-Host":  {"flamingo.me"},
+			},
+			clientIP: "198.51.100.7",
+			scheme:   "https",
+			host:     "flamingo.me",
+		},
+		{
+			name:       "client supplied x-forwarded values are ignored",
+			remoteAddr: "10.0.0.1:1234",
+			header: http.Header{
+				"X-Forwarded-For":   {"198.51.100.7"},
+				"X-Forwarded-Proto": {"http, https"},
+				"X-Forwarded-Host":  {"evil.host", "evil.host, flamingo.me"},
+			},
+			clientIP: "198.51.100.7",
+			scheme:   "https",
+			host:     "flamingo.me",
+		},
+		{
+			name:       "all hops trusted",
+			remoteAddr: "10.0.0.1:1234",
+			header:     http.Header{"X-Forwarded-For": {"10.0.0.3, 10.0.0.2"}},
+			clientIP:   "10.0.0.3",
+			scheme:     "http",
+			host:       "internal.host",
+		},
+		{
+			name:       "x-real-ip",
+			remoteAddr: "10.0.0.1:1234",
+			header:     http.Header{"X-Real-Ip": {"198.51.100.7"}, "X-Forwarded-Proto": {"HTTPS"}},
+			clientIP:   "198.51.100.7",
+			scheme:     "https",
+			host:       "internal.host",
+		},
+		{
+			name:       "client supplied forwarded header is ignored with x-forwarded headers",
+			remoteAddr: "10.0.0.1:1234",
+			header: http.Header{
+				"Forwarded":       {"for=1.1.1.1;host=evil.test;proto=https"},
+				"X-Forwarded-For": {"203.0.113.7"},
+			},
+			clientIP: "203.0.113.7",
+			scheme:   "http",
+			host:     "internal.host",
+		},
+		{
+			name:             "forwarded header",
+			remoteAddr:       "10.0.0.1:1234",
+			forwardedHeaders: forwardedHeadersForwarded,
+			header: http.Header{
+				"Forwarded": {`for=203.0.113.1;proto=http;host=evil.host, for="[2001:db8::7]:4711";proto=https;host=flamingo.me`, "for=10.0.0.2"},
+			},
+			clientIP: "2001:db8::7",
+			scheme:   "https",
+			host:     "flamingo.me",
+		},
+		{
+			name:             "client supplied x-forwarded headers are ignored with forwarded header",
+			remoteAddr:       "10.0.0.1:1234",
+			forwardedHeaders: forwardedHeadersForwarded,
+			header: http.Header{
+				"Forwarded":         {"for=198.51.100.7"},
+				"X-Forwarded-For":   {"203.0.113.1"},
+				"X-Forwarded-Proto": {"https"},
+				"X-Forwarded-Host":  {"evil.test"},
+				"X-Real-Ip":         {"203.0.113.2"},
+			},
+			clientIP: "198.51.100.7",
+			scheme:   "http",
+			host:     "internal.host",
+		},
+		{
+			name:             "obfuscated client",
+			remoteAddr:       "10.0.0.1:1234",
+			forwardedHeaders: forwardedHeadersForwarded,
+			header:           http.Header{"Forwarded": {"for=unknown, for=10.0.0.2;proto=https"}},
+			clientIP:         "10.0.0.1",
+			scheme:           "https",
+			host:             "internal.host",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			httpRequest := httptest.NewRequest(http.MethodGet, "http://internal.host/", nil)
+			httpRequest.RemoteAddr = tt.remoteAddr
+			if tt.tls {
+				httpRequest.TLS = new(tls.ConnectionState)
+			}
+			for name, values := range tt.header {
+				httpRequest.Header[name] = values
+			}
+
+			req := CreateRequest(httpRequest, nil)
+			req.trustedProxies = proxies
+			req.forwardedHeaders = tt.forwardedHeaders
+
+			assert.Equal(t, tt.clientIP, req.ClientIP())
+			assert.Equal(t, tt.scheme, req.Scheme())
+			assert.Equal(t, tt.host, req.Host())
+		})
+	}
+}
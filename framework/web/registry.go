@@ -22,6 +22,7 @@ type (
 	RouterRegistry struct {
 		handler map[string]handlerAction
 		routes  []*Handler
+		tree    *routeTree
 		alias   map[string]*Handler
 	}
 
@@ -31,6 +32,9 @@ type (
 		handler  string
 		params   map[string]*param
 		catchall bool
+		filters  []Filter
+
+		problemDetails bool
 	}
 
 	handlerAction struct {
@@ -71,6 +75,7 @@ func BindRoutes(injector *dingo.Injector, m RoutesModule) {
 func NewRegistry() *RouterRegistry {
 	return &RouterRegistry{
 		handler: make(map[string]handlerAction),
+		tree:    newRouteTree(),
 		alias:   make(map[string]*Handler),
 	}
 }
@@ -207,6 +212,7 @@ func (registry *RouterRegistry) Route(path, handler string) (*Handler, error) {
 		h.params, h.catchall = parseParams(strings.Join(h.path.params, ", "))
 	}
 
+	registry.tree.insert(h, len(registry.routes))
 	registry.routes = append(registry.routes, h)
 	return h, nil
 }
@@ -417,42 +423,40 @@ catchallrouteloop:
 
 // Match a request path
 func (registry *RouterRegistry) match(path string) (handler handlerAction, params map[string]string) {
-	for _, route := range registry.routes {
-		if match := route.path.Match(path); match != nil {
-			handler = registry.handler[route.handler]
-			params = make(map[string]string)
-			for k, param := range route.params {
-				params[k] = param.value
-			}
-			for k, v := range match.Values {
-				params[k] = v
-			}
-			return
-		}
+	matches := registry.tree.match(path)
+	if len(matches) == 0 {
+		return
+	}
+
+	handler = registry.handler[matches[0].handler.handler]
+	params = make(map[string]string)
+	for k, param := range matches[0].handler.params {
+		params[k] = param.value
+	}
+	for k, v := range matches[0].match.Values {
+		params[k] = v
 	}
 	return
 }
 
-// matchRequest matches a http Request (with query and path parameters)
-func (registry *RouterRegistry) matchRequest(req *http.Request) (handlerAction, map[string]string, *Handler) {
+func requestPath(req *http.Request) string {
 	var path = req.URL.Path
 	if req.URL.RawPath != "" {
 		path = req.URL.RawPath
 	}
 
-	path = "/" + strings.TrimLeft(path, "/")
+	return "/" + strings.TrimLeft(path, "/")
+}
 
+// matchRequest matches a http Request (with query and path parameters)
+func (registry *RouterRegistry) matchRequest(req *http.Request) (handlerAction, map[string]string, *Handler) {
 	var matchedHandlers matchedHandlers
-	for _, handler := range registry.routes {
-		if match := handler.path.Match(path); match != nil {
-			controller := registry.handler[handler.handler]
-			matchedHandler := &matchedHandler{
-				handlerAction: controller,
-				handler:       handler,
-				match:         match,
-			}
-			matchedHandlers = append(matchedHandlers, matchedHandler)
-		}
+	for _, matched := range registry.tree.match(requestPath(req)) {
+		matchedHandlers = append(matchedHandlers, &matchedHandler{
+			handlerAction: registry.handler[matched.handler.handler],
+			handler:       matched.handler,
+			match:         matched.match,
+		})
 	}
 
 	if any := matchedHandlers.getHandleAny(); any != nil && !matchedHandlers.hasMethod(req.Method) {
@@ -479,6 +483,38 @@ func (registry *RouterRegistry) matchRequest(req *http.Request) (handlerAction,
 	return handlerAction{}, nil, nil
 }
 
+// matchMethods returns the methods with a registered action for all routes matching the request path,
+// together with the params and handler of the first matching route
+func (registry *RouterRegistry) matchMethods(req *http.Request) ([]string, map[string]string, *Handler) {
+	var methods []string
+	var params map[string]string
+	var handler *Handler
+
+	known := make(map[string]struct{})
+	for _, matched := range registry.tree.match(requestPath(req)) {
+		controller, matchedParams, matchedHandler := registry.makeHandler(req, matchedHandler{
+			handlerAction: registry.handler[matched.handler.handler],
+			handler:       matched.handler,
+			match:         matched.match,
+		})
+		if matchedHandler == nil {
+			continue
+		}
+		if handler == nil {
+			params, handler = matchedParams, matchedHandler
+		}
+		for method := range controller.method {
+			if _, ok := known[method]; !ok {
+				known[method] = struct{}{}
+				methods = append(methods, method)
+			}
+		}
+	}
+
+	sort.Strings(methods)
+	return methods, params, handler
+}
+
 func (registry *RouterRegistry) makeHandler(req *http.Request, matched matchedHandler) (handlerAction, map[string]string, *Handler) {
 	params := make(map[string]string)
 	if len(matched.handler.params) > 0 {
@@ -2,7 +2,10 @@ package web
 
 import (
 	"context"
+	"fmt"
 	"net/http"
+	"net/http/httptest"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -225,3 +228,106 @@ func TestRegistry(t *testing.T) {
 		assert.Equal(t, "/page2/Test+%26+123+-+test", path)
 	})
 }
+
+// linearMatchRequest is the former matching strategy, checking every route in registration order
+func linearMatchRequest(registry *RouterRegistry, req *http.Request) (handlerAction, map[string]string, *Handler) {
+	path := "/" + strings.TrimLeft(req.URL.Path, "/")
+
+	var matchedHandlers matchedHandlers
+	for _, handler := range registry.routes {
+		if match := handler.path.Match(path); match != nil {
+			matchedHandlers = append(matchedHandlers, &matchedHandler{
+				handlerAction: registry.handler[handler.handler],
+				handler:       handler,
+				match:         match,
+			})
+		}
+	}
+
+	if any := matchedHandlers.getHandleAny(); any != nil && !matchedHandlers.hasMethod(req.Method) {
+		return registry.makeHandler(req, *any)
+	}
+
+	for _, matched := range matchedHandlers {
+		if _, ok := matched.handlerAction.method[req.Method]; !ok && len(matched.handlerAction.method) > 0 {
+			continue
+		}
+		if controller, params, handler := registry.makeHandler(req, *matched); handler != nil {
+			return controller, params, handler
+		}
+	}
+	return handlerAction{}, nil, nil
+}
+
+func benchmarkRegistry(routes int) *RouterRegistry {
+	registry := NewRegistry()
+	registry.HandleAny("page.view", testController)
+	registry.HandleGet("product.view", testController)
+	registry.HandlePost("product.update", testController)
+	registry.HandleAny("search.view", testController)
+
+	for i := 0; i < routes; i++ {
+		registry.MustRoute(fmt.Sprintf("/page%d", i), fmt.Sprintf(`page.view(page="page%d")`, i))
+		registry.MustRoute(fmt.Sprintf("/shop%d/product/:id/:name.html", i), "product.view")
+		registry.MustRoute(fmt.Sprintf("/shop%d/product/:id/:name.html", i), "product.update")
+		registry.MustRoute(fmt.Sprintf("/shop%d/sku/$sku<[0-9]+>", i), "product.view")
+		registry.MustRoute(fmt.Sprintf("/search%d/*query", i), "search.view")
+	}
+	registry.MustRoute("/:page", "page.view(page)")
+
+	return registry
+}
+
+func TestRegistryTreeMatch(t *testing.T) {
+	registry := benchmarkRegistry(20)
+	registry.HandleGet("mixed.get", testController)
+	registry.HandleAny("mixed.any", testController)
+	registry.MustRoute("/mixed/$id<[0-9]+/[0-9]+>", "mixed.get")
+	registry.MustRoute("/mixed/:id", "mixed.any")
+	registry.MustRoute("/mixed/*rest", "mixed.any")
+	registry.MustRoute("/mixed/", `mixed.get(id="root")`)
+
+	for _, method := range []string{http.MethodGet, http.MethodPost, http.MethodPut} {
+		for _, path := range []string{
+			"/", "/page1", "/page1/", "/page19", "/page20", "/unknown", "/unknown/deep",
+			"/shop3/product/123/name.html", "/shop3/product/123/name.htm", "/shop3/product/123/", "/shop3/product/123/name.html/",
+			"/shop7/sku/123", "/shop7/sku/abc", "/shop7/sku/", "/search5/", "/search5/a/b/c", "/search5",
+			"/mixed/", "/mixed/1/2", "/mixed/1/a", "/mixed/1", "/mixed/a/b",
+		} {
+			req := httptest.NewRequest(method, path+"?page=query", nil)
+			expectedController, expectedParams, expectedHandler := linearMatchRequest(registry, req)
+			controller, params, handler := registry.matchRequest(req)
+			assert.Equal(t, expectedHandler, handler, "%s %s", method, path)
+			assert.Equal(t, expectedParams, params, "%s %s", method, path)
+			assert.Equal(t, expectedController.method == nil, controller.method == nil, "%s %s", method, path)
+			assert.Equal(t, expectedController.any == nil, controller.any == nil, "%s %s", method, path)
+		}
+	}
+}
+
+func BenchmarkRegistryMatchRequest(b *testing.B) {
+	for _, routes := range []int{10, 100, 500} {
+		registry := benchmarkRegistry(routes)
+		requests := []*http.Request{
+			httptest.NewRequest(http.MethodGet, fmt.Sprintf("/page%d", routes-1), nil),
+			httptest.NewRequest(http.MethodGet, fmt.Sprintf("/shop%d/product/123/name.html", routes/2), nil),
+			httptest.NewRequest(http.MethodPost, fmt.Sprintf("/shop%d/product/123/name.html", routes/2), nil),
+			httptest.NewRequest(http.MethodGet, fmt.Sprintf("/search%d/a/b/c", routes-1), nil),
+			httptest.NewRequest(http.MethodGet, "/not-found", nil),
+		}
+
+		b.Run(fmt.Sprintf("tree/%d", routes), func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				registry.matchRequest(requests[i%len(requests)])
+			}
+		})
+
+		b.Run(fmt.Sprintf("linear/%d", routes), func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				linearMatchRequest(registry, requests[i%len(requests)])
+			}
+		})
+	}
+}
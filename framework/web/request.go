@@ -17,6 +17,10 @@ type (
 		Handler *Handler
 		Params  RequestParams
 		Values  sync.Map
+
+		trustedProxies   TrustedProxies
+		forwardedHeaders string
+		router           *handler
 	}
 
 	// RequestParams store string->string values for request data
@@ -75,7 +79,8 @@ func (r *Request) Session() *Session {
 	return &r.session
 }
 
-// RemoteAddress get the requests real remote address
+// RemoteAddress returns the unverified X-Forwarded-For addresses and the remote address of the connection.
+// Use ClientIP for the address resolved with the trusted proxies.
 func (r *Request) RemoteAddress() []string {
 	var remoteAddress []string
 
@@ -8,9 +8,11 @@ import (
 	"fmt"
 	"io"
 	"log"
+	"math"
 	"net/http"
 	"net/url"
 	"os"
+	"strconv"
 	"strings"
 	"time"
 
@@ -31,6 +33,8 @@ type (
 		logger flamingo.Logger
 		debug  bool
 
+		encoders *encoders
+
 		templateForbidden     string
 		templateBadRequest    string
 		templateNotFound      string
@@ -64,7 +68,9 @@ type (
 	// DataResponse returns a response containing data, e.g. as JSON
 	DataResponse struct {
 		Response
-		Data interface{}
+		Data     interface{}
+		encoders *encoders
+		encoder  Encoder
 	}
 
 	// RenderResponse renders data
@@ -137,6 +143,8 @@ func (r *Responder) Inject(router *Router, logger flamingo.Logger, cfg *struct {
 	TemplateNotFound      string                  `inject:"config:flamingo.template.err404"`
 	TemplateUnavailable   string                  `inject:"config:flamingo.template.err503"`
 	TemplateErrorWithCode string                  `inject:"config:flamingo.template.errWithCode"`
+	Encoders              []Encoder               `inject:",optional"`
+	DefaultMediaType      string                  `inject:"config:flamingo.web.dataResponse.defaultMediaType,optional"`
 }) *Responder {
 	r.engine = cfg.Engine
 	r.router = router
@@ -147,6 +155,7 @@ func (r *Responder) Inject(router *Router, logger flamingo.Logger, cfg *struct {
 	r.templateErrorWithCode = cfg.TemplateErrorWithCode
 	r.logger = logger.WithField("module", "framework.web").WithField("category", "responder")
 	r.debug = cfg.Debug
+	r.encoders = newEncoders(cfg.Encoders, cfg.DefaultMediaType)
 	return r
 }
 
@@ -275,7 +284,8 @@ func (r *URLRedirectResponse) SetNoCache() *URLRedirectResponse {
 // Data returns a data response which can be serialized
 func (r *Responder) Data(data interface{}) *DataResponse {
 	return &DataResponse{
-		Data: data,
+		Data:     data,
+		encoders: r.encoders,
 		Response: Response{
 			Status: http.StatusOK,
 			Header: make(http.Header),
@@ -283,21 +293,59 @@ func (r *Responder) Data(data interface{}) *DataResponse {
 	}
 }
 
-// Apply response
-// todo: support more than json
+// Apply response, the data is encoded with the encoder negotiated from the Accept header
 func (r *DataResponse) Apply(c context.Context, w http.ResponseWriter) error {
+	encoder := r.negotiate(c)
+	if encoder == nil {
+		if r.Response.Status < http.StatusBadRequest {
+			w.Header().Set("Vary", "Accept")
+			http.Error(w, "none of the accepted media types is available, supported: "+strings.Join(r.encoders.mediaTypes(), ", "), http.StatusNotAcceptable)
+			return nil
+		}
+		// errors are sent anyway, using the default encoder
+		encoder = r.encoders.list[0]
+	}
+
 	buf := new(bytes.Buffer)
-	if err := json.NewEncoder(buf).Encode(r.Data); err != nil {
+	if err := encoder.Encode(c, buf, r.Data); err != nil {
 		return err
 	}
 	r.Body = buf
 	if r.Response.Header == nil {
 		r.Response.Header = make(http.Header)
 	}
-	r.Response.Header.Set("Content-Type", "application/json; charset=utf-8")
+	r.Response.Header.Set("Content-Type", encoder.ContentType())
+	if len(r.encoders.list) > 1 {
+		r.Response.Header.Add("Vary", "Accept")
+	}
 	return r.Response.Apply(c, w)
 }
 
+// MediaType returns the media type the data will be encoded in, negotiated from the Accept header of the request.
+// It is empty if the request does not accept any of the available media types.
+func (r *DataResponse) MediaType(ctx context.Context) string {
+	if encoder := r.negotiate(ctx); encoder != nil {
+		return encoder.MediaType()
+	}
+	return ""
+}
+
+func (r *DataResponse) negotiate(ctx context.Context) Encoder {
+	if r.encoders == nil {
+		r.encoders = newEncoders(nil, "")
+	}
+
+	if r.encoder == nil {
+		var accept string
+		if req := RequestFromContext(ctx); req != nil {
+			accept = req.Request().Header.Get("Accept")
+		}
+		r.encoder = r.encoders.negotiate(accept)
+	}
+
+	return r.encoder
+}
+
 // Status changes response status code
 func (r *DataResponse) Status(status uint) *DataResponse {
 	r.Response.Status = status
@@ -402,6 +450,13 @@ func (r *RenderResponse) SetNoCache() *RenderResponse {
 
 // Apply response
 func (r *ServerErrorResponse) Apply(c context.Context, w http.ResponseWriter) error {
+	if wantsProblem(c) {
+		if err := r.applyProblem(c, w); err != nil {
+			http.Error(w, r.ErrString, int(r.RenderResponse.DataResponse.Response.Status))
+		}
+		return nil
+	}
+
 	if r.RenderResponse.DataResponse.Response.Status == 0 {
 		r.RenderResponse.DataResponse.Response.Status = http.StatusInternalServerError
 	}
@@ -438,6 +493,7 @@ func (r *Responder) ServerErrorWithCodeAndTemplate(err error, tpl string, status
 					"code":  status,
 					"error": errstr,
 				},
+				encoders: r.encoders,
 				Response: Response{
 					Status: status,
 					Header: make(http.Header),
@@ -499,11 +555,38 @@ func (r *Responder) ForbiddenWithContext(ctx context.Context, err error) *Server
 	return r.ServerErrorWithCodeAndTemplate(err, r.templateForbidden, http.StatusForbidden)
 }
 
+// MethodNotAllowedWithContext creates a 405 error response with an Allow header listing the allowed methods
+func (r *Responder) MethodNotAllowedWithContext(ctx context.Context, err error, allowed []string) *ServerErrorResponse {
+	r.getLogger().WithContext(ctx).Info(err)
+
+	response := r.ServerErrorWithCodeAndTemplate(err, r.templateErrorWithCode, http.StatusMethodNotAllowed)
+	response.Header.Set("Allow", strings.Join(allowed, ", "))
+
+	return response
+}
+
+// TooManyRequestsWithContext creates a 429 error response with a Retry-After header in seconds
+func (r *Responder) TooManyRequestsWithContext(ctx context.Context, err error, retryAfter time.Duration) *ServerErrorResponse {
+	r.getLogger().WithContext(ctx).Info(err)
+
+	response := r.ServerErrorWithCodeAndTemplate(err, r.templateErrorWithCode, http.StatusTooManyRequests)
+	response.Header.Set("Retry-After", strconv.FormatInt(int64(math.Ceil(retryAfter.Seconds())), 10))
+
+	return response
+}
+
 // BadRequestWithContext creates a 400 error response and uses the provided context for enhanced logging
 func (r *Responder) BadRequestWithContext(ctx context.Context, err error) *ServerErrorResponse {
 	r.getLogger().WithContext(ctx).Info(err)
 
-	return r.ServerErrorWithCodeAndTemplate(err, r.templateForbidden, http.StatusBadRequest)
+	response := r.ServerErrorWithCodeAndTemplate(err, r.templateForbidden, http.StatusBadRequest)
+
+	var bindErr *BindError
+	if errors.As(err, &bindErr) {
+		response.RenderResponse.DataResponse.Data.(map[string]interface{})["fields"] = bindErr.Fields
+	}
+
+	return response
 }
 
 // SetNoCache helper
@@ -529,10 +612,17 @@ func (r *Responder) getLogger() flamingo.Logger {
 
 func (r *Responder) completeResult(result Result) Result {
 	switch result := result.(type) {
+	case *DataResponse:
+		if result.encoders == nil {
+			result.encoders = r.encoders
+		}
 	case *RenderResponse:
 		if result.engine == nil {
 			result.engine = r.engine
 		}
+		if result.encoders == nil {
+			result.encoders = r.encoders
+		}
 	case *RouteRedirectResponse:
 		if result.router == nil {
 			result.router = r.router
@@ -541,6 +631,9 @@ func (r *Responder) completeResult(result Result) Result {
 		if result.engine == nil {
 			result.engine = r.engine
 		}
+		if result.encoders == nil {
+			result.encoders = r.encoders
+		}
 	}
 	return result
 }
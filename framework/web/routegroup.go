@@ -0,0 +1,142 @@
+package web
+
+import (
+	"net/http"
+	"sort"
+	"strings"
+)
+
+type (
+	// RouteGroup registers routes and actions with a shared path prefix, handler name prefix and filter chain.
+	// The group filters run after the global filters for every route registered through the group.
+	RouteGroup struct {
+		registry   *RouterRegistry
+		pathPrefix string
+		namePrefix string
+		filters    []Filter
+
+		problemDetails bool
+	}
+)
+
+func normalizePathPrefix(prefix string) string {
+	prefix = strings.Trim(prefix, "/")
+	if prefix == "" {
+		return ""
+	}
+	return "/" + prefix
+}
+
+// Group creates a new RouteGroup for the registry
+func (registry *RouterRegistry) Group(pathPrefix, namePrefix string, filters ...Filter) *RouteGroup {
+	return &RouteGroup{
+		registry:   registry,
+		pathPrefix: normalizePathPrefix(pathPrefix),
+		namePrefix: namePrefix,
+		filters:    filters,
+	}
+}
+
+// Group creates a nested RouteGroup, which inherits the prefixes and filters of the group
+func (group *RouteGroup) Group(pathPrefix, namePrefix string, filters ...Filter) *RouteGroup {
+	return &RouteGroup{
+		registry:   group.registry,
+		pathPrefix: group.pathPrefix + normalizePathPrefix(pathPrefix),
+		namePrefix: group.namePrefix + namePrefix,
+		filters:    append(append(make([]Filter, 0, len(group.filters)+len(filters)), group.filters...), filters...),
+
+		problemDetails: group.problemDetails,
+	}
+}
+
+// WithProblemDetails answers errors of all routes of the group with problem details, this is inherited by nested groups
+func (group *RouteGroup) WithProblemDetails() *RouteGroup {
+	group.problemDetails = true
+	return group
+}
+
+// Route assigns a route to a Handler, both prefixed by the group
+func (group *RouteGroup) Route(path, handler string) (*Handler, error) {
+	h, err := group.registry.Route(group.pathPrefix+path, group.namePrefix+handler)
+	if err != nil {
+		return nil, err
+	}
+	if group.problemDetails {
+		h.WithProblemDetails()
+	}
+	return h.WithFilters(group.filters...), nil
+}
+
+// MustRoute makes a checked Route call
+func (group *RouteGroup) MustRoute(path, handler string) *Handler {
+	return MustRoute(group.Route(path, handler))
+}
+
+// HandleAny serves as a fallback to handle HTTP requests which are not taken care of by other handlers
+func (group *RouteGroup) HandleAny(name string, action Action) {
+	group.registry.HandleAny(group.namePrefix+name, action)
+}
+
+// HandleData sets the controllers data action
+func (group *RouteGroup) HandleData(name string, action DataAction) {
+	group.registry.HandleData(group.namePrefix+name, action)
+}
+
+// HandleMethod handles requests for the specified HTTP Method
+func (group *RouteGroup) HandleMethod(method, name string, action Action) {
+	group.registry.HandleMethod(method, group.namePrefix+name, action)
+}
+
+// HandleGet handles a HTTP GET request
+func (group *RouteGroup) HandleGet(name string, action Action) {
+	group.HandleMethod(http.MethodGet, name, action)
+}
+
+// HandlePost handles HTTP POST requests
+func (group *RouteGroup) HandlePost(name string, action Action) {
+	group.HandleMethod(http.MethodPost, name, action)
+}
+
+// HandlePut handles HTTP PUT requests
+func (group *RouteGroup) HandlePut(name string, action Action) {
+	group.HandleMethod(http.MethodPut, name, action)
+}
+
+// HandleDelete handles HTTP DELETE requests
+func (group *RouteGroup) HandleDelete(name string, action Action) {
+	group.HandleMethod(http.MethodDelete, name, action)
+}
+
+// HandleOptions handles HTTP OPTIONS requests
+func (group *RouteGroup) HandleOptions(name string, action Action) {
+	group.HandleMethod(http.MethodOptions, name, action)
+}
+
+// HandleHead handles HTTP HEAD requests
+func (group *RouteGroup) HandleHead(name string, action Action) {
+	group.HandleMethod(http.MethodHead, name, action)
+}
+
+// HandleWebSocket upgrades HTTP GET requests to a WebSocket connection
+func (group *RouteGroup) HandleWebSocket(name string, action WebSocketAction) {
+	group.registry.HandleWebSocket(group.namePrefix+name, action)
+}
+
+// WithFilters adds filters which only run for requests matched by this route.
+// Route filters run after the global filters, ordered by their priority.
+func (handler *Handler) WithFilters(filters ...Filter) *Handler {
+	if len(filters) == 0 {
+		return handler
+	}
+
+	sortable := newSortableFilters(append(handler.filters, filters...))
+	sort.Sort(sort.Reverse(sortable))
+	handler.filters = sortable.toFilters()
+
+	return handler
+}
+
+// GetFilters returns the filters which only apply to this route
+func (handler *Handler) GetFilters() []Filter {
+	return handler.filters
+}
@@ -0,0 +1,127 @@
+package web
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"flamingo.me/flamingo/v3/framework/flamingo"
+)
+
+type recordingFilter struct {
+	name string
+	log  *[]string
+}
+
+func (f *recordingFilter) Filter(ctx context.Context, req *Request, w http.ResponseWriter, fc *FilterChain) Result {
+	*f.log = append(*f.log, f.name)
+	return fc.Next(ctx, req, w)
+}
+
+type prioritizedRecordingFilter struct {
+	recordingFilter
+	priority int
+}
+
+func (f *prioritizedRecordingFilter) Priority() int {
+	return f.priority
+}
+
+func TestRouteGroup(t *testing.T) {
+	t.Run("prefixes paths and handler names", func(t *testing.T) {
+		registry := NewRegistry()
+		api := registry.Group("/api/", "api.")
+		v1 := api.Group("v1", "v1.")
+
+		api.HandleGet("status", testController)
+		v1.HandleGet("product.view", testController)
+		v1.MustRoute("/product/:id", "product.view(id)")
+		api.MustRoute("/status", "status")
+
+		assert.True(t, registry.Has(http.MethodGet, "api.status"))
+		assert.True(t, registry.Has(http.MethodGet, "api.v1.product.view"))
+
+		p, err := registry.Reverse("api.v1.product.view", map[string]string{"id": "1"})
+		require.NoError(t, err)
+		assert.Equal(t, "/api/v1/product/1", p)
+
+		p, err = registry.Reverse("api.status", nil)
+		require.NoError(t, err)
+		assert.Equal(t, "/api/status", p)
+
+		controller, params := registry.match("/api/v1/product/2")
+		assert.NotNil(t, controller.method[http.MethodGet])
+		assert.Equal(t, "2", params["id"])
+	})
+
+	t.Run("nested groups inherit filters", func(t *testing.T) {
+		var log []string
+		outer := &recordingFilter{name: "outer", log: &log}
+		inner := &recordingFilter{name: "inner", log: &log}
+		route := &prioritizedRecordingFilter{recordingFilter: recordingFilter{name: "route", log: &log}, priority: 10}
+
+		registry := NewRegistry()
+		group := registry.Group("/a", "a.", outer)
+		nested := group.Group("/b", "b.", inner)
+
+		h := nested.MustRoute("/c", "c").WithFilters(route)
+		assert.Equal(t, []Filter{route, outer, inner}, h.GetFilters())
+
+		h = group.MustRoute("/c", "c")
+		assert.Equal(t, []Filter{outer}, h.GetFilters())
+
+		h = registry.MustRoute("/c", "c")
+		assert.Empty(t, h.GetFilters())
+	})
+
+	t.Run("route filters run after global filters", func(t *testing.T) {
+		var log []string
+		global := &recordingFilter{name: "global", log: &log}
+		scoped := &recordingFilter{name: "scoped", log: &log}
+
+		registry := NewRegistry()
+		registry.HandleAny(FlamingoNotfound, func(context.Context, *Request) Result {
+			log = append(log, "notfound")
+			return &Response{Status: http.StatusNotFound}
+		})
+		group := registry.Group("/scoped", "scoped.", scoped)
+		group.HandleGet("view", func(context.Context, *Request) Result {
+			log = append(log, "scoped action")
+			return &Response{Status: http.StatusOK, Body: strings.NewReader("scoped")}
+		})
+		group.MustRoute("/view", "view")
+		registry.HandleGet("view", func(context.Context, *Request) Result {
+			log = append(log, "action")
+			return &Response{Status: http.StatusOK, Body: strings.NewReader("unscoped")}
+		})
+		registry.MustRoute("/view", "view")
+
+		router := &Router{
+			eventRouter:    new(flamingo.DefaultEventRouter),
+			filterProvider: func() []Filter { return []Filter{global} },
+			routesProvider: func() []RoutesModule { return nil },
+			logger:         flamingo.NullLogger{},
+		}
+		h := router.Handler()
+		h.(*handler).routerRegistry = registry
+
+		recorder := httptest.NewRecorder()
+		h.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/scoped/view", nil))
+		body, _ := io.ReadAll(recorder.Body)
+		assert.Equal(t, "scoped", string(body))
+		assert.Equal(t, []string{"global", "scoped", "scoped action"}, log)
+
+		log = nil
+		recorder = httptest.NewRecorder()
+		h.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/view", nil))
+		body, _ = io.ReadAll(recorder.Body)
+		assert.Equal(t, "unscoped", string(body))
+		assert.Equal(t, []string{"global", "action"}, log)
+	})
+}
@@ -44,6 +44,11 @@ type (
 		sessionStore      *SessionStore
 		sessionName       string
 		responderProvider responderProvider
+		methodNotAllowed  bool
+		autoOptions       bool
+		autoHead          bool
+		trustedProxies    TrustedProxies
+		forwardedHeaders  string
 	}
 
 	// AreaRoutedEvent is dispatched when the router initializes the Handler
@@ -68,6 +73,13 @@ func (r *Router) Inject(
 		Path        string `inject:"config:flamingo.router.path,optional"`
 		External    string `inject:"config:flamingo.router.external,optional"`
 		SessionName string `inject:"config:flamingo.session.name,optional"`
+		// method handling
+		MethodNotAllowed bool `inject:"config:flamingo.router.methodNotAllowed,optional"`
+		AutoOptions      bool `inject:"config:flamingo.router.autoOptions,optional"`
+		AutoHead         bool `inject:"config:flamingo.router.autoHead,optional"`
+		// reverse proxies
+		TrustedProxies   config.Slice `inject:"config:flamingo.router.trustedProxies,optional"`
+		ForwardedHeaders string       `inject:"config:flamingo.router.forwardedHeaders,optional"`
 	},
 	sessionStore *SessionStore,
 	eventRouter flamingo.EventRouter,
@@ -100,6 +112,29 @@ func (r *Router) Inject(
 		r.sessionName = cfg.SessionName
 	}
 	r.responderProvider = responderProvider
+	r.methodNotAllowed = cfg.MethodNotAllowed
+	r.autoOptions = cfg.AutoOptions
+	r.autoHead = cfg.AutoHead
+
+	var trustedProxies []string
+	if err := cfg.TrustedProxies.MapInto(&trustedProxies); err != nil {
+		panic(fmt.Errorf("invalid trusted proxies on %q: %w", "flamingo.router.trustedProxies", err))
+	}
+
+	proxies, err := ParseTrustedProxies(trustedProxies)
+	if err != nil {
+		panic(fmt.Errorf("invalid trusted proxies on %q: %w", "flamingo.router.trustedProxies", err))
+	}
+	r.trustedProxies = proxies
+
+	switch cfg.ForwardedHeaders {
+	case "", forwardedHeadersXForwarded:
+		r.forwardedHeaders = forwardedHeadersXForwarded
+	case forwardedHeadersForwarded:
+		r.forwardedHeaders = forwardedHeadersForwarded
+	default:
+		panic(fmt.Errorf("invalid forwarded headers on %q: %q", "flamingo.router.forwardedHeaders", cfg.ForwardedHeaders))
+	}
 }
 
 // Handler creates and returns new instance of http.Handler interface
@@ -142,6 +177,12 @@ func (r *Router) Handler() http.Handler {
 		sessionName:    r.sessionName,
 		prefix:         strings.TrimRight(r.Base().Path, "/"),
 		responder:      r.responderProvider(),
+
+		methodNotAllowed: r.methodNotAllowed,
+		autoOptions:      r.autoOptions,
+		autoHead:         r.autoHead,
+		trustedProxies:   r.trustedProxies,
+		forwardedHeaders: r.forwardedHeaders,
 	}
 }
 
@@ -223,15 +264,15 @@ func (r *Router) Absolute(req *Request, to string, params map[string]string) (*u
 	host := r.Base().Host
 
 	if scheme == "" {
-		if req != nil && req.request.TLS != nil {
-			scheme = "https"
+		if req != nil {
+			scheme = req.Scheme()
 		} else {
 			scheme = "http"
 		}
 	}
 
 	if host == "" && req != nil {
-		host = req.request.Host
+		host = req.Host()
 	}
 
 	u, err := r.Relative(to, params)
@@ -7,11 +7,13 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"net/url"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
+	"flamingo.me/flamingo/v3/framework/config"
 	"flamingo.me/flamingo/v3/framework/flamingo"
 )
 
@@ -147,6 +149,147 @@ func TestRouter(t *testing.T) {
 	})
 }
 
+type filterFunc func(ctx context.Context, req *Request, w http.ResponseWriter, chain *FilterChain) Result
+
+func (f filterFunc) Filter(ctx context.Context, req *Request, w http.ResponseWriter, chain *FilterChain) Result {
+	return f(ctx, req, w, chain)
+}
+
+func TestRouterMethodHandling(t *testing.T) {
+	registry := NewRegistry()
+	registry.HandleAny(FlamingoNotfound, func(context.Context, *Request) Result {
+		return &Response{Status: http.StatusNotFound}
+	})
+	registry.HandleGet("test", func(context.Context, *Request) Result {
+		return &Response{Status: http.StatusOK, Header: http.Header{"X-Test": []string{"get"}}, Body: strings.NewReader("body")}
+	})
+	registry.HandlePost("test", func(context.Context, *Request) Result { return &Response{Status: http.StatusCreated} })
+	registry.MustRoute("/test", "test")
+	registry.HandlePut("other", func(context.Context, *Request) Result { return &Response{Status: http.StatusOK} })
+	registry.MustRoute("/test", "other")
+	registry.HandleGet("mustget", func(context.Context, *Request) Result { return &Response{Status: http.StatusOK} })
+	registry.MustRoute("/mustget", "mustget(page)")
+
+	router := &Router{
+		eventRouter:    new(flamingo.DefaultEventRouter),
+		filterProvider: func() []Filter { return nil },
+		routesProvider: func() []RoutesModule { return nil },
+		logger:         flamingo.NullLogger{},
+	}
+
+	serve := func(h http.Handler, method, path string) *httptest.ResponseRecorder {
+		recorder := httptest.NewRecorder()
+		h.ServeHTTP(recorder, httptest.NewRequest(method, path, nil))
+		return recorder
+	}
+
+	t.Run("enabled", func(t *testing.T) {
+		router.methodNotAllowed, router.autoOptions, router.autoHead = true, true, true
+		h := router.Handler()
+		h.(*handler).routerRegistry = registry
+
+		res := serve(h, http.MethodDelete, "/test")
+		assert.Equal(t, http.StatusMethodNotAllowed, res.Code)
+		assert.Equal(t, "GET, HEAD, OPTIONS, POST, PUT", res.Header().Get("Allow"))
+
+		res = serve(h, http.MethodOptions, "/test")
+		assert.Equal(t, http.StatusNoContent, res.Code)
+		assert.Equal(t, "GET, HEAD, OPTIONS, POST, PUT", res.Header().Get("Allow"))
+
+		res = serve(h, http.MethodHead, "/test")
+		assert.Equal(t, http.StatusOK, res.Code)
+		assert.Equal(t, "get", res.Header().Get("X-Test"))
+		assert.Empty(t, res.Body.String())
+
+		res = serve(h, http.MethodPut, "/test")
+		assert.Equal(t, http.StatusOK, res.Code)
+
+		res = serve(h, http.MethodGet, "/unknown")
+		assert.Equal(t, http.StatusNotFound, res.Code)
+
+		res = serve(h, http.MethodPost, "/mustget")
+		assert.Equal(t, http.StatusNotFound, res.Code, "routes with missing params do not match")
+
+		res = serve(h, http.MethodPost, "/mustget?page=1")
+		assert.Equal(t, http.StatusMethodNotAllowed, res.Code)
+		assert.Equal(t, "GET, HEAD, OPTIONS", res.Header().Get("Allow"))
+	})
+
+	t.Run("disabled", func(t *testing.T) {
+		router.methodNotAllowed, router.autoOptions, router.autoHead = false, false, false
+		h := router.Handler()
+		h.(*handler).routerRegistry = registry
+
+		res := serve(h, http.MethodDelete, "/test")
+		assert.Equal(t, http.StatusNotFound, res.Code)
+		assert.Empty(t, res.Header().Get("Allow"))
+
+		res = serve(h, http.MethodOptions, "/test")
+		assert.Equal(t, http.StatusNotFound, res.Code)
+
+		res = serve(h, http.MethodHead, "/test")
+		assert.Equal(t, http.StatusNotFound, res.Code)
+	})
+
+	t.Run("method not allowed without automatic options", func(t *testing.T) {
+		router.methodNotAllowed, router.autoOptions, router.autoHead = true, false, false
+		h := router.Handler()
+		h.(*handler).routerRegistry = registry
+
+		res := serve(h, http.MethodOptions, "/test")
+		assert.Equal(t, http.StatusMethodNotAllowed, res.Code)
+		assert.Equal(t, "GET, POST, PUT", res.Header().Get("Allow"))
+	})
+
+	t.Run("preflight requests match the requested method", func(t *testing.T) {
+		router.methodNotAllowed, router.autoOptions, router.autoHead = false, false, false
+		h := router.Handler()
+		h.(*handler).routerRegistry = registry
+
+		var handlerName string
+		h.(*handler).filter = []Filter{lastFilter(func(ctx context.Context, req *Request, w http.ResponseWriter) Result {
+			if req.Handler != nil {
+				handlerName = req.Handler.GetHandlerName()
+			}
+			return &Response{Status: http.StatusNoContent}
+		})}
+
+		recorder := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodOptions, "/test", nil)
+		req.Header.Set("Access-Control-Request-Method", http.MethodPut)
+		h.ServeHTTP(recorder, req)
+		assert.Equal(t, "other", handlerName)
+
+		handlerName = ""
+		res := serve(h, http.MethodOptions, "/test")
+		assert.Empty(t, handlerName, "options requests without preflight header are not matched")
+		assert.Equal(t, http.StatusNoContent, res.Code)
+	})
+
+	t.Run("preflight requests match the requested method with automatic options", func(t *testing.T) {
+		router.methodNotAllowed, router.autoOptions, router.autoHead = true, true, true
+		h := router.Handler()
+		h.(*handler).routerRegistry = registry
+
+		var handlerName string
+		h.(*handler).filter = []Filter{filterFunc(func(ctx context.Context, req *Request, w http.ResponseWriter, chain *FilterChain) Result {
+			handlerName = req.Handler.GetHandlerName()
+			return chain.Next(ctx, req, w)
+		})}
+
+		for method, expected := range map[string]string{http.MethodPut: "other", http.MethodPost: "test"} {
+			recorder := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodOptions, "/test", nil)
+			req.Header.Set("Access-Control-Request-Method", method)
+			h.ServeHTTP(recorder, req)
+
+			assert.Equal(t, expected, handlerName)
+			assert.Equal(t, http.StatusNoContent, recorder.Code, "preflights without cors filter are answered automatically")
+			assert.Equal(t, "GET, HEAD, OPTIONS, POST, PUT", recorder.Header().Get("Allow"))
+		}
+	})
+}
+
 func TestRouterTestify(t *testing.T) {
 	registry := NewRegistry()
 	_, err := registry.Route("/test", "test")
@@ -196,6 +339,13 @@ func TestRouterRelativeAndAbsolute(t *testing.T) {
 			Path        string `inject:"config:flamingo.router.path,optional"`
 			External    string `inject:"config:flamingo.router.external,optional"`
 			SessionName string `inject:"config:flamingo.session.name,optional"`
+			// method handling
+			MethodNotAllowed bool `inject:"config:flamingo.router.methodNotAllowed,optional"`
+			AutoOptions      bool `inject:"config:flamingo.router.autoOptions,optional"`
+			AutoHead         bool `inject:"config:flamingo.router.autoHead,optional"`
+			// reverse proxies
+			TrustedProxies   config.Slice `inject:"config:flamingo.router.trustedProxies,optional"`
+			ForwardedHeaders string       `inject:"config:flamingo.router.forwardedHeaders,optional"`
 		}{
 			Scheme:      scheme,
 			Host:        host,
@@ -521,4 +671,160 @@ func TestRouterRelativeAndAbsolute(t *testing.T) {
 		assert.NoError(t, err)
 		assert.Equal(t, "http://external.domain/external-path/test", absoluteURL.String())
 	})
+	t.Run("Test without scheme, without host, with trusted proxy", func(t *testing.T) {
+		router := setupRouter("", "", "", "")
+
+		req := httptest.NewRequest(http.MethodGet, "http://internal.host/flamingo", nil)
+		req.RemoteAddr = "10.0.0.1:1234"
+		req.Header.Set("X-Forwarded-Proto", "https")
+		req.Header.Set("X-Forwarded-Host", "flamingo.me")
+
+		request := CreateRequest(req, nil)
+		absoluteURL, err := router.Absolute(request, "test", nil)
+		assert.NoError(t, err)
+		assert.Equal(t, "http://internal.host/test", absoluteURL.String(), "headers of untrusted proxies are ignored")
+
+		request.trustedProxies, err = ParseTrustedProxies([]string{"10.0.0.0/8"})
+		require.NoError(t, err)
+		absoluteURL, err = router.Absolute(request, "test", nil)
+		assert.NoError(t, err)
+		assert.Equal(t, "https://flamingo.me/test", absoluteURL.String())
+	})
+}
+
+func TestRouterTrustedProxies(t *testing.T) {
+	router := &Router{
+		eventRouter:    new(flamingo.DefaultEventRouter),
+		filterProvider: func() []Filter { return nil },
+		routesProvider: func() []RoutesModule { return nil },
+		logger:         flamingo.NullLogger{},
+	}
+	router.Inject(&struct {
+		Scheme      string `inject:"config:flamingo.router.scheme,optional"`
+		Host        string `inject:"config:flamingo.router.host,optional"`
+		Path        string `inject:"config:flamingo.router.path,optional"`
+		External    string `inject:"config:flamingo.router.external,optional"`
+		SessionName string `inject:"config:flamingo.session.name,optional"`
+		// method handling
+		MethodNotAllowed bool `inject:"config:flamingo.router.methodNotAllowed,optional"`
+		AutoOptions      bool `inject:"config:flamingo.router.autoOptions,optional"`
+		AutoHead         bool `inject:"config:flamingo.router.autoHead,optional"`
+		// reverse proxies
+		TrustedProxies   config.Slice `inject:"config:flamingo.router.trustedProxies,optional"`
+		ForwardedHeaders string       `inject:"config:flamingo.router.forwardedHeaders,optional"`
+	}{
+		TrustedProxies:   config.Slice{"10.0.0.0/8", "192.0.2.10"},
+		ForwardedHeaders: "forwarded",
+	}, nil, new(flamingo.DefaultEventRouter), func() []Filter { return nil }, func() []RoutesModule { return nil }, flamingo.NullLogger{}, nil, nil)
+
+	var clientIP, scheme, host string
+	registry := NewRegistry()
+	registry.HandleGet("test", func(_ context.Context, r *Request) Result {
+		clientIP, scheme, host = r.ClientIP(), r.Scheme(), r.Host()
+		return &Response{Status: http.StatusOK}
+	})
+	registry.MustRoute("/test", "test")
+
+	h := router.Handler()
+	h.(*handler).routerRegistry = registry
+
+	req := httptest.NewRequest(http.MethodGet, "http://internal.host/test", nil)
+	req.RemoteAddr = "192.0.2.10:1234"
+	req.Header.Set("Forwarded", `for=198.51.100.7;proto=https;host=flamingo.me, for="10.1.2.3:8080"`)
+	h.ServeHTTP(httptest.NewRecorder(), req)
+
+	assert.Equal(t, "198.51.100.7", clientIP)
+	assert.Equal(t, "https", scheme)
+	assert.Equal(t, "flamingo.me", host)
+
+	assert.Panics(t, func() {
+		router.Inject(&struct {
+			Scheme      string `inject:"config:flamingo.router.scheme,optional"`
+			Host        string `inject:"config:flamingo.router.host,optional"`
+			Path        string `inject:"config:flamingo.router.path,optional"`
+			External    string `inject:"config:flamingo.router.external,optional"`
+			SessionName string `inject:"config:flamingo.session.name,optional"`
+			// method handling
+			MethodNotAllowed bool `inject:"config:flamingo.router.methodNotAllowed,optional"`
+			AutoOptions      bool `inject:"config:flamingo.router.autoOptions,optional"`
+			AutoHead         bool `inject:"config:flamingo.router.autoHead,optional"`
+			// reverse proxies
+			TrustedProxies   config.Slice `inject:"config:flamingo.router.trustedProxies,optional"`
+			ForwardedHeaders string       `inject:"config:flamingo.router.forwardedHeaders,optional"`
+		}{
+			TrustedProxies: config.Slice{"proxy.local"},
+		}, nil, new(flamingo.DefaultEventRouter), func() []Filter { return nil }, func() []RoutesModule { return nil }, flamingo.NullLogger{}, nil, nil)
+	}, "invalid trusted proxies are rejected")
+
+	assert.Panics(t, func() {
+		router.Inject(&struct {
+			Scheme      string `inject:"config:flamingo.router.scheme,optional"`
+			Host        string `inject:"config:flamingo.router.host,optional"`
+			Path        string `inject:"config:flamingo.router.path,optional"`
+			External    string `inject:"config:flamingo.router.external,optional"`
+			SessionName string `inject:"config:flamingo.session.name,optional"`
+			// method handling
+			MethodNotAllowed bool `inject:"config:flamingo.router.methodNotAllowed,optional"`
+			AutoOptions      bool `inject:"config:flamingo.router.autoOptions,optional"`
+			AutoHead         bool `inject:"config:flamingo.router.autoHead,optional"`
+			// reverse proxies
+			TrustedProxies   config.Slice `inject:"config:flamingo.router.trustedProxies,optional"`
+			ForwardedHeaders string       `inject:"config:flamingo.router.forwardedHeaders,optional"`
+		}{
+			ForwardedHeaders: "x-real-ip",
+		}, nil, new(flamingo.DefaultEventRouter), func() []Filter { return nil }, func() []RoutesModule { return nil }, flamingo.NullLogger{}, nil, nil)
+	}, "unknown forwarded headers are rejected")
+}
+
+func TestRedispatch(t *testing.T) {
+	type redispatchKey struct{}
+
+	router := &Router{
+		eventRouter:    new(flamingo.DefaultEventRouter),
+		filterProvider: func() []Filter { return nil },
+		routesProvider: func() []RoutesModule { return nil },
+		logger:         flamingo.NullLogger{},
+	}
+	router.Inject(&struct {
+		Scheme      string `inject:"config:flamingo.router.scheme,optional"`
+		Host        string `inject:"config:flamingo.router.host,optional"`
+		Path        string `inject:"config:flamingo.router.path,optional"`
+		External    string `inject:"config:flamingo.router.external,optional"`
+		SessionName string `inject:"config:flamingo.session.name,optional"`
+		// method handling
+		MethodNotAllowed bool `inject:"config:flamingo.router.methodNotAllowed,optional"`
+		AutoOptions      bool `inject:"config:flamingo.router.autoOptions,optional"`
+		AutoHead         bool `inject:"config:flamingo.router.autoHead,optional"`
+		// reverse proxies
+		TrustedProxies   config.Slice `inject:"config:flamingo.router.trustedProxies,optional"`
+		ForwardedHeaders string       `inject:"config:flamingo.router.forwardedHeaders,optional"`
+	}{
+		Path: "/en",
+	}, nil, new(flamingo.DefaultEventRouter), func() []Filter { return nil }, func() []RoutesModule { return nil }, flamingo.NullLogger{}, nil, nil)
+
+	var served []string
+	var serve func(rw http.ResponseWriter)
+	registry := NewRegistry()
+	registry.HandleGet("test", func(ctx context.Context, r *Request) Result {
+		served = append(served, r.Request().URL.String())
+		if ctx.Value(redispatchKey{}) == nil {
+			var ok bool
+			serve, ok = Redispatch(context.WithValue(ctx, redispatchKey{}, true), r)
+			assert.True(t, ok)
+		}
+		return &Response{Status: http.StatusOK}
+	})
+	registry.MustRoute("/test", "test")
+
+	h := router.Handler()
+	h.(*handler).routerRegistry = registry
+
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "http://flamingo.me/en/test?q=1", nil))
+	require.NotNil(t, serve)
+	serve(httptest.NewRecorder())
+
+	assert.Equal(t, []string{"http://flamingo.me/test?q=1", "http://flamingo.me/test?q=1"}, served, "the copy is served with the context by the same router")
+
+	_, ok := Redispatch(context.Background(), CreateRequest(nil, nil))
+	assert.False(t, ok, "requests not served by a router can not be dispatched again")
 }
@@ -48,15 +48,33 @@ func dumpRoutes(router *Router) {
 	if router.routerRegistry == nil {
 		router.Handler()
 	}
+	var globalFilters []Filter
+	if router.filterProvider != nil {
+		globalFilters = router.filterProvider()
+	}
+	fmt.Println()
+	fmt.Println("Global filters: " + filterNames(globalFilters))
 	fmt.Println()
 	fmt.Println("***************************************************************************")
-	fmt.Println(" Route                						| Handler-Name:               ")
+	fmt.Println(" Route                						| Handler-Name:               | Route-Filters:")
 	fmt.Println("****************************************************************************")
 	for _, routeHandler := range router.routerRegistry.routes {
 		routePath := routeHandler.path.path + "(" + strings.Join(routeHandler.path.params, ";") + ")"
 		spaceAmount1 := int(math.Max(0, float64(60-len(routePath))))
-		fmt.Printf("    %s%s| %s\n", routePath, strings.Repeat(" ", spaceAmount1), routeHandler.handler)
+		spaceAmount2 := int(math.Max(0, float64(28-len(routeHandler.handler))))
+		fmt.Printf("    %s%s| %s%s| %s\n", routePath, strings.Repeat(" ", spaceAmount1), routeHandler.handler, strings.Repeat(" ", spaceAmount2), filterNames(routeHandler.filters))
+	}
+}
+
+func filterNames(filters []Filter) string {
+	if len(filters) == 0 {
+		return "-"
+	}
+	names := make([]string, len(filters))
+	for i, filter := range filters {
+		names[i] = fmt.Sprintf("%T", filter)
 	}
+	return strings.Join(names, " ; ")
 }
 
 func dumpHandler(router *Router) {
@@ -0,0 +1,141 @@
+package web
+
+import (
+	"sort"
+	"strings"
+)
+
+type (
+	// routeTree is a prefix tree over the parts of all registered paths.
+	// Fixed parts are split into their segments and indexed by segment, params, regex and wildcard parts
+	// are tried in registration order. Matching only visits branches which can still match the request path.
+	routeTree struct {
+		root *routeNode
+	}
+
+	routeNode struct {
+		static  map[string]*routeNode
+		dynamic []*routeNode
+		part    part
+		key     string
+		routes  []routeEntry
+	}
+
+	routeEntry struct {
+		index   int
+		handler *Handler
+	}
+
+	routeMatch struct {
+		routeEntry
+		match *Match
+	}
+)
+
+func newRouteTree() *routeTree {
+	return &routeTree{root: new(routeNode)}
+}
+
+// partKey returns an identifier for a dynamic part, parts with the same key share a node in the tree
+func partKey(p part) string {
+	switch p := p.(type) {
+	case *partParam:
+		return ":" + p.name + p.suffix
+	case *partRegex:
+		return "$" + p.name + "<" + p.regex.String() + ">"
+	case *partWildcard:
+		return "*" + p.name
+	}
+	return ""
+}
+
+func (n *routeNode) staticChild(segment string) *routeNode {
+	if n.static == nil {
+		n.static = make(map[string]*routeNode)
+	}
+	child, ok := n.static[segment]
+	if !ok {
+		child = new(routeNode)
+		n.static[segment] = child
+	}
+	return child
+}
+
+func (n *routeNode) dynamicChild(p part) *routeNode {
+	key := partKey(p)
+	for _, child := range n.dynamic {
+		if child.key == key {
+			return child
+		}
+	}
+	child := &routeNode{part: p, key: key}
+	n.dynamic = append(n.dynamic, child)
+	return child
+}
+
+// insert adds a handler, index is used to keep the registration order as precedence
+func (t *routeTree) insert(handler *Handler, index int) {
+	node := t.root
+	for _, p := range handler.path.parts {
+		if fixed, ok := p.(*partFixed); ok {
+			for _, segment := range strings.Split(fixed.part, "/") {
+				node = node.staticChild(segment)
+			}
+			continue
+		}
+		node = node.dynamicChild(p)
+	}
+	node.routes = append(node.routes, routeEntry{index: index, handler: handler})
+}
+
+// match returns all handlers matching the path, ordered by registration
+func (t *routeTree) match(path string) []routeMatch {
+	var matches []routeMatch
+	t.root.collect(path, nil, &matches)
+
+	sort.Slice(matches, func(i, j int) bool {
+		return matches[i].index < matches[j].index
+	})
+
+	return matches
+}
+
+// collect walks the tree the same way Path.Match consumes a path, values holds pairs of param names and values
+func (n *routeNode) collect(path string, values []string, matches *[]routeMatch) {
+	if len(n.routes) > 0 && (path == "" || path == "/") {
+		for _, route := range n.routes {
+			match := &Match{Values: make(map[string]string, len(values)/2)}
+			for i := 0; i < len(values); i += 2 {
+				match.Values[values[i]] = values[i+1]
+			}
+			*matches = append(*matches, routeMatch{routeEntry: route, match: match})
+		}
+	}
+
+	if len(path) < 1 || path[0] != '/' {
+		return
+	}
+	path = path[1:]
+
+	if n.static != nil {
+		segment := path
+		if pos := strings.IndexByte(path, '/'); pos >= 0 {
+			segment = path[:pos]
+		}
+		if child, ok := n.static[segment]; ok {
+			child.collect(path[len(segment):], values, matches)
+		}
+	}
+
+	for _, child := range n.dynamic {
+		matched, key, value, length := child.part.match(path)
+		if !matched {
+			continue
+		}
+		childValues := values
+		if key != "" {
+			childValues = append(values[:len(values):len(values)], key, value)
+		}
+		child.collect(path[length:], childValues, matches)
+	}
+}
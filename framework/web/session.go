@@ -16,6 +16,7 @@ type Session struct {
 	hashedid        string
 	dirty           map[interface{}]struct{}
 	dirtyAll        bool
+	regenerate      bool
 	sessionSaveMode sessionPersistLevel
 }
 
@@ -117,6 +118,17 @@ func (s *Session) ID() (id string) {
 	return s.s.ID
 }
 
+// Regenerate assigns a new id to the session when it is saved.
+// The data is migrated to the new id and the backend entry of the old id is deleted,
+// which prevents session fixation when the privilege level changes, e.g. on login or logout.
+func (s *Session) Regenerate() *Session {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.regenerate = true
+	return s
+}
+
 // Keys returns an unordered list of session keys
 // Deprecated: please know what you will need
 func (s *Session) Keys() []interface{} {
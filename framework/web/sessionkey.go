@@ -0,0 +1,136 @@
+package web
+
+import (
+	"bytes"
+	"encoding/gob"
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+type (
+	// SessionKey provides typed access to a session value.
+	// Values are stored encoded by the codec of the key, so they survive the serialization of all session backends
+	// without registering the type with gob.Register.
+	SessionKey[T any] struct {
+		name  string
+		codec SessionCodec[T]
+	}
+
+	// SessionCodec encodes the values of a SessionKey
+	SessionCodec[T any] interface {
+		Encode(value T) ([]byte, error)
+		Decode(b []byte) (T, error)
+	}
+
+	// JSONSessionCodec encodes session values as JSON
+	JSONSessionCodec[T any] struct{}
+
+	// GobSessionCodec encodes session values with encoding/gob,
+	// only interface values within T need to be registered with gob.Register
+	GobSessionCodec[T any] struct{}
+)
+
+var (
+	// ErrSessionValueType is returned if a session value can not be decoded into the type of the key,
+	// e.g. after the type has changed with a deployment
+	ErrSessionValueType = errors.New("session value has an unexpected type")
+
+	_ SessionCodec[string] = JSONSessionCodec[string]{}
+	_ SessionCodec[string] = GobSessionCodec[string]{}
+)
+
+// NewSessionKey creates a key whose values are encoded as JSON
+func NewSessionKey[T any](name string) SessionKey[T] {
+	return NewSessionKeyWithCodec[T](name, JSONSessionCodec[T]{})
+}
+
+// NewSessionKeyWithCodec creates a key whose values are encoded by the codec
+func NewSessionKeyWithCodec[T any](name string, codec SessionCodec[T]) SessionKey[T] {
+	return SessionKey[T]{name: name, codec: codec}
+}
+
+// Name of the key in the session
+func (k SessionKey[T]) Name() string {
+	return k.name
+}
+
+// Get the value, values which can not be decoded are reported as not found
+func (k SessionKey[T]) Get(session *Session) (T, bool) {
+	value, found, err := k.Load(session)
+	if err != nil {
+		return value, false
+	}
+
+	return value, found
+}
+
+// Load the value, values which can not be decoded into T return an error wrapping ErrSessionValueType
+func (k SessionKey[T]) Load(session *Session) (value T, found bool, err error) {
+	data, ok := session.Load(k.name)
+	if !ok {
+		return value, false, nil
+	}
+
+	switch data := data.(type) {
+	case []byte:
+		value, err = k.codec.Decode(data)
+		if err != nil {
+			return value, true, fmt.Errorf("session key %q: %w: %w", k.name, ErrSessionValueType, err)
+		}
+		return value, true, nil
+	case T:
+		// stored without a SessionKey, e.g. by Session.Store
+		return data, true, nil
+	default:
+		return value, true, fmt.Errorf("session key %q: %w: %T", k.name, ErrSessionValueType, data)
+	}
+}
+
+// Set the value
+func (k SessionKey[T]) Set(session *Session, value T) error {
+	data, err := k.codec.Encode(value)
+	if err != nil {
+		return fmt.Errorf("session key %q: %w", k.name, err)
+	}
+
+	session.Store(k.name, data)
+
+	return nil
+}
+
+// Delete the value
+func (k SessionKey[T]) Delete(session *Session) {
+	session.Delete(k.name)
+}
+
+// Encode the value as JSON
+func (JSONSessionCodec[T]) Encode(value T) ([]byte, error) {
+	return json.Marshal(value)
+}
+
+// Decode the JSON into T
+func (JSONSessionCodec[T]) Decode(b []byte) (T, error) {
+	var value T
+	err := json.Unmarshal(b, &value)
+
+	return value, err
+}
+
+// Encode the value with gob
+func (GobSessionCodec[T]) Encode(value T) ([]byte, error) {
+	b := new(bytes.Buffer)
+	if err := gob.NewEncoder(b).Encode(&value); err != nil {
+		return nil, err
+	}
+
+	return b.Bytes(), nil
+}
+
+// Decode the gob into T
+func (GobSessionCodec[T]) Decode(b []byte) (T, error) {
+	var value T
+	err := gob.NewDecoder(bytes.NewReader(b)).Decode(&value)
+
+	return value, err
+}
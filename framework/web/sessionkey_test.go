@@ -0,0 +1,77 @@
+package web
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zemirco/memorystore"
+
+	"flamingo.me/flamingo/v3/framework/flamingo"
+)
+
+type sessionKeyTestCart struct {
+	ID    string
+	Items []string
+}
+
+func TestSessionKey(t *testing.T) {
+	for name, key := range map[string]SessionKey[*sessionKeyTestCart]{
+		"json": NewSessionKey[*sessionKeyTestCart]("cart"),
+		"gob":  NewSessionKeyWithCodec[*sessionKeyTestCart]("cart", GobSessionCodec[*sessionKeyTestCart]{}),
+	} {
+		t.Run(name, func(t *testing.T) {
+			sessionStore := &SessionStore{logger: new(flamingo.StdLogger), sessionName: "test", sessionStore: memorystore.NewMemoryStore([]byte("flamingosecret"))}
+
+			session, saveSession := testsession(t, sessionStore)
+			_, found := key.Get(session)
+			assert.False(t, found)
+
+			require.NoError(t, key.Set(session, &sessionKeyTestCart{ID: "1", Items: []string{"a", "b"}}))
+			saveSession()
+
+			session, saveSession = testsession(t, sessionStore)
+			cart, found := key.Get(session)
+			assert.True(t, found)
+			assert.Equal(t, &sessionKeyTestCart{ID: "1", Items: []string{"a", "b"}}, cart)
+
+			key.Delete(session)
+			saveSession()
+
+			session, _ = testsession(t, sessionStore)
+			_, found = key.Get(session)
+			assert.False(t, found)
+		})
+	}
+}
+
+func TestSessionKeyTypeMismatch(t *testing.T) {
+	session := EmptySession()
+
+	require.NoError(t, NewSessionKey[string]("key").Set(session, "value"))
+
+	t.Run("different type", func(t *testing.T) {
+		key := NewSessionKey[int]("key")
+		_, found, err := key.Load(session)
+		assert.True(t, found)
+		assert.ErrorIs(t, err, ErrSessionValueType)
+
+		_, found = key.Get(session)
+		assert.False(t, found)
+	})
+
+	t.Run("untyped value of the same type", func(t *testing.T) {
+		session.Store("untyped", 42)
+		value, found, err := NewSessionKey[int]("untyped").Load(session)
+		assert.NoError(t, err)
+		assert.True(t, found)
+		assert.Equal(t, 42, value)
+	})
+
+	t.Run("untyped value of a different type", func(t *testing.T) {
+		session.Store("untyped", "42")
+		_, found, err := NewSessionKey[int]("untyped").Load(session)
+		assert.True(t, found)
+		assert.ErrorIs(t, err, ErrSessionValueType)
+	})
+}
@@ -7,6 +7,7 @@ import (
 	"net/http"
 
 	"flamingo.me/flamingo/v3/framework/flamingo"
+	"flamingo.me/flamingo/v3/framework/flamingo/sessionstore"
 	"github.com/gorilla/securecookie"
 	"github.com/gorilla/sessions"
 	"github.com/zemirco/memorystore"
@@ -74,6 +75,8 @@ func (s *SessionStore) requestFromID(id string) *http.Request {
 		codecs = s.Codecs
 	case *sessions.FilesystemStore:
 		codecs = s.Codecs
+	case *sessionstore.SQLStore:
+		codecs = s.Codecs
 	}
 
 	cookie := id
@@ -114,6 +117,17 @@ func (s *SessionStore) Save(ctx context.Context, session *Session) (http.Header,
 
 	gs := session.s
 
+	if session.regenerate {
+		if err := s.deleteBackendEntry(gs); err != nil {
+			return nil, err
+		}
+		// an empty id makes the backend generate a new one, all values are persisted since there is nothing to merge
+		gs.ID = ""
+		gs.IsNew = true
+		session.regenerate = false
+		session.hashedid = ""
+	}
+
 	// copy dirty values to new instance and move Values to original session
 	if s.sessionSaveMode != sessionSaveAlways && !session.dirtyAll && session.s.ID != "" {
 		// no dirty data means we do not need to persist anything at all
@@ -146,6 +160,31 @@ func (s *SessionStore) Save(ctx context.Context, session *Session) (http.Header,
 	return rw.Header(), nil
 }
 
+// deleteBackendEntry removes the data of the session id from the backend.
+// Gorilla stores delete sessions with a negative MaxAge, stores which ignore it at least overwrite the data with an empty session.
+func (s *SessionStore) deleteBackendEntry(gs *sessions.Session) error {
+	if gs.ID == "" {
+		return nil
+	}
+
+	options := sessions.Options{}
+	if gs.Options != nil {
+		options = *gs.Options
+	}
+	options.MaxAge = -1
+
+	old := sessions.NewSession(s.sessionStore, gs.Name())
+	old.ID = gs.ID
+	old.Options = &options
+
+	// the cookie of the old session is replaced by the new session, so the headers are discarded
+	if err := s.sessionStore.Save(s.requestFromID(old.ID), headerResponseWriter(make(http.Header)), old); err != nil {
+		return fmt.Errorf("failed to delete session for regeneration: %w", err)
+	}
+
+	return nil
+}
+
 // AddHTTPHeader adds the sources http.Header to the target.
 func AddHTTPHeader(target, source http.Header) {
 	for k, v := range source {
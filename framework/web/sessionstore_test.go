@@ -157,3 +157,40 @@ func TestSessionSavOnWriteDirtyAll(t *testing.T) {
 	session, _ = testsession(t, sessionStore)
 	assert.Equal(t, map[interface{}]interface{}{}, session.s.Values)
 }
+
+func TestSessionRegenerate(t *testing.T) {
+	for name, mode := range map[string]sessionPersistLevel{"always": sessionSaveAlways, "on write": sessionSaveOnWrite} {
+		t.Run(name, func(t *testing.T) {
+			store := memorystore.NewMemoryStore([]byte("flamingosecret"))
+			sessionStore := &SessionStore{logger: new(flamingo.StdLogger), sessionName: "test", sessionStore: store, sessionSaveMode: mode}
+
+			session, saveSession := testsession(t, sessionStore)
+			session.Store("key1", "val0")
+			saveSession()
+			oldID := session.ID()
+			oldHash := session.IDHash()
+
+			session.Regenerate()
+			header, err := sessionStore.Save(context.Background(), session)
+			assert.NoError(t, err)
+			assert.NotEqual(t, oldID, session.ID())
+			assert.NotEqual(t, oldHash, session.IDHash())
+			assert.Len(t, header.Values("Set-Cookie"), 1)
+
+			regenerated, err := sessionStore.LoadByID(context.Background(), session.ID())
+			assert.NoError(t, err)
+			assert.Equal(t, map[interface{}]interface{}{"key1": "val0"}, regenerated.s.Values)
+
+			old, err := sessionStore.LoadByID(context.Background(), oldID)
+			assert.NoError(t, err)
+			assert.Empty(t, old.s.Values)
+
+			// the id is only regenerated once
+			id := session.ID()
+			session.Store("key2", "val1")
+			_, err = sessionStore.Save(context.Background(), session)
+			assert.NoError(t, err)
+			assert.Equal(t, id, session.ID())
+		})
+	}
+}
@@ -0,0 +1,208 @@
+package web
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type (
+	// StreamWriter writes the body of a streamed response, written data is sent to the client on Flush
+	StreamWriter interface {
+		io.Writer
+		// Flush sends all buffered data to the client
+		Flush() error
+	}
+
+	// StreamFunc writes the body of a StreamResponse, the context is canceled if the client disconnects
+	StreamFunc func(ctx context.Context, w StreamWriter) error
+
+	// StreamResponse writes its body directly to the client instead of providing a body reader
+	StreamResponse struct {
+		Response
+		stream StreamFunc
+	}
+
+	// Event is a single server-sent event
+	Event struct {
+		// ID sets the last event ID of the client, omitted if empty
+		ID string
+		// Event is the event type, omitted if empty
+		Event string
+		// Data of the event, multiple lines are sent as multiple data fields
+		Data string
+		// Retry tells the client the reconnection time, omitted if 0
+		Retry time.Duration
+	}
+
+	// SSEResponse sends server-sent events until the event channel is closed or the client disconnects
+	SSEResponse struct {
+		Response
+		events <-chan Event
+		// KeepAlive sends a comment in the given interval to keep the connection open, 0 disables it
+		KeepAlive time.Duration
+	}
+
+	streamWriter struct {
+		rw         http.ResponseWriter
+		controller *http.ResponseController
+	}
+)
+
+var (
+	_ Result       = new(StreamResponse)
+	_ Result       = new(SSEResponse)
+	_ StreamWriter = new(streamWriter)
+)
+
+// Stream creates a response which writes its body with the given function
+func (r *Responder) Stream(stream StreamFunc) *StreamResponse {
+	return &StreamResponse{
+		stream: stream,
+		Response: Response{
+			Status: http.StatusOK,
+			Header: make(http.Header),
+		},
+	}
+}
+
+// Apply response, headers are sent before the stream function is called
+func (r *StreamResponse) Apply(ctx context.Context, w http.ResponseWriter) error {
+	r.Body = nil
+	if err := r.Response.Apply(ctx, w); err != nil {
+		return err
+	}
+
+	if r.stream == nil {
+		return nil
+	}
+
+	err := r.stream(ctx, newStreamWriter(w))
+	if ctx.Err() != nil {
+		// the client is gone, there is nobody left to report the error to
+		return nil
+	}
+
+	return err
+}
+
+// Status changes response status code
+func (r *StreamResponse) Status(status uint) *StreamResponse {
+	r.Response.Status = status
+	return r
+}
+
+// SSE creates a response which sends all events of the channel as server-sent events
+func (r *Responder) SSE(events <-chan Event) *SSEResponse {
+	return &SSEResponse{
+		events: events,
+		Response: Response{
+			Status: http.StatusOK,
+			Header: make(http.Header),
+		},
+	}
+}
+
+// Apply response
+func (r *SSEResponse) Apply(ctx context.Context, w http.ResponseWriter) error {
+	if r.Header == nil {
+		r.Header = make(http.Header)
+	}
+	r.Header.Set("Content-Type", "text/event-stream")
+	r.Header.Set("Cache-Control", "no-cache")
+	r.Header.Set("X-Accel-Buffering", "no")
+	r.Body = nil
+
+	if err := r.Response.Apply(ctx, w); err != nil {
+		return err
+	}
+
+	sw := newStreamWriter(w)
+	if err := sw.Flush(); err != nil {
+		return err
+	}
+
+	var keepAlive <-chan time.Time
+	if r.KeepAlive > 0 {
+		ticker := time.NewTicker(r.KeepAlive)
+		defer ticker.Stop()
+		keepAlive = ticker.C
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-keepAlive:
+			if _, err := io.WriteString(sw, ":\n\n"); err != nil {
+				return ignoreCanceled(ctx, err)
+			}
+		case event, ok := <-r.events:
+			if !ok {
+				return nil
+			}
+			if _, err := event.WriteTo(sw); err != nil {
+				return ignoreCanceled(ctx, err)
+			}
+		}
+
+		if err := sw.Flush(); err != nil {
+			return ignoreCanceled(ctx, err)
+		}
+	}
+}
+
+// WriteTo writes the event in the text/event-stream format
+func (e Event) WriteTo(w io.Writer) (int64, error) {
+	b := new(strings.Builder)
+
+	if e.ID != "" {
+		b.WriteString("id: " + singleLine(e.ID) + "\n")
+	}
+	if e.Event != "" {
+		b.WriteString("event: " + singleLine(e.Event) + "\n")
+	}
+	if e.Retry > 0 {
+		b.WriteString("retry: " + strconv.FormatInt(e.Retry.Milliseconds(), 10) + "\n")
+	}
+	for _, line := range strings.Split(strings.ReplaceAll(e.Data, "\r\n", "\n"), "\n") {
+		b.WriteString("data: " + line + "\n")
+	}
+	b.WriteString("\n")
+
+	n, err := io.WriteString(w, b.String())
+	return int64(n), err
+}
+
+func singleLine(s string) string {
+	return strings.NewReplacer("\r", "", "\n", "").Replace(s)
+}
+
+func ignoreCanceled(ctx context.Context, err error) error {
+	if ctx.Err() != nil {
+		return nil
+	}
+	return err
+}
+
+func newStreamWriter(rw http.ResponseWriter) *streamWriter {
+	return &streamWriter{rw: rw, controller: http.NewResponseController(rw)}
+}
+
+// Write to the underlying response writer
+func (s *streamWriter) Write(p []byte) (int, error) {
+	return s.rw.Write(p)
+}
+
+// Flush the underlying response writer, response writers without flush support are ignored
+func (s *streamWriter) Flush() error {
+	if err := s.controller.Flush(); err != nil && !errors.Is(err, http.ErrNotSupported) {
+		return fmt.Errorf("flush failed: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,185 @@
+package web
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"flamingo.me/flamingo/v3/framework/flamingo"
+)
+
+// wrappingFilter wraps the response writer, like the requestlogger or metrics filter do
+type wrappingFilter struct{}
+
+type wrappedResponseWriter struct {
+	http.ResponseWriter
+}
+
+func (w *wrappedResponseWriter) Flush() {
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+type wrappedResult struct {
+	result Result
+}
+
+func (r *wrappedResult) Apply(ctx context.Context, rw http.ResponseWriter) error {
+	return r.result.Apply(ctx, &wrappedResponseWriter{ResponseWriter: rw})
+}
+
+func (wrappingFilter) Filter(ctx context.Context, req *Request, w http.ResponseWriter, chain *FilterChain) Result {
+	return &wrappedResult{result: chain.Next(ctx, req, w)}
+}
+
+func streamTestServer(t *testing.T, action Action) *httptest.Server {
+	t.Helper()
+
+	registry := NewRegistry()
+	registry.HandleGet("stream", action)
+	registry.MustRoute("/stream", "stream")
+
+	router := &Router{
+		eventRouter:    new(flamingo.DefaultEventRouter),
+		filterProvider: func() []Filter { return []Filter{wrappingFilter{}} },
+		routesProvider: func() []RoutesModule { return nil },
+		logger:         flamingo.NullLogger{},
+	}
+	h := router.Handler()
+	h.(*handler).routerRegistry = registry
+
+	server := httptest.NewServer(h)
+	t.Cleanup(server.Close)
+
+	return server
+}
+
+func TestStreamResponse(t *testing.T) {
+	t.Run("flushes partial output", func(t *testing.T) {
+		proceed := make(chan struct{})
+		server := streamTestServer(t, func(ctx context.Context, r *Request) Result {
+			return new(Responder).Stream(func(ctx context.Context, w StreamWriter) error {
+				if _, err := io.WriteString(w, "first\n"); err != nil {
+					return err
+				}
+				if err := w.Flush(); err != nil {
+					return err
+				}
+				<-proceed
+				_, err := io.WriteString(w, "second\n")
+				return err
+			})
+		})
+
+		response, err := http.Get(server.URL + "/stream")
+		require.NoError(t, err)
+		defer response.Body.Close()
+
+		reader := bufio.NewReader(response.Body)
+		line, err := reader.ReadString('\n')
+		require.NoError(t, err)
+		assert.Equal(t, "first\n", line, "first chunk must arrive before the stream finishes")
+
+		close(proceed)
+		rest, err := io.ReadAll(reader)
+		require.NoError(t, err)
+		assert.Equal(t, "second\n", string(rest))
+	})
+
+	t.Run("canceled on client disconnect", func(t *testing.T) {
+		done := make(chan error, 1)
+		server := streamTestServer(t, func(ctx context.Context, r *Request) Result {
+			return new(Responder).Stream(func(ctx context.Context, w StreamWriter) error {
+				if err := w.Flush(); err != nil {
+					return err
+				}
+				<-ctx.Done()
+				done <- ctx.Err()
+				return ctx.Err()
+			})
+		})
+
+		ctx, cancel := context.WithCancel(context.Background())
+		request, _ := http.NewRequestWithContext(ctx, http.MethodGet, server.URL+"/stream", nil)
+		response, err := http.DefaultClient.Do(request)
+		require.NoError(t, err)
+		cancel()
+		_ = response.Body.Close()
+
+		select {
+		case err := <-done:
+			assert.ErrorIs(t, err, context.Canceled)
+		case <-time.After(5 * time.Second):
+			t.Fatal("stream was not canceled")
+		}
+	})
+}
+
+func TestSSEResponse(t *testing.T) {
+	t.Run("sends events", func(t *testing.T) {
+		events := make(chan Event)
+		server := streamTestServer(t, func(ctx context.Context, r *Request) Result {
+			return new(Responder).SSE(events)
+		})
+
+		response, err := http.Get(server.URL + "/stream")
+		require.NoError(t, err)
+		defer response.Body.Close()
+
+		assert.Equal(t, "text/event-stream", response.Header.Get("Content-Type"))
+		assert.Equal(t, "no-cache", response.Header.Get("Cache-Control"))
+
+		reader := bufio.NewReader(response.Body)
+		readEvent := func() string {
+			var event string
+			for {
+				line, err := reader.ReadString('\n')
+				require.NoError(t, err)
+				if line == "\n" {
+					return event
+				}
+				event += line
+			}
+		}
+
+		events <- Event{ID: "1", Event: "update", Data: "hello\nworld", Retry: time.Second}
+		assert.Equal(t, "id: 1\nevent: update\nretry: 1000\ndata: hello\ndata: world\n", readEvent())
+
+		events <- Event{Data: "plain"}
+		assert.Equal(t, "data: plain\n", readEvent())
+
+		close(events)
+		rest, err := io.ReadAll(reader)
+		require.NoError(t, err)
+		assert.Empty(t, rest)
+	})
+
+	t.Run("stops on client disconnect", func(t *testing.T) {
+		responder := new(Responder)
+		sse := responder.SSE(make(chan Event))
+		sse.KeepAlive = time.Millisecond
+
+		ctx, cancel := context.WithCancel(context.Background())
+		done := make(chan error, 1)
+		recorder := httptest.NewRecorder()
+		go func() {
+			done <- sse.Apply(ctx, recorder)
+		}()
+		cancel()
+
+		select {
+		case err := <-done:
+			assert.NoError(t, err)
+		case <-time.After(5 * time.Second):
+			t.Fatal("sse was not stopped")
+		}
+	})
+}
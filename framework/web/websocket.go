@@ -0,0 +1,78 @@
+package web
+
+import (
+	"bufio"
+	"context"
+	"net"
+	"net/http"
+
+	"github.com/gorilla/websocket"
+	"go.opencensus.io/trace"
+)
+
+type (
+	// WebSocketAction handles an upgraded WebSocket connection, the connection is closed when the action returns.
+	// The request carries the loaded session, the action should read from the connection to notice when the client is gone.
+	WebSocketAction func(ctx context.Context, req *Request, conn *websocket.Conn)
+
+	// WebSocketResponse upgrades the request to a WebSocket connection when it is applied, after all filters ran
+	WebSocketResponse struct {
+		action   WebSocketAction
+		request  *Request
+		upgrader *websocket.Upgrader
+	}
+
+	// hijackResponseWriter makes wrapped response writers hijackable, as long as they can be unwrapped
+	hijackResponseWriter struct {
+		http.ResponseWriter
+		controller *http.ResponseController
+	}
+)
+
+var _ Result = new(WebSocketResponse)
+
+// HandleWebSocket registers an action which upgrades GET requests to a WebSocket connection.
+// The filter chain runs before the upgrade, so filters can still deny the request.
+func (registry *RouterRegistry) HandleWebSocket(name string, action WebSocketAction) {
+	registry.HandleWebSocketWithUpgrader(name, new(websocket.Upgrader), action)
+}
+
+// HandleWebSocketWithUpgrader registers a WebSocket action which is upgraded by the given upgrader,
+// e.g. to allow other origins, negotiate subprotocols or change the buffer sizes.
+func (registry *RouterRegistry) HandleWebSocketWithUpgrader(name string, upgrader *websocket.Upgrader, action WebSocketAction) {
+	registry.HandleGet(name, func(ctx context.Context, req *Request) Result {
+		return &WebSocketResponse{action: action, request: req, upgrader: upgrader}
+	})
+}
+
+// Apply response by upgrading the connection and running the action
+func (r *WebSocketResponse) Apply(ctx context.Context, w http.ResponseWriter) error {
+	if _, ok := w.(http.Hijacker); !ok {
+		w = &hijackResponseWriter{ResponseWriter: w, controller: http.NewResponseController(w)}
+	}
+
+	// the session cookie has already been set by the handler, pass it on to the handshake response
+	var header http.Header
+	if cookies := w.Header().Values("Set-Cookie"); len(cookies) > 0 {
+		header = http.Header{"Set-Cookie": cookies}
+	}
+
+	conn, err := r.upgrader.Upgrade(w, r.request.Request(), header)
+	if err != nil {
+		// the upgrader already answered the request with an error status
+		return nil
+	}
+	defer conn.Close()
+
+	ctx, span := trace.StartSpan(ctx, "flamingo/web/websocket")
+	defer span.End()
+
+	r.action(ctx, r.request, conn)
+
+	return nil
+}
+
+// Hijack the connection of the innermost response writer
+func (w *hijackResponseWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	return w.controller.Hijack()
+}
@@ -0,0 +1,148 @@
+package web
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opencensus.io/trace"
+
+	"flamingo.me/flamingo/v3/framework/flamingo"
+)
+
+type denyFilter struct{}
+
+func (denyFilter) Filter(ctx context.Context, req *Request, w http.ResponseWriter, chain *FilterChain) Result {
+	if req.Request().URL.Query().Get("deny") != "" {
+		return &Response{Status: http.StatusForbidden}
+	}
+	return chain.Next(ctx, req, w)
+}
+
+// unwrappingResponseWriter neither flushes nor hijacks, but can be unwrapped
+type unwrappingResponseWriter struct {
+	http.ResponseWriter
+}
+
+func (w *unwrappingResponseWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+type unwrappingFilter struct{}
+
+func (unwrappingFilter) Filter(ctx context.Context, req *Request, w http.ResponseWriter, chain *FilterChain) Result {
+	result := chain.Next(ctx, req, w)
+	return resultFunc(func(ctx context.Context, rw http.ResponseWriter) error {
+		return result.Apply(ctx, &unwrappingResponseWriter{ResponseWriter: rw})
+	})
+}
+
+type resultFunc func(ctx context.Context, rw http.ResponseWriter) error
+
+func (f resultFunc) Apply(ctx context.Context, rw http.ResponseWriter) error {
+	return f(ctx, rw)
+}
+
+func TestHandleWebSocket(t *testing.T) {
+	var log []string
+
+	registry := NewRegistry()
+	registry.HandleWebSocket("echo", func(ctx context.Context, req *Request, conn *websocket.Conn) {
+		log = append(log, "upgraded")
+		assert.NotNil(t, req.Session())
+		assert.NotNil(t, trace.FromContext(ctx), "the action runs in a traced context")
+
+		for {
+			messageType, message, err := conn.ReadMessage()
+			if err != nil {
+				return
+			}
+			if err := conn.WriteMessage(messageType, []byte(strings.ToUpper(string(message)))); err != nil {
+				return
+			}
+		}
+	})
+	registry.MustRoute("/ws", "echo")
+	registry.HandleWebSocketWithUpgrader("chat", &websocket.Upgrader{
+		Subprotocols: []string{"chat"},
+		CheckOrigin: func(r *http.Request) bool {
+			return r.Header.Get("Origin") == "https://app.test"
+		},
+	}, func(ctx context.Context, req *Request, conn *websocket.Conn) {
+		_ = conn.WriteMessage(websocket.TextMessage, []byte(conn.Subprotocol()))
+	})
+	registry.MustRoute("/chat", "chat")
+
+	router := &Router{
+		eventRouter: new(flamingo.DefaultEventRouter),
+		filterProvider: func() []Filter {
+			return []Filter{&recordingFilter{name: "filter", log: &log}, denyFilter{}, unwrappingFilter{}}
+		},
+		routesProvider: func() []RoutesModule { return nil },
+		logger:         flamingo.NullLogger{},
+	}
+	h := router.Handler()
+	h.(*handler).routerRegistry = registry
+
+	server := httptest.NewServer(h)
+	defer server.Close()
+
+	wsURL := "ws" + strings.TrimPrefix(server.URL, "http") + "/ws"
+
+	t.Run("echo", func(t *testing.T) {
+		log = nil
+		conn, response, err := websocket.DefaultDialer.Dial(wsURL, nil)
+		require.NoError(t, err)
+		defer conn.Close()
+		assert.Equal(t, http.StatusSwitchingProtocols, response.StatusCode)
+
+		require.NoError(t, conn.WriteMessage(websocket.TextMessage, []byte("hello")))
+		_, message, err := conn.ReadMessage()
+		require.NoError(t, err)
+		assert.Equal(t, "HELLO", string(message))
+
+		assert.Equal(t, []string{"filter", "upgraded"}, log, "filters run before the upgrade")
+	})
+
+	t.Run("filters can deny the upgrade", func(t *testing.T) {
+		log = nil
+		_, response, err := websocket.DefaultDialer.Dial(wsURL+"?deny=1", nil)
+		require.ErrorIs(t, err, websocket.ErrBadHandshake)
+		assert.Equal(t, http.StatusForbidden, response.StatusCode)
+		assert.Equal(t, []string{"filter"}, log)
+	})
+
+	t.Run("other origins are rejected by default", func(t *testing.T) {
+		_, response, err := websocket.DefaultDialer.Dial(wsURL, http.Header{"Origin": {"https://app.test"}})
+		require.ErrorIs(t, err, websocket.ErrBadHandshake)
+		assert.Equal(t, http.StatusForbidden, response.StatusCode)
+	})
+
+	t.Run("custom upgrader", func(t *testing.T) {
+		chatURL := "ws" + strings.TrimPrefix(server.URL, "http") + "/chat"
+		dialer := &websocket.Dialer{Subprotocols: []string{"chat"}}
+
+		conn, _, err := dialer.Dial(chatURL, http.Header{"Origin": {"https://app.test"}})
+		require.NoError(t, err)
+		defer conn.Close()
+		_, message, err := conn.ReadMessage()
+		require.NoError(t, err)
+		assert.Equal(t, "chat", string(message), "the subprotocol is negotiated")
+
+		_, response, err := dialer.Dial(chatURL, http.Header{"Origin": {"https://evil.test"}})
+		require.ErrorIs(t, err, websocket.ErrBadHandshake)
+		assert.Equal(t, http.StatusForbidden, response.StatusCode)
+	})
+
+	t.Run("plain requests are rejected", func(t *testing.T) {
+		response, err := http.Get(server.URL + "/ws")
+		require.NoError(t, err)
+		_ = response.Body.Close()
+		assert.Equal(t, http.StatusBadRequest, response.StatusCode)
+	})
+}